@@ -1,39 +1,13 @@
 package gfx
 
-import (
-	"sort"
-)
-
-func Partition(starts, ends []float64) (ranges []Range) {
-	events := append(starts, ends...)
-	sort.Float64s(events)
-
-	contains := func(set []float64, e float64) bool {
-		for _, s := range set {
-			if s == e {
-				return true
-			}
-		}
-		return false
-	}
-
-	count, start := 0, 0.0
-	for _, evt := range events {
-		if contains(starts, evt) {
-			if count == 0 {
-				start = evt
-			}
-			count++
-		}
-		if contains(ends, evt) {
-			count--
-		}
-		if count == 0 {
-			ranges = append(ranges, Range{start, evt})
-		}
+// Partition returns the union of the ranges [starts[i], ends[i]] as sorted,
+// disjoint ranges. Ranges that touch are merged.
+func Partition(starts, ends []float64) []Range {
+	ranges := make([]Range, len(starts))
+	for i := range starts {
+		ranges[i] = Range{starts[i], ends[i]}
 	}
-
-	return
+	return MakeRangeSet(ranges...)
 }
 
 func PartitionRectRows(rects Rects) (results Rects) {
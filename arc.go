@@ -0,0 +1,168 @@
+package gfx
+
+import "math"
+
+// ellipticalArc adds the arc of the ellipse centered at (cx, cy) with radii
+// rx and ry, its x axis rotated by rotation radians, from angle start
+// sweeping by sweep radians, as cubic curves of at most a quarter turn each.
+// The path must already be at the arc's start point.
+func (p *Path) ellipticalArc(cx, cy, rx, ry, rotation, start, sweep float64) {
+	n := int(math.Ceil(math.Abs(sweep)/(math.Pi/2) - 1e-9))
+	if n < 1 {
+		n = 1
+	}
+	delta := sweep / float64(n)
+	k := 4.0 / 3 * math.Tan(delta/4)
+
+	sin, cos := math.Sincos(rotation)
+	point := func(x, y float64) (float64, float64) {
+		return cx + rx*x*cos - ry*y*sin, cy + rx*x*sin + ry*y*cos
+	}
+
+	a := start
+	for i := 0; i < n; i++ {
+		b := a + delta
+		sa, ca := quadrantSincos(a)
+		sb, cb := quadrantSincos(b)
+		x1, y1 := point(ca-k*sa, sa+k*ca)
+		x2, y2 := point(cb+k*sb, sb-k*cb)
+		x3, y3 := point(cb, sb)
+		p.CubicCurveTo(x1, y1, x2, y2, x3, y3)
+		a = b
+	}
+}
+
+// quadrantSincos is math.Sincos with the rounding error at whole quarter
+// turns removed, so that arcs meeting the axes of their ellipse land on them
+// exactly.
+func quadrantSincos(a float64) (sin, cos float64) {
+	sin, cos = math.Sincos(a)
+	snap := func(v float64) float64 {
+		if math.Abs(v) < 1e-15 {
+			return 0
+		}
+		return v
+	}
+	return snap(sin), snap(cos)
+}
+
+// svgArcTo adds an elliptical arc in SVG's endpoint form: from (x0, y0),
+// where the path must be, to (x, y) on an ellipse with radii rx and ry
+// rotated by rotation degrees, taking the larger or smaller of the possible
+// arcs as large says and running counter-clockwise in y-up coordinates if
+// sweep is set. Radii too small to reach are scaled up, and a zero radius
+// draws a line.
+func (p *Path) svgArcTo(x0, y0, rx, ry, rotation float64, large, sweep bool, x, y float64) {
+	if x0 == x && y0 == y {
+		return
+	}
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 {
+		p.LineTo(x, y)
+		return
+	}
+
+	// the conversion from endpoint to center parameterization of SVG 1.1,
+	// appendix F.6.5
+	phi := rotation * math.Pi / 180
+	sin, cos := math.Sincos(phi)
+	dx, dy := (x0-x)/2, (y0-y)/2
+	x1, y1 := cos*dx+sin*dy, -sin*dx+cos*dy
+
+	if lambda := x1*x1/(rx*rx) + y1*y1/(ry*ry); lambda > 1 {
+		rx, ry = rx*math.Sqrt(lambda), ry*math.Sqrt(lambda)
+	}
+
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	coef := math.Sqrt(math.Max(0, num/den))
+	if large == sweep {
+		coef = -coef
+	}
+	cx1, cy1 := coef*rx*y1/ry, -coef*ry*x1/rx
+	cx, cy := cos*cx1-sin*cy1+(x0+x)/2, sin*cx1+cos*cy1+(y0+y)/2
+
+	start := math.Atan2((y1-cy1)/ry, (x1-cx1)/rx)
+	end := math.Atan2((-y1-cy1)/ry, (-x1-cx1)/rx)
+	delta := math.Mod(end-start, 2*math.Pi)
+	if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	} else if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	}
+
+	p.ellipticalArc(cx, cy, rx, ry, phi, start, delta)
+	// land exactly on the end point
+	p.Points[len(p.Points)-1] = Point{x, y}
+	p.x, p.y = x, y
+}
+
+// ArcTo adds an arc of the ellipse centered at (cx, cy) with radii rx and ry,
+// from angle start turning by angle radians, counter-clockwise in y-up
+// coordinates for positive angles. A line joins the current point to the
+// start of the arc; on an empty path the arc starts a subpath instead. The
+// arc is made of cubic curves of at most a quarter turn each, which stay
+// within 0.03% of the radius.
+func (p *Path) ArcTo(cx, cy, rx, ry, start, angle float64) {
+	sin, cos := math.Sincos(start)
+	x0, y0 := cx+rx*cos, cy+ry*sin
+	if len(p.Components) == 0 {
+		p.MoveTo(x0, y0)
+	} else if x, y := p.LastPoint(); x != x0 || y != y0 {
+		p.LineTo(x0, y0)
+	}
+	if angle != 0 && rx != 0 && ry != 0 {
+		p.ellipticalArc(cx, cy, rx, ry, 0, start, angle)
+	}
+}
+
+// TangentArcTo adds a circular arc of the given radius touching both the
+// line from the current point to (x1, y1) and the line from there to
+// (x2, y2), joined to the current point with a line, as PostScript's arct
+// does. Where the lines are parallel or the radius is zero it adds a line to
+// (x1, y1).
+func (p *Path) TangentArcTo(x1, y1, x2, y2, radius float64) {
+	if len(p.Components) == 0 {
+		p.MoveTo(x1, y1)
+		return
+	}
+
+	x0, y0 := p.LastPoint()
+	corner := Point{x1, y1}
+	d0, d1 := Point{x0, y0}.Sub(corner), Point{x2, y2}.Sub(corner)
+	if radius <= 0 || d0.Norm() == 0 || d1.Norm() == 0 || math.Abs(d0.Cross(d1)) <= 1e-12*d0.Norm()*d1.Norm() {
+		p.LineTo(x1, y1)
+		return
+	}
+	// a left turn at the corner takes a counter-clockwise arc
+	turn := d1.Cross(d0)
+	d0, d1 = d0.Normalize(), d1.Normalize()
+
+	// the arc touches each line half the corner's angle away from it
+	half := math.Acos(math.Max(-1, math.Min(1, d0.Dot(d1)))) / 2
+	t0 := corner.Add(d0.Mul(radius / math.Tan(half)))
+	t1 := corner.Add(d1.Mul(radius / math.Tan(half)))
+	center := corner.Add(d0.Add(d1).Normalize().Mul(radius / math.Sin(half)))
+
+	p.LineTo(t0.X, t0.Y)
+	start := math.Atan2(t0.Y-center.Y, t0.X-center.X)
+	sweep := math.Copysign(math.Pi-2*half, turn)
+	p.ellipticalArc(center.X, center.Y, radius, radius, 0, start, sweep)
+	p.Points[len(p.Points)-1] = t1
+	p.x, p.y = t1.X, t1.Y
+}
+
+// Ellipse adds the ellipse centered at (cx, cy) with radii rx and ry as a
+// closed subpath running counter-clockwise in y-up coordinates from its
+// rightmost point.
+func (p *Path) Ellipse(cx, cy, rx, ry float64) {
+	p.MoveTo(cx+rx, cy)
+	p.ellipticalArc(cx, cy, rx, ry, 0, 0, 2*math.Pi)
+	p.Close()
+}
+
+// Circle adds the circle centered at (cx, cy) with radius r as a closed
+// subpath, as Ellipse does.
+func (p *Path) Circle(cx, cy, r float64) {
+	p.Ellipse(cx, cy, r, r)
+}
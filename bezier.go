@@ -0,0 +1,511 @@
+package gfx
+
+import (
+	"math"
+	"sort"
+)
+
+// QuadBezier is a quadratic Bézier curve given by its start point, control
+// point and end point.
+type QuadBezier [3]Point
+
+// CubicBezier is a cubic Bézier curve given by its start point, two control
+// points and end point.
+type CubicBezier [4]Point
+
+// CurveIntersection is a point where a curve meets another curve or a line,
+// with the parameter of the point on each.
+type CurveIntersection struct {
+	Point Point
+	T, U  float64
+}
+
+// Eval returns the point on the curve at t.
+func (q QuadBezier) Eval(t float64) Point { return bezierPoint(q[:], t) }
+
+// Derivative returns the derivative of the curve at t.
+func (q QuadBezier) Derivative(t float64) Point { return bezierDerivative(q[:], t) }
+
+// SplitAt returns the parts of the curve before and after t.
+func (q QuadBezier) SplitAt(t float64) (QuadBezier, QuadBezier) {
+	var a, b QuadBezier
+	copy(a[:], splitBezier(q[:], t))
+	copy(b[:], splitBezierRight(q[:], t))
+	return a, b
+}
+
+// Extrema returns the parameters strictly between 0 and 1 where the curve
+// turns in x or in y, in increasing order.
+func (q QuadBezier) Extrema() []float64 { return bezierExtrema(q[:]) }
+
+// Bounds returns the exact bounds of the curve.
+func (q QuadBezier) Bounds() Rect { return q.Elevate().Bounds() }
+
+// ArcLength returns the length of the curve.
+func (q QuadBezier) ArcLength() float64 { return newMeasuredSegment(q[:]).length }
+
+// NearestPoint returns the parameter and position of the point on the curve
+// nearest to pt.
+func (q QuadBezier) NearestPoint(pt Point) (float64, Point) { return bezierNearest(q[:], pt) }
+
+// Elevate returns the cubic curve tracing the same path.
+func (q QuadBezier) Elevate() CubicBezier {
+	return CubicBezier{q[0], q[0].Add(q[1].Sub(q[0]).Mul(2.0 / 3)), q[2].Add(q[1].Sub(q[2]).Mul(2.0 / 3)), q[2]}
+}
+
+// IntersectLine returns the points where the curve crosses or touches the
+// segment l, U being the parameter along l. A curve lying along l has none.
+func (q QuadBezier) IntersectLine(l Line) []CurveIntersection {
+	return bezierLineIntersections(q[:], l)
+}
+
+// IntersectQuad returns the points where the curve meets o, U being the
+// parameter on o. Where the curves overlap along a stretch only the ends of
+// the stretch are returned.
+func (q QuadBezier) IntersectQuad(o QuadBezier) []CurveIntersection {
+	return bezierIntersections(q[:], o[:])
+}
+
+// IntersectCubic returns the points where the curve meets the cubic curve o,
+// U being the parameter on o, as IntersectQuad does.
+func (q QuadBezier) IntersectCubic(o CubicBezier) []CurveIntersection {
+	return bezierIntersections(q[:], o[:])
+}
+
+// Eval returns the point on the curve at t.
+func (c CubicBezier) Eval(t float64) Point { return bezierPoint(c[:], t) }
+
+// Derivative returns the derivative of the curve at t.
+func (c CubicBezier) Derivative(t float64) Point { return bezierDerivative(c[:], t) }
+
+// SplitAt returns the parts of the curve before and after t.
+func (c CubicBezier) SplitAt(t float64) (CubicBezier, CubicBezier) {
+	var a, b CubicBezier
+	copy(a[:], splitBezier(c[:], t))
+	copy(b[:], splitBezierRight(c[:], t))
+	return a, b
+}
+
+// Extrema returns the parameters strictly between 0 and 1 where the curve
+// turns in x or in y, in increasing order.
+func (c CubicBezier) Extrema() []float64 { return bezierExtrema(c[:]) }
+
+// Inflections returns the parameters strictly between 0 and 1 where the
+// curvature of the curve changes sign, in increasing order.
+func (c CubicBezier) Inflections() []float64 {
+	// with B'(t) = 3(a + 2bt + dt²) and B''(t) = 6(b + dt), the curvature
+	// has the sign of a×b + (a×d)t + (b×d)t²
+	a := c[1].Sub(c[0])
+	b := c[2].Sub(c[1].Mul(2)).Add(c[0])
+	d := c[3].Sub(c[2].Mul(3)).Add(c[1].Mul(3)).Sub(c[0])
+	return unitRoots(quadraticRoots(b.Cross(d), a.Cross(d), a.Cross(b)))
+}
+
+// Bounds returns the exact bounds of the curve.
+func (c CubicBezier) Bounds() Rect {
+	return bezierBounds(c[0].X, c[0].Y, c[1].X, c[1].Y, c[2].X, c[2].Y, c[3].X, c[3].Y)
+}
+
+// ArcLength returns the length of the curve.
+func (c CubicBezier) ArcLength() float64 { return newMeasuredSegment(c[:]).length }
+
+// NearestPoint returns the parameter and position of the point on the curve
+// nearest to pt.
+func (c CubicBezier) NearestPoint(pt Point) (float64, Point) { return bezierNearest(c[:], pt) }
+
+// Lower returns the quadratic curve closest to c sharing its end points, and
+// a bound on the distance between the two curves, which is zero for a cubic
+// elevated from a quadratic.
+func (c CubicBezier) Lower() (QuadBezier, float64) {
+	control := c[1].Add(c[2]).Mul(3).Sub(c[0]).Sub(c[3]).Mul(0.25)
+	d := c[3].Sub(c[2].Mul(3)).Add(c[1].Mul(3)).Sub(c[0])
+	return QuadBezier{c[0], control, c[3]}, math.Sqrt(3) / 36 * d.Norm()
+}
+
+// IntersectLine returns the points where the curve crosses or touches the
+// segment l, U being the parameter along l. A curve lying along l has none.
+func (c CubicBezier) IntersectLine(l Line) []CurveIntersection {
+	return bezierLineIntersections(c[:], l)
+}
+
+// IntersectCubic returns the points where the curve meets o, U being the
+// parameter on o. Where the curves overlap along a stretch only the ends of
+// the stretch are returned.
+func (c CubicBezier) IntersectCubic(o CubicBezier) []CurveIntersection {
+	return bezierIntersections(c[:], o[:])
+}
+
+// IntersectQuad returns the points where the curve meets the quadratic curve
+// o, U being the parameter on o, as IntersectCubic does.
+func (c CubicBezier) IntersectQuad(o QuadBezier) []CurveIntersection {
+	return bezierIntersections(c[:], o[:])
+}
+
+// bezierPoint evaluates the line, quadratic or cubic Bézier curve with the
+// given control points at t.
+func bezierPoint(p []Point, t float64) Point {
+	mt := 1 - t
+	switch len(p) {
+	case 3:
+		return p[0].Mul(mt * mt).Add(p[1].Mul(2 * mt * t)).Add(p[2].Mul(t * t))
+	case 4:
+		return p[0].Mul(mt * mt * mt).Add(p[1].Mul(3 * mt * mt * t)).Add(p[2].Mul(3 * mt * t * t)).Add(p[3].Mul(t * t * t))
+	default:
+		return p[0].Add(p[1].Sub(p[0]).Mul(t))
+	}
+}
+
+// bezierDerivative returns the derivative of the line, quadratic or cubic
+// Bézier curve with the given control points at t.
+func bezierDerivative(p []Point, t float64) Point {
+	mt := 1 - t
+	switch len(p) {
+	case 3:
+		return p[1].Sub(p[0]).Mul(2 * mt).Add(p[2].Sub(p[1]).Mul(2 * t))
+	case 4:
+		return p[1].Sub(p[0]).Mul(3 * mt * mt).Add(p[2].Sub(p[1]).Mul(6 * mt * t)).Add(p[3].Sub(p[2]).Mul(3 * t * t))
+	default:
+		return p[1].Sub(p[0])
+	}
+}
+
+// bezierPiece returns the control points of the part of a Bézier curve
+// between parameters a < b.
+func bezierPiece(points []Point, a, b float64) []Point {
+	left := splitBezier(points, b)
+	if b == 0 {
+		return left
+	}
+	return splitBezierRight(left, a/b)
+}
+
+// splitBezier returns the control points of the part of a Bézier curve before t.
+func splitBezier(points []Point, t float64) []Point {
+	left := make([]Point, len(points))
+	work := append([]Point(nil), points...)
+	for i := range points {
+		left[i] = work[0]
+		for j := 0; j < len(work)-1-i; j++ {
+			work[j] = work[j].Add(work[j+1].Sub(work[j]).Mul(t))
+		}
+	}
+	return left
+}
+
+// splitBezierRight returns the control points of the part of a Bézier curve
+// after t.
+func splitBezierRight(points []Point, t float64) []Point {
+	n := len(points)
+	right := make([]Point, n)
+	work := append([]Point(nil), points...)
+	for i := range points {
+		right[n-1-i] = work[n-1-i]
+		for j := 0; j < n-1-i; j++ {
+			work[j] = work[j].Add(work[j+1].Sub(work[j]).Mul(t))
+		}
+	}
+	return right
+}
+
+// bezierTurns returns the parameters strictly between 0 and 1 where one
+// coordinate of a quadratic or cubic curve, given by its values at the
+// control points, has a zero derivative.
+func bezierTurns(v []float64) []float64 {
+	switch len(v) {
+	case 3:
+		if d := v[0] - 2*v[1] + v[2]; d != 0 {
+			return unitRoots([]float64{(v[0] - v[1]) / d})
+		}
+	case 4:
+		return unitRoots(quadraticRoots(-v[0]+3*v[1]-3*v[2]+v[3], 2*(v[0]-2*v[1]+v[2]), v[1]-v[0]))
+	}
+	return nil
+}
+
+// bezierExtrema returns the parameters where a curve turns in x or in y.
+func bezierExtrema(points []Point) []float64 {
+	xs, ys := make([]float64, len(points)), make([]float64, len(points))
+	for i, pt := range points {
+		xs[i], ys[i] = pt.X, pt.Y
+	}
+	return unitRoots(append(bezierTurns(xs), bezierTurns(ys)...))
+}
+
+// unitRoots returns the distinct roots strictly between 0 and 1, sorted.
+func unitRoots(roots []float64) []float64 {
+	var ts []float64
+	for _, t := range roots {
+		if t > 0 && t < 1 {
+			ts = append(ts, t)
+		}
+	}
+	sort.Float64s(ts)
+	for i := len(ts) - 1; i > 0; i-- {
+		if ts[i] == ts[i-1] {
+			ts = append(ts[:i], ts[i+1:]...)
+		}
+	}
+	return ts
+}
+
+// bezierNearest returns the parameter and position of the point on a Bézier
+// curve nearest to pt, refining the best of a set of samples with a golden
+// section search.
+func bezierNearest(points []Point, pt Point) (float64, Point) {
+	if len(points) == 2 {
+		t := projectParam(points[0], points[1], pt)
+		return t, bezierPoint(points, t)
+	}
+
+	const samples = 32
+	dist := func(t float64) float64 { return bezierPoint(points, t).DistanceTo(pt) }
+	best, bestT := math.Inf(1), 0.0
+	for i := 0; i <= samples; i++ {
+		t := float64(i) / samples
+		if d := dist(t); d < best {
+			best, bestT = d, t
+		}
+	}
+
+	lo, hi := math.Max(bestT-1.0/samples, 0), math.Min(bestT+1.0/samples, 1)
+	const ratio = 0.6180339887498949
+	x1, x2 := hi-ratio*(hi-lo), lo+ratio*(hi-lo)
+	d1, d2 := dist(x1), dist(x2)
+	for i := 0; i < 64 && hi-lo > 1e-12; i++ {
+		if d1 < d2 {
+			hi, x2, d2 = x2, x1, d1
+			x1 = hi - ratio*(hi-lo)
+			d1 = dist(x1)
+		} else {
+			lo, x1, d1 = x1, x2, d2
+			x2 = lo + ratio*(hi-lo)
+			d2 = dist(x2)
+		}
+	}
+	if t := (lo + hi) / 2; dist(t) < best {
+		return t, bezierPoint(points, t)
+	}
+	return bestT, bezierPoint(points, bestT)
+}
+
+// bezierLineIntersections finds where a quadratic or cubic curve meets the
+// segment l by solving for the roots of its distance from l's line.
+func bezierLineIntersections(points []Point, l Line) (found []CurveIntersection) {
+	dir := l.End.Sub(l.Start)
+	length := dir.Norm()
+	if length == 0 {
+		return nil
+	}
+	d := make([]float64, len(points))
+	for i, pt := range points {
+		d[i] = dir.Cross(pt.Sub(l.Start)) / length
+	}
+
+	var roots []float64
+	switch len(d) {
+	case 3:
+		roots = quadraticRoots(d[0]-2*d[1]+d[2], 2*(d[1]-d[0]), d[0])
+	case 4:
+		roots = cubicRoots(-d[0]+3*d[1]-3*d[2]+d[3], 3*(d[0]-2*d[1]+d[2]), 3*(d[1]-d[0]), d[0])
+	}
+
+	const slack = 1e-9
+	for _, t := range roots {
+		if t < -slack || t > 1+slack {
+			continue
+		}
+		t = math.Max(0, math.Min(1, t))
+		pt := bezierPoint(points, t)
+		u := pt.Sub(l.Start).Dot(dir) / (length * length)
+		if u < -slack || u > 1+slack {
+			continue
+		}
+		found = append(found, CurveIntersection{Point: pt, T: t, U: math.Max(0, math.Min(1, u))})
+	}
+	return dedupeIntersections(found, 1e-9*(length+controlBounds(&Path{Points: points}).Size().Norm()))
+}
+
+// cubicRoots returns the real roots of a*t³ + b*t² + c*t + d, polished
+// with Newton steps.
+func cubicRoots(a, b, c, d float64) []float64 {
+	if math.Abs(a) <= 1e-12*(math.Abs(b)+math.Abs(c)+math.Abs(d)) {
+		return quadraticRoots(b, c, d)
+	}
+
+	// the depressed cubic s³ + ps + q for t = s - b/3a
+	b, c, d = b/a, c/a, d/a
+	p := c - b*b/3
+	q := 2*b*b*b/27 - b*c/3 + d
+	shift := -b / 3
+
+	var roots []float64
+	switch disc := q*q/4 + p*p*p/27; {
+	case disc > 0:
+		sq := math.Sqrt(disc)
+		roots = []float64{math.Cbrt(-q/2+sq) + math.Cbrt(-q/2-sq) + shift}
+	case p == 0:
+		roots = []float64{shift}
+	default:
+		// three real roots, by the trigonometric method
+		r := 2 * math.Sqrt(-p/3)
+		phi := math.Acos(math.Max(-1, math.Min(1, 3*q/(p*r))))
+		for k := 0; k < 3; k++ {
+			roots = append(roots, r*math.Cos((phi-2*math.Pi*float64(k))/3)+shift)
+		}
+	}
+
+	for i, t := range roots {
+		for k := 0; k < 2; k++ {
+			f := ((t+b)*t+c)*t + d
+			df := (3*t+2*b)*t + c
+			if df == 0 {
+				break
+			}
+			t -= f / df
+		}
+		roots[i] = t
+	}
+	return roots
+}
+
+// bezierIntersectionBudget bounds the pairs of pieces bezierIntersections
+// compares, which only curves overlapping along a stretch run out of.
+const bezierIntersectionBudget = 1 << 16
+
+// bezierIntersections finds where two curves meet by halving whichever is
+// less flat while their bounds overlap, intersecting their chords once both
+// are flat to within a billionth of their size.
+func bezierIntersections(a, b []Point) []CurveIntersection {
+	tolerance := defaultTolerance(controlBounds(&Path{Points: a}, &Path{Points: b})) * 1e-5
+
+	var found []CurveIntersection
+	budget := bezierIntersectionBudget
+	var visit func(a, b []Point, a0, a1, b0, b1 float64)
+	visit = func(a, b []Point, a0, a1, b0, b1 float64) {
+		if budget <= 0 {
+			return
+		}
+		budget--
+		if !controlBounds(&Path{Points: a}).ExpandedByMargin(tolerance).Intersects(controlBounds(&Path{Points: b})) {
+			return
+		}
+
+		fa, fb := bezierFlatness(a), bezierFlatness(b)
+		if fa <= tolerance && fb <= tolerance {
+			if s, u, ok := chordIntersection(a, b, tolerance); ok {
+				t := a0 + s*(a1-a0)
+				found = append(found, CurveIntersection{T: t, U: b0 + u*(b1-b0)})
+			}
+			return
+		}
+		if fa >= fb {
+			mid := (a0 + a1) / 2
+			visit(splitBezier(a, 0.5), b, a0, mid, b0, b1)
+			visit(splitBezierRight(a, 0.5), b, mid, a1, b0, b1)
+		} else {
+			mid := (b0 + b1) / 2
+			visit(a, splitBezier(b, 0.5), a0, a1, b0, mid)
+			visit(a, splitBezierRight(b, 0.5), a0, a1, mid, b1)
+		}
+	}
+	visit(a, b, 0, 1, 0, 1)
+
+	if budget <= 0 {
+		// the curves overlap, so report the ends of the shared stretch: the
+		// end points of either curve lying on the other
+		found = nil
+		for _, t := range []float64{0, 1} {
+			if u, pt := bezierNearest(b, bezierPoint(a, t)); pt.DistanceTo(bezierPoint(a, t)) <= 1e3*tolerance {
+				found = append(found, CurveIntersection{T: t, U: u})
+			}
+			if s, pt := bezierNearest(a, bezierPoint(b, t)); pt.DistanceTo(bezierPoint(b, t)) <= 1e3*tolerance {
+				found = append(found, CurveIntersection{T: s, U: t})
+			}
+		}
+		for i := range found {
+			found[i].Point = bezierPoint(a, found[i].T)
+		}
+		return dedupeIntersections(found, 1e3*tolerance)
+	}
+
+	// a tangency, or a crossing found in neighbouring pieces, leaves a run
+	// of points between which the curves stay together. Two crossings close
+	// by are told apart by the curves crossing the other way at the second
+	// and parting between the two.
+	sort.Slice(found, func(i, j int) bool { return found[i].T < found[j].T })
+	turn := func(x CurveIntersection) float64 {
+		return bezierDerivative(a, x.T).Cross(bezierDerivative(b, x.U))
+	}
+	apart := func(x, y CurveIntersection) float64 {
+		mid := bezierPoint(a, (x.T+y.T)/2)
+		_, pt := bezierNearest(b, mid)
+		return pt.DistanceTo(mid)
+	}
+	together := func(x, y CurveIntersection) bool {
+		d := apart(x, y)
+		if turn(x)*turn(y) < 0 {
+			return d <= 2*tolerance
+		}
+		return d <= 1e3*tolerance
+	}
+	var merged []CurveIntersection
+	for i := 0; i < len(found); {
+		j := i
+		for j+1 < len(found) && together(found[j], found[j+1]) {
+			j++
+		}
+		// settle on the middle of the run
+		var x CurveIntersection
+		for _, y := range found[i : j+1] {
+			x.T += y.T
+			x.U += y.U
+		}
+		n := float64(j - i + 1)
+		x.T, x.U = x.T/n, x.U/n
+		x.Point = bezierPoint(a, x.T)
+		merged = append(merged, x)
+		i = j + 1
+	}
+	return merged
+}
+
+// bezierFlatness returns the largest distance of a curve's control points
+// from its chord.
+func bezierFlatness(points []Point) (flatness float64) {
+	first, last := points[0], points[len(points)-1]
+	for _, pt := range points[1 : len(points)-1] {
+		flatness = math.Max(flatness, pointSegmentDistance(pt, first, last))
+	}
+	return
+}
+
+// chordIntersection returns where the chords of two curves meet, as
+// parameters along each, if they come within tolerance of each other.
+func chordIntersection(a, b []Point, tolerance float64) (s, u float64, ok bool) {
+	a0, a1, b0, b1 := a[0], a[len(a)-1], b[0], b[len(b)-1]
+	da, db := a1.Sub(a0), b1.Sub(b0)
+	if den := da.Cross(db); math.Abs(den) > 1e-12*da.Norm()*db.Norm() {
+		u = math.Max(0, math.Min(1, b0.Sub(a0).Cross(da)/den))
+	} else {
+		// parallel chords meet, if at all, around the middle of their overlap
+		u = projectParam(b0, b1, a0.Add(da.Mul(0.5)))
+	}
+	// clamping can leave the points apart, so settle on the nearest pair
+	s = projectParam(a0, a1, b0.Add(db.Mul(u)))
+	u = projectParam(b0, b1, a0.Add(da.Mul(s)))
+	return s, u, a0.Add(da.Mul(s)).DistanceTo(b0.Add(db.Mul(u))) <= 2*tolerance
+}
+
+// dedupeIntersections sorts intersections by T and merges those closer
+// together than tolerance.
+func dedupeIntersections(found []CurveIntersection, tolerance float64) []CurveIntersection {
+	sort.Slice(found, func(i, j int) bool { return found[i].T < found[j].T })
+	var kept []CurveIntersection
+	for _, x := range found {
+		if n := len(kept); n > 0 && kept[n-1].Point.DistanceTo(x.Point) <= tolerance {
+			continue
+		}
+		kept = append(kept, x)
+	}
+	return kept
+}
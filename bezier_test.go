@@ -0,0 +1,130 @@
+package gfx_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+func TestBezierCurves(t *testing.T) {
+	near := func(a, b float64) bool { return math.Abs(a-b) <= 1e-9 }
+	nearPoint := func(a, b gfx.Point) bool { return a.DistanceTo(b) <= 1e-9 }
+
+	q := gfx.QuadBezier{{0, 0}, {1, 2}, {2, 0}}
+	if pt := q.Eval(0.5); !nearPoint(pt, gfx.Point{1, 1}) {
+		t.Errorf("quad Eval(0.5) = %v", pt)
+	}
+	if ext := q.Extrema(); len(ext) != 1 || !near(ext[0], 0.5) {
+		t.Errorf("quad Extrema = %v", ext)
+	}
+	c := q.Elevate()
+	for _, u := range []float64{0.1, 0.5, 0.8} {
+		if !nearPoint(c.Eval(u), q.Eval(u)) {
+			t.Errorf("elevated quad differs at %v", u)
+		}
+	}
+	if lowered, bound := c.Lower(); bound > 1e-12 || !nearPoint(lowered[1], q[1]) {
+		t.Errorf("Lower of an elevated quad = %v, %v", lowered, bound)
+	}
+	if l := q.ArcLength(); !near(l, math.Sqrt(5)+math.Asinh(2)/2) {
+		t.Errorf("quad ArcLength = %v", l)
+	}
+
+	// an S curve turning in y twice with an inflection in the middle
+	s := gfx.CubicBezier{{0, 0}, {1, 2}, {2, -2}, {3, 0}}
+	if inf := s.Inflections(); len(inf) != 1 || !near(inf[0], 0.5) {
+		t.Errorf("Inflections = %v", inf)
+	}
+	if ext := s.Extrema(); len(ext) != 2 || !near(ext[0]+ext[1], 1) {
+		t.Errorf("cubic Extrema = %v", ext)
+	}
+	a, b := s.SplitAt(0.3)
+	if !nearPoint(a[3], s.Eval(0.3)) || !nearPoint(b[0], s.Eval(0.3)) || !nearPoint(b.Eval(0.5), s.Eval(0.65)) {
+		t.Errorf("SplitAt(0.3) = %v, %v", a, b)
+	}
+	if d := s.Derivative(0); !nearPoint(d, gfx.Point{3, 6}) {
+		t.Errorf("Derivative(0) = %v", d)
+	}
+	d := s.Derivative(0.3)
+	off := s.Eval(0.3).Add(gfx.Point{-d.Y, d.X}.Normalize().Mul(0.1))
+	if u, pt := s.NearestPoint(off); math.Abs(u-0.3) > 1e-6 || !nearPoint(pt, s.Eval(u)) {
+		t.Errorf("NearestPoint = %v, %v", u, pt)
+	}
+	if bounds := s.Bounds(); !near(bounds.X.Max, 3) || bounds.Y.Max <= 0.5 || bounds.Y.Max >= 2 {
+		t.Errorf("Bounds = %v", bounds)
+	}
+}
+
+func TestBezierIntersections(t *testing.T) {
+	s := gfx.CubicBezier{{0, 0}, {1, 2}, {2, -2}, {3, 0}}
+	hits := s.IntersectLine(gfx.MakeLine(-1, 0, 4, 0))
+	if len(hits) != 3 {
+		t.Fatalf("IntersectLine = %v", hits)
+	}
+	for i, want := range []float64{0, 0.5, 1} {
+		if math.Abs(hits[i].T-want) > 1e-9 || math.Abs(hits[i].Point.Y) > 1e-9 {
+			t.Errorf("hit %d = %+v, want t = %v", i, hits[i], want)
+		}
+		if u := (hits[i].Point.X + 1) / 5; math.Abs(hits[i].U-u) > 1e-9 {
+			t.Errorf("hit %d at U %v, want %v", i, hits[i].U, u)
+		}
+	}
+	if hits := s.IntersectLine(gfx.MakeLine(0, 5, 3, 5)); len(hits) != 0 {
+		t.Errorf("IntersectLine above the curve = %v", hits)
+	}
+
+	// two arches crossing twice
+	a := gfx.QuadBezier{{0, 0}, {2, 4}, {4, 0}}
+	b := gfx.QuadBezier{{0, 1}, {2, -1}, {4, 1}}
+	crossings := a.IntersectQuad(b)
+	if len(crossings) != 2 {
+		t.Fatalf("IntersectQuad = %v", crossings)
+	}
+	for _, x := range crossings {
+		if p, q := a.Eval(x.T), b.Eval(x.U); p.DistanceTo(q) > 1e-6 || p.DistanceTo(x.Point) > 1e-9 {
+			t.Errorf("crossing %+v: curves at %v and %v", x, p, q)
+		}
+	}
+
+	// arches touching at the top meet once
+	touch := gfx.QuadBezier{{0, 2}, {2, -2}, {4, 2}}.IntersectQuad(gfx.QuadBezier{{0, -2}, {2, 2}, {4, -2}})
+	if len(touch) != 1 || touch[0].Point.DistanceTo(gfx.Point{2, 0}) > 1e-6 {
+		t.Errorf("IntersectQuad of touching curves = %v", touch)
+	}
+
+	// nearly touching arches still cross twice, about 4.5e-4 apart
+	arch := gfx.QuadBezier{{-1, 1}, {0, -1}, {1, 1}}
+	flipped := gfx.QuadBezier{{-1, -1 + 1e-7}, {0, 1 + 1e-7}, {1, -1 + 1e-7}}
+	near := arch.IntersectQuad(flipped)
+	if len(near) != 2 {
+		t.Fatalf("IntersectQuad of nearly touching curves = %v", near)
+	}
+	for i, x := range []float64{-math.Sqrt(0.5e-7), math.Sqrt(0.5e-7)} {
+		if math.Abs(near[i].Point.X-x) > 1e-5 || arch.Eval(near[i].T).DistanceTo(flipped.Eval(near[i].U)) > 1e-8 {
+			t.Errorf("crossing %d = %+v, want x = %v", i, near[i], x)
+		}
+	}
+
+	// curves of mixed degree
+	mixed := a.IntersectCubic(b.Elevate())
+	if len(mixed) != 2 {
+		t.Fatalf("IntersectCubic of a quadratic = %v", mixed)
+	}
+	reversed := b.Elevate().IntersectQuad(a)
+	if len(reversed) != len(mixed) {
+		t.Fatalf("IntersectQuad of a cubic = %v", reversed)
+	}
+	for i, x := range reversed {
+		if x.Point.DistanceTo(mixed[i].Point) > 1e-6 || math.Abs(x.T-mixed[i].U) > 1e-6 {
+			t.Errorf("crossing %d = %+v, want %+v the other way round", i, x, mixed[i])
+		}
+	}
+
+	// a curve overlapping a piece of itself meets it at the ends of the piece
+	_, tail := s.SplitAt(0.4)
+	overlap := s.IntersectCubic(tail)
+	if len(overlap) != 2 || math.Abs(overlap[0].T-0.4) > 1e-6 || math.Abs(overlap[1].T-1) > 1e-6 {
+		t.Errorf("IntersectCubic of overlapping curves = %v", overlap)
+	}
+}
@@ -0,0 +1,102 @@
+package gfx
+
+import (
+	"image"
+	"image/color"
+)
+
+// Bitmap is a binary image. Set pixels are ink, clear pixels background.
+type Bitmap struct {
+	Rect image.Rectangle
+	Pix  []bool
+}
+
+func NewBitmap(r image.Rectangle) *Bitmap {
+	return &Bitmap{Rect: r, Pix: make([]bool, r.Dx()*r.Dy())}
+}
+
+func (b *Bitmap) Bounds() image.Rectangle { return b.Rect }
+
+// Get reports whether the pixel at (x, y) is set. Pixels outside the bitmap
+// are clear.
+func (b *Bitmap) Get(x, y int) bool {
+	if !(image.Point{x, y}.In(b.Rect)) {
+		return false
+	}
+	return b.Pix[(y-b.Rect.Min.Y)*b.Rect.Dx()+(x-b.Rect.Min.X)]
+}
+
+func (b *Bitmap) Set(x, y int, v bool) {
+	if !(image.Point{x, y}.In(b.Rect)) {
+		return
+	}
+	b.Pix[(y-b.Rect.Min.Y)*b.Rect.Dx()+(x-b.Rect.Min.X)] = v
+}
+
+// Binarize converts img to a bitmap whose set pixels are those with a gray
+// level at or below threshold. A threshold of 0 picks one with
+// OtsuThreshold.
+func Binarize(img image.Image, threshold uint8) *Bitmap {
+	if threshold == 0 {
+		threshold = OtsuThreshold(img)
+	}
+
+	r := img.Bounds()
+	b := NewBitmap(r)
+	i := 0
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			b.Pix[i] = grayAt(img, x, y) <= threshold
+			i++
+		}
+	}
+	return b
+}
+
+// OtsuThreshold returns the gray level that best separates the pixels of img
+// into dark and light classes, by maximizing the variance between them.
+func OtsuThreshold(img image.Image) uint8 {
+	var histogram [256]int
+	r := img.Bounds()
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			histogram[grayAt(img, x, y)]++
+		}
+	}
+
+	total, sum := 0, 0.0
+	for level, count := range histogram {
+		total += count
+		sum += float64(level * count)
+	}
+
+	var best uint8
+	var bestVariance, darkSum float64
+	dark := 0
+	for level, count := range histogram {
+		dark += count
+		if dark == 0 {
+			continue
+		}
+		light := total - dark
+		if light == 0 {
+			break
+		}
+
+		darkSum += float64(level * count)
+		darkMean := darkSum / float64(dark)
+		lightMean := (sum - darkSum) / float64(light)
+		variance := float64(dark) * float64(light) * (darkMean - lightMean) * (darkMean - lightMean)
+		if variance > bestVariance {
+			best, bestVariance = uint8(level), variance
+		}
+	}
+	return best
+}
+
+func grayAt(img image.Image, x, y int) uint8 {
+	if gray, ok := img.(*image.Gray); ok {
+		return gray.GrayAt(x, y).Y
+	}
+	return color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y
+}
@@ -0,0 +1,43 @@
+package gfx_test
+
+import (
+	"image"
+	"testing"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+func TestComponents(t *testing.T) {
+	rows := []string{
+		"#.....................",
+		"......................",
+		"#..##...#.#......###..",
+		"#..#.#...#.......#.#..",
+		"#..#.#..#.#......###..",
+	}
+	b := gfx.NewBitmap(image.Rect(0, 0, len(rows[0]), len(rows)))
+	for y, row := range rows {
+		for x, c := range row {
+			b.Set(x, y, c == '#')
+		}
+	}
+
+	four := b.Components(gfx.Connectivity4)
+	eight := b.Components(gfx.Connectivity8)
+	if len(four) != 10 || len(eight) != 5 {
+		t.Fatalf("components = %d and %d, want 10 and 5", len(four), len(eight))
+	}
+	if eight[4].Rect != gfx.MakeRect(17, 2, 20, 5) || eight[4].Pixels != 8 {
+		t.Errorf("ring = %+v", eight[4])
+	}
+
+	glyphs := eight.Glyphs(0.5)
+	if len(glyphs) != 4 || glyphs[0].Rect != gfx.MakeRect(0, 0, 1, 5) || glyphs[0].Pixels != 4 {
+		t.Fatalf("glyphs = %+v", glyphs)
+	}
+
+	words := glyphs.Words(1)
+	if len(words) != 2 || words[0].Rect != gfx.MakeRect(0, 0, 11, 5) {
+		t.Errorf("words = %+v", words)
+	}
+}
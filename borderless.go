@@ -0,0 +1,409 @@
+package gfx
+
+import (
+	"math"
+	"sort"
+	"unicode"
+)
+
+// BorderlessTableOptions controls table detection from text alignment.
+// Distances are fractions of the median height of the words, so the same
+// options work for any font size and resolution.
+type BorderlessTableOptions struct {
+	// MinColumnGap is the narrowest gap between words that can separate
+	// columns.
+	MinColumnGap float64
+	// MaxRowGap is the largest vertical gap between consecutive rows of a
+	// table.
+	MaxRowGap float64
+	// GapSupport is the fraction of a table's rows in which a column gap
+	// must be empty. Rows crossing the gap hold cells spanning columns.
+	GapSupport float64
+	// AlignmentTolerance is how far the left edges, right edges, centers or
+	// decimal points of a column's cells may stray and still line up.
+	AlignmentTolerance float64
+	// MinAligned is the fraction of a column's cells that must line up for
+	// the column to count as aligned.
+	MinAligned float64
+	// MinRows and MinColumns are the smallest table accepted. A table needs
+	// at least MinColumns aligned columns.
+	MinRows    int
+	MinColumns int
+}
+
+func DefaultBorderlessTableOptions() BorderlessTableOptions {
+	return BorderlessTableOptions{
+		MinColumnGap:       1.0,
+		MaxRowGap:          1.5,
+		GapSupport:         0.8,
+		AlignmentTolerance: 0.5,
+		MinAligned:         0.7,
+		MinRows:            3,
+		MinColumns:         2,
+	}
+}
+
+// FindBorderlessTables infers tables without ruling lines from the words of
+// a page. Words are grouped into rows, and runs of consecutive rows with
+// several widely spaced segments are searched for whitespace gaps lining up
+// across the rows. The columns between the gaps must be left, right, center
+// or decimal aligned. Leading rows that span columns, are set in a different
+// font, or hold text above numeric columns are counted as header rows.
+//
+// Words are expected upright in y-up coordinates. Two columns of prose look
+// much like a two column table, so pages are best segmented into regions
+// first and each region passed on its own.
+func FindBorderlessTables(words TextWords, opts BorderlessTableOptions) (tables []Table) {
+	if len(words) == 0 {
+		return
+	}
+
+	rects := make(Rects, len(words))
+	heights := make([]float64, len(words))
+	for i, word := range words {
+		rects[i] = word.Quad.Bounds()
+		heights[i] = rects[i].Height()
+	}
+	sort.Float64s(heights)
+	size := heights[len(heights)/2]
+	if size <= 0 {
+		size = 1
+	}
+
+	var rows []textRow
+	for _, ids := range rects.GroupRowIndices() {
+		rows = append(rows, makeTextRow(words, ids, rects, opts.MinColumnGap*size))
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].bounds.Y.Max > rows[j].bounds.Y.Max })
+
+	for start := 0; start < len(rows); {
+		end := start + 1
+		for end < len(rows) && rows[end-1].bounds.Y.Min-rows[end].bounds.Y.Max <= opts.MaxRowGap*size {
+			end++
+		}
+
+		run := rows[start:end]
+		for len(run) > 0 && len(run[0].segments) < 2 {
+			run = run[1:]
+		}
+		for len(run) > 0 && len(run[len(run)-1].segments) < 2 {
+			run = run[:len(run)-1]
+		}
+		if table, ok := makeBorderlessTable(run, size, opts); ok {
+			tables = append(tables, table)
+		}
+		start = end
+	}
+	return
+}
+
+// textRow is a row of words split into segments at gaps wide enough to
+// separate columns.
+type textRow struct {
+	bounds   Rect
+	font     FontData
+	segments []textSegment
+}
+
+// textSegment is a run of closely spaced words in a row. Decimal is the
+// position of the decimal point of a numeric segment, or of its right edge
+// when it has none.
+type textSegment struct {
+	span    Range
+	numeric bool
+	decimal float64
+}
+
+func makeTextRow(words TextWords, ids []int, rects Rects, gap float64) (row textRow) {
+	sort.SliceStable(ids, func(i, j int) bool { return rects[ids[i]].X.Min < rects[ids[j]].X.Min })
+
+	row.bounds = EmptyRect()
+	starts, ends := make([]float64, len(ids)), make([]float64, len(ids))
+	for i, id := range ids {
+		row.bounds = row.bounds.Union(rects[id])
+		starts[i], ends[i] = rects[id].X.Min-gap/2, rects[id].X.Max+gap/2
+	}
+	if letters := words[ids[0]].Letters; len(letters) > 0 {
+		row.font = letters[0].FontData
+	}
+
+	for _, span := range Partition(starts, ends) {
+		var letters Letters
+		segment := textSegment{span: span.Expanded(-gap / 2)}
+		for _, id := range ids {
+			if span.Contains(rects[id].X.Min) {
+				letters = append(letters, words[id].Letters...)
+			}
+		}
+		segment.numeric, segment.decimal = numericDecimal(letters, segment.span)
+		row.segments = append(row.segments, segment)
+	}
+	return
+}
+
+// numericDecimal reports whether letters spell a number, and where its
+// decimal point lies. Numbers without a decimal point align on their right
+// edge.
+func numericDecimal(letters Letters, span Range) (numeric bool, decimal float64) {
+	decimal = span.Max
+	for i, letter := range letters {
+		switch {
+		case unicode.IsDigit(letter.Rune):
+			numeric = true
+		case unicode.IsLetter(letter.Rune):
+			return false, math.NaN()
+		case letter.Rune == '.' && i+1 < len(letters) && unicode.IsDigit(letters[i+1].Rune):
+			decimal = letter.Quad.Centroid().X
+		}
+	}
+	if !numeric {
+		return false, math.NaN()
+	}
+	return
+}
+
+// borderlessCell is a cell of a row under construction, covering columns
+// first through last.
+type borderlessCell struct {
+	first, last int
+	segments    []textSegment
+}
+
+func makeBorderlessTable(run []textRow, size float64, opts BorderlessTableOptions) (table Table, ok bool) {
+	if len(run) < opts.MinRows || len(run) == 0 {
+		return
+	}
+
+	var xs []float64
+	for _, row := range run {
+		for _, segment := range row.segments {
+			xs = append(xs, segment.span.Min, segment.span.Max)
+		}
+	}
+	sort.Float64s(xs)
+
+	// a gap may be crossed by as many rows as GapSupport leaves over
+	allowed := int(math.Floor((1-opts.GapSupport)*float64(len(run)) + Epsilon))
+	// columns are split in the middle of the widest stretch of a gap that
+	// no row crosses, so spanning cells stay free of the boundary
+	var cuts []float64
+	gap, free, widest, covered := EmptyRange(), EmptyRange(), EmptyRange(), false
+	for i := 0; i+1 < len(xs); i++ {
+		stretch := Range{xs[i], xs[i+1]}
+		if stretch.Length() <= 0 {
+			continue
+		}
+
+		count := 0
+		for _, row := range run {
+			for _, segment := range row.segments {
+				if segment.span.InteriorIntersects(stretch) {
+					count++
+					break
+				}
+			}
+		}
+		if count <= allowed {
+			if covered {
+				gap = gap.Union(stretch)
+				if count == 0 {
+					free = free.Union(stretch)
+				} else {
+					free = EmptyRange()
+				}
+				if !free.IsEmpty() && (widest.IsEmpty() || free.Length() > widest.Length()) {
+					widest = free
+				}
+			}
+			continue
+		}
+
+		if !gap.IsEmpty() && gap.Length() >= opts.MinColumnGap*size {
+			if widest.IsEmpty() {
+				widest = gap
+			}
+			cuts = append(cuts, (widest.Min+widest.Max)/2)
+		}
+		gap, free, widest, covered = EmptyRange(), EmptyRange(), EmptyRange(), true
+	}
+
+	cols := len(cuts) + 1
+	if cols < opts.MinColumns {
+		return
+	}
+
+	bounds := append([]float64{xs[0]}, cuts...)
+	bounds = append(bounds, xs[len(xs)-1])
+	for c := 0; c < cols; c++ {
+		table.Columns = append(table.Columns, Range{bounds[c], bounds[c+1]})
+	}
+
+	for r, row := range run {
+		top, bottom := row.bounds.Y.Max, row.bounds.Y.Min
+		if r > 0 {
+			top = (run[r-1].bounds.Y.Min + top) / 2
+		}
+		if r+1 < len(run) {
+			bottom = (bottom + run[r+1].bounds.Y.Max) / 2
+		}
+		table.Rows = append(table.Rows, Range{bottom, top})
+	}
+
+	grid := make([][]borderlessCell, len(run))
+	for r, row := range run {
+		grid[r] = borderlessRowCells(row, table.Columns)
+	}
+
+	aligned := 0
+	for c := range table.Columns {
+		var segments []textSegment
+		for _, cells := range grid {
+			for _, cell := range cells {
+				if cell.first == c && cell.last == c && len(cell.segments) == 1 {
+					segments = append(segments, cell.segments[0])
+				}
+			}
+		}
+		if columnAligned(segments, opts.AlignmentTolerance*size, opts.MinAligned) {
+			aligned++
+		}
+	}
+	if aligned < opts.MinColumns {
+		return
+	}
+
+	for r, cells := range grid {
+		for _, cell := range cells {
+			table.Cells = append(table.Cells, TableCell{
+				Row:     r,
+				Col:     cell.first,
+				RowSpan: 1,
+				ColSpan: cell.last - cell.first + 1,
+				Rect:    MakeRect(table.Columns[cell.first].Min, table.Rows[r].Min, table.Columns[cell.last].Max, table.Rows[r].Max),
+			})
+		}
+	}
+
+	table.Bounds = MakeRect(bounds[0], table.Rows[len(run)-1].Min, bounds[cols], table.Rows[0].Max)
+	table.HeaderRows = borderlessHeaderRows(run, grid)
+	return table, true
+}
+
+// borderlessRowCells assigns the segments of a row to the columns they
+// overlap. Segments sharing a column are merged into one cell, and columns
+// without a segment get an empty cell of their own.
+func borderlessRowCells(row textRow, columns []Range) (cells []borderlessCell) {
+	for _, segment := range row.segments {
+		cell := borderlessCell{first: -1, segments: []textSegment{segment}}
+		for c, column := range columns {
+			if segment.span.InteriorIntersects(column) || (segment.span.Length() == 0 && column.Contains(segment.span.Min)) {
+				if cell.first < 0 {
+					cell.first = c
+				}
+				cell.last = c
+			}
+		}
+		if cell.first < 0 {
+			continue
+		}
+
+		if n := len(cells); n > 0 && cells[n-1].last >= cell.first {
+			cells[n-1].last = int(math.Max(float64(cells[n-1].last), float64(cell.last)))
+			cells[n-1].segments = append(cells[n-1].segments, segment)
+			continue
+		}
+		cells = append(cells, cell)
+	}
+
+	var full []borderlessCell
+	c := 0
+	for _, cell := range cells {
+		for ; c < cell.first; c++ {
+			full = append(full, borderlessCell{first: c, last: c})
+		}
+		full = append(full, cell)
+		c = cell.last + 1
+	}
+	for ; c < len(columns); c++ {
+		full = append(full, borderlessCell{first: c, last: c})
+	}
+	return full
+}
+
+// columnAligned reports whether at least minAligned of the segments share a
+// left edge, right edge, center or decimal point within tol.
+func columnAligned(segments []textSegment, tol, minAligned float64) bool {
+	if len(segments) < 2 {
+		return false
+	}
+
+	features := []func(textSegment) float64{
+		func(s textSegment) float64 { return s.span.Min },
+		func(s textSegment) float64 { return s.span.Max },
+		func(s textSegment) float64 { return (s.span.Min + s.span.Max) / 2 },
+		func(s textSegment) float64 { return s.decimal },
+	}
+	for _, feature := range features {
+		var values []float64
+		for _, segment := range segments {
+			if v := feature(segment); !math.IsNaN(v) {
+				values = append(values, v)
+			}
+		}
+		if len(values) < 2 {
+			continue
+		}
+
+		sort.Float64s(values)
+		median := values[len(values)/2]
+		count := 0
+		for _, v := range values {
+			if math.Abs(v-median) <= tol {
+				count++
+			}
+		}
+		if float64(count) >= minAligned*float64(len(segments)) {
+			return true
+		}
+	}
+	return false
+}
+
+// borderlessHeaderRows counts the leading rows that span columns, are set
+// in a different font than the last row, or hold text above a column whose
+// body is mostly numeric. At least one row is left as body.
+func borderlessHeaderRows(run []textRow, grid [][]borderlessCell) (headers int) {
+	body := run[len(run)-1].font
+
+	for r := 0; r < len(run)-1; r++ {
+		header := run[r].font != body && run[r].font != (FontData{}) && body != (FontData{})
+
+		for _, cell := range grid[r] {
+			if cell.last > cell.first && len(cell.segments) > 0 {
+				header = true
+			}
+			if header || len(cell.segments) == 0 || cell.segments[0].numeric || cell.first != cell.last {
+				continue
+			}
+
+			numeric, filled := 0, 0
+			for _, cells := range grid[r+1:] {
+				for _, below := range cells {
+					if below.first == cell.first && below.last == cell.last && len(below.segments) > 0 {
+						filled++
+						if below.segments[0].numeric {
+							numeric++
+						}
+					}
+				}
+			}
+			header = filled > 0 && 2*numeric > filled
+		}
+
+		if !header {
+			break
+		}
+		headers++
+	}
+	return
+}
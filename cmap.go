@@ -0,0 +1,138 @@
+package gfx
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/bryanmatteson/gfx/font/woff"
+)
+
+// coverageCache holds the coverage of system font files by path, so that
+// each file's cmap is read at most once per process.
+var coverageCache = struct {
+	sync.Mutex
+	entries map[string]coverageEntry
+}{entries: make(map[string]coverageEntry)}
+
+type coverageEntry struct {
+	runes RangeSet
+	err   error
+}
+
+// Coverage returns the runes the record's font maps to glyphs, read from its
+// cmap table without loading the font. Each rune code point c is covered if
+// the set contains float64(c). Results are cached by path.
+func (r SystemFontRecord) Coverage() (RangeSet, error) {
+	coverageCache.Lock()
+	entry, ok := coverageCache.entries[r.Path]
+	coverageCache.Unlock()
+	if ok {
+		return entry.runes, entry.err
+	}
+
+	entry.runes, entry.err = fileCoverage(r.Path)
+	coverageCache.Lock()
+	coverageCache.entries[r.Path] = entry
+	coverageCache.Unlock()
+	return entry.runes, entry.err
+}
+
+func fileCoverage(path string) (RangeSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if woff.IsWOFF(data) || woff.IsWOFF2(data) {
+		if data, err = woff.Decode(data); err != nil {
+			return nil, err
+		}
+	}
+	cmap, err := sfntTable(data, "cmap")
+	if err != nil {
+		return nil, err
+	}
+	if cmap == nil {
+		return nil, fmt.Errorf("font has no cmap table")
+	}
+	return parseCmapCoverage(cmap)
+}
+
+// parseCmapCoverage returns the runes mapped to glyphs other than .notdef by
+// the best Unicode subtable of a cmap table: a format 12 subtable if there is
+// one and a format 4 subtable otherwise.
+func parseCmapCoverage(cmap []byte) (runes RangeSet, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			runes, err = nil, fmt.Errorf("invalid cmap table: %v", r)
+		}
+	}()
+
+	r := otReader(cmap)
+	best, bestRank := -1, 0
+	for i, n := 0, int(r.u16(2)); i < n; i++ {
+		rec := 4 + 8*i
+		platform, encoding, offset := r.u16(rec), r.u16(rec+2), int(r.u32(rec+4))
+		if platform != 0 && (platform != 3 || encoding != 0 && encoding != 1 && encoding != 10) {
+			continue
+		}
+
+		rank := 0
+		switch r.u16(offset) {
+		case 12:
+			rank = 3
+		case 4:
+			rank = 2
+			if platform == 3 && encoding == 0 {
+				// symbol fonts map their glyphs into the private use area
+				rank = 1
+			}
+		}
+		if rank > bestRank {
+			best, bestRank = offset, rank
+		}
+	}
+	if best < 0 {
+		return nil, fmt.Errorf("cmap has no unicode subtable")
+	}
+
+	var ranges []Range
+	add := func(lo, hi int) {
+		if n := len(ranges); n > 0 && int(ranges[n-1].Max)+1 == lo {
+			ranges[n-1].Max = float64(hi)
+			return
+		}
+		ranges = append(ranges, Range{float64(lo), float64(hi)})
+	}
+
+	switch r.u16(best) {
+	case 12:
+		for i, n := 0, int(r.u32(best+12)); i < n; i++ {
+			group := best + 16 + 12*i
+			add(int(r.u32(group)), int(r.u32(group+4)))
+		}
+	case 4:
+		segments := int(r.u16(best+6)) / 2
+		ends := best + 14
+		starts := ends + 2*segments + 2
+		deltas := starts + 2*segments
+		rangeOffsets := deltas + 2*segments
+		for i := 0; i < segments; i++ {
+			start, end := int(r.u16(starts+2*i)), int(r.u16(ends+2*i))
+			if start == 0xffff || start > end {
+				continue
+			}
+			rangeOffset := rangeOffsets + 2*i
+			if r.u16(rangeOffset) == 0 {
+				add(start, end)
+				continue
+			}
+			for c := start; c <= end; c++ {
+				if r.u16(rangeOffset+int(r.u16(rangeOffset))+2*(c-start)) != 0 {
+					add(c, c)
+				}
+			}
+		}
+	}
+	return MakeRangeSet(ranges...), nil
+}
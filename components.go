@@ -0,0 +1,179 @@
+package gfx
+
+import "sort"
+
+// Connectivity selects which neighbouring pixels join a connected component.
+type Connectivity int
+
+const (
+	// Connectivity4 joins pixels sharing an edge.
+	Connectivity4 Connectivity = 4
+	// Connectivity8 also joins pixels touching at a corner.
+	Connectivity8 Connectivity = 8
+)
+
+// Component is a connected set of ink pixels, or a group of them after
+// merging. Rect lies on pixel edges in image coordinates.
+type Component struct {
+	Rect   Rect
+	Pixels int
+}
+
+type Components []Component
+
+// Components labels the connected components of the bitmap's set pixels and
+// returns them in the raster order of their first pixel.
+func (b *Bitmap) Components(connectivity Connectivity) (components Components) {
+	w, h := b.Rect.Dx(), b.Rect.Dy()
+	labels := make([]int32, w*h)
+	parent := []int32{0}
+
+	var find func(int32) int32
+	find = func(l int32) int32 {
+		for parent[l] != l {
+			parent[l] = parent[parent[l]]
+			l = parent[l]
+		}
+		return l
+	}
+	union := func(a, b int32) int32 {
+		ra, rb := find(a), find(b)
+		if ra < rb {
+			parent[rb] = ra
+			return ra
+		}
+		parent[ra] = rb
+		return rb
+	}
+
+	join := func(label int32, x, y int) int32 {
+		if x < 0 || x >= w || y < 0 {
+			return label
+		}
+		l := labels[y*w+x]
+		switch {
+		case l == 0:
+			return label
+		case label == 0:
+			return find(l)
+		default:
+			return union(label, l)
+		}
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			if !b.Pix[i] {
+				continue
+			}
+
+			label := join(join(0, x-1, y), x, y-1)
+			if connectivity == Connectivity8 {
+				label = join(join(label, x-1, y-1), x+1, y-1)
+			}
+			if label == 0 {
+				label = int32(len(parent))
+				parent = append(parent, label)
+			}
+			labels[i] = label
+		}
+	}
+
+	index := make(map[int32]int)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			l := labels[y*w+x]
+			if l == 0 {
+				continue
+			}
+			l = find(l)
+
+			px := MakeRect(float64(b.Rect.Min.X+x), float64(b.Rect.Min.Y+y), float64(b.Rect.Min.X+x+1), float64(b.Rect.Min.Y+y+1))
+			k, ok := index[l]
+			if !ok {
+				k = len(components)
+				index[l] = k
+				components = append(components, Component{Rect: px})
+			}
+			components[k].Rect = components[k].Rect.Union(px)
+			components[k].Pixels++
+		}
+	}
+	return
+}
+
+func (c Components) Rects() Rects {
+	rects := make(Rects, len(c))
+	for i, component := range c {
+		rects[i] = component.Rect
+	}
+	return rects
+}
+
+// Merge groups components whose rects are closer than maxDistance with
+// Rects.Cluster and returns one component per group, in the order of each
+// group's first component.
+func (c Components) Merge(maxDistance float64) Components {
+	return c.mergeScaled(1, 1, maxDistance)
+}
+
+// Glyphs merges components stacked above one another into glyph-like
+// blobs, such as the dot and stem of an i or a letter and its accent.
+// Components join when their horizontal extents overlap and the vertical
+// gap between them is less than maxGap times the median component height.
+func (c Components) Glyphs(maxGap float64) Components {
+	return c.mergeScaled(componentSeparation, 1, maxGap*c.medianHeight())
+}
+
+// Words merges components side by side into word-like blobs. Components
+// join when their vertical extents overlap and the horizontal gap between
+// them is less than maxGap times the median component height. Glyphs should
+// usually be merged first so accents and dots do not stand apart.
+func (c Components) Words(maxGap float64) Components {
+	return c.mergeScaled(1, componentSeparation, maxGap*c.medianHeight())
+}
+
+// componentSeparation stretches one axis before clustering so that
+// components apart on that axis never join.
+const componentSeparation = 1e6
+
+func (c Components) medianHeight() float64 {
+	if len(c) == 0 {
+		return 0
+	}
+	heights := make([]float64, len(c))
+	for i, component := range c {
+		heights[i] = component.Rect.Height()
+	}
+	sort.Float64s(heights)
+	return heights[len(heights)/2]
+}
+
+func (c Components) mergeScaled(sx, sy, maxDistance float64) (merged Components) {
+	if len(c) == 0 {
+		return
+	}
+
+	scaled := make(Rects, len(c))
+	for i, component := range c {
+		r := component.Rect
+		scaled[i] = MakeRect(r.X.Min*sx, r.Y.Min*sy, r.X.Max*sx, r.Y.Max*sy)
+	}
+
+	groups := scaled.ClusterIndices(0, maxDistance)
+	for _, ids := range groups {
+		sort.Ints(ids)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i][0] < groups[j][0] })
+
+	for _, ids := range groups {
+		component := Component{Rect: EmptyRect()}
+		for _, id := range ids {
+			component.Rect = component.Rect.Union(c[id].Rect)
+			component.Pixels += c[id].Pixels
+		}
+		merged = append(merged, component)
+	}
+	return
+}
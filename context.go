@@ -21,6 +21,9 @@ type ImageContext struct {
 	*StackGraphicContext
 	img        *image.RGBA
 	fontCache  FontCache
+	glyphCache *GlyphCache
+	shaper     *Shaper
+	recorder   func(Letter)
 	rasterizer *raster.Rasterizer
 	dpi        int
 	filter     ImageFilter
@@ -45,6 +48,7 @@ func NewContextForImage(img image.Image) *ImageContext {
 	gc := &ImageContext{
 		StackGraphicContext: NewStackGraphicContext(),
 		img:                 rgbaImage,
+		shaper:              NewShaper(),
 		rasterizer:          raster.NewRasterizer(width, height),
 		dpi:                 72,
 		filter:              BilinearFilter,
@@ -54,6 +58,20 @@ func NewContextForImage(img image.Image) *ImageContext {
 
 func (gc *ImageContext) SetFontCache(cache FontCache) { gc.fontCache = cache }
 
+// SetGlyphCache sets the cache used to reuse rasterized glyphs when drawing
+// text. A nil cache renders every glyph from its outline.
+func (gc *ImageContext) SetGlyphCache(cache *GlyphCache) { gc.glyphCache = cache }
+
+// SetShaper sets the shaper used by FillString. A nil shaper draws one glyph
+// per rune without kerning or ligatures.
+func (gc *ImageContext) SetShaper(shaper *Shaper) { gc.shaper = shaper }
+
+// SetLetterRecorder sets a function that receives a Letter, in device
+// space, for every character drawn by FillRune, FillString and FillGlyphRun.
+// The letters can be grouped with Letters.Blocks. A nil recorder disables
+// recording.
+func (gc *ImageContext) SetLetterRecorder(recorder func(Letter)) { gc.recorder = recorder }
+
 func (gc *ImageContext) GetDPI() int { return gc.dpi }
 
 func (gc *ImageContext) Clear(color color.Color) {
@@ -165,6 +183,10 @@ func (gc *ImageContext) Stroke(paths ...*Path) {
 
 	stroker := NewLineStroker(gc.Current.Cap, gc.Current.Join, Transformer{Tr: gc.Current.Trm, Flattener: ftLineBuilder{Adder: gc.rasterizer}})
 	stroker.HalfLineWidth = gc.Current.LineWidth / 2
+	stroker.MiterLimit = gc.Current.MiterLimit
+	if scale := gc.Current.Trm.GetScale(); scale > 0 {
+		stroker.Tolerance = 0.125 / scale
+	}
 
 	var liner Flattener = stroker
 	if len(gc.Current.Dash) > 0 {
@@ -175,19 +197,7 @@ func (gc *ImageContext) Stroke(paths ...*Path) {
 		Flatten(p, liner, gc.Current.Trm.GetScale())
 	}
 
-	var painter raster.Painter
-	if gc.Current.Mask == nil {
-		if pattern, ok := gc.Current.StrokePattern.(*solidPattern); ok {
-			p := raster.NewRGBAPainter(gc.img)
-			p.SetColor(pattern.color)
-			painter = p
-		}
-	}
-	if painter == nil {
-		painter = newPatternPainter(gc.img, gc.Current.Mask, gc.Current.StrokePattern)
-	}
-
-	gc.rasterizer.Rasterize(painter)
+	gc.rasterizer.Rasterize(gc.painter(gc.Current.StrokePattern))
 	gc.rasterizer.Clear()
 	gc.Current.Path.Clear()
 }
@@ -206,21 +216,140 @@ func (gc *ImageContext) Fill(paths ...*Path) {
 		Flatten(p, flattener, gc.Current.Trm.GetScale())
 	}
 
-	var painter raster.Painter
+	gc.rasterizer.Rasterize(gc.painter(gc.Current.FillPattern))
+	gc.rasterizer.Clear()
+	gc.Current.Path.Clear()
+}
+
+// painter returns a painter compositing the pattern onto the image through
+// the current clip mask.
+func (gc *ImageContext) painter(pattern Pattern) raster.Painter {
 	if gc.Current.Mask == nil {
-		if pattern, ok := gc.Current.FillPattern.(*solidPattern); ok {
+		if solid, ok := pattern.(*solidPattern); ok {
 			p := raster.NewRGBAPainter(gc.img)
-			p.SetColor(pattern.color)
-			painter = p
+			p.SetColor(solid.color)
+			return p
+		}
+	}
+	return newPatternPainter(gc.img, gc.Current.Mask, pattern)
+}
+
+// FillRune draws chr with the current font and font size with its origin at
+// (x, y) and returns the advance in user space. Glyph masks are reused from
+// the glyph cache when one is set.
+func (gc *ImageContext) FillRune(chr rune, x, y float64) float64 {
+	font := gc.Current.Font
+	if font == nil {
+		return 0
+	}
+
+	if fallback, ok := font.(*FallbackFont); ok {
+		if font = fallback.FontFor(chr); font == nil {
+			return 0
 		}
 	}
-	if painter == nil {
-		painter = newPatternPainter(gc.img, gc.Current.Mask, gc.Current.FillPattern)
+
+	gid := GlyphID(chr)
+	if sf, ok := font.(ShapingFont); ok {
+		gid = sf.GlyphIndex(chr)
+	}
+	size := gc.Current.FontSize * float64(gc.dpi) / 72
+	gc.fillGlyph(font, gid, size, x, y)
+	advance := font.Advance(chr, 0)
+	gc.recordGlyph(font, ShapedGlyph{ID: gid, Runes: []rune{chr}, Advance: advance}, size, x, y)
+	return advance * size
+}
+
+// FillString shapes s with the context's shaper and draws it with the
+// current font and font size starting at (x, y). With a FallbackFont each
+// run is drawn with the font covering it. It returns the total advance in
+// user space.
+func (gc *ImageContext) FillString(s string, x, y float64) float64 {
+	if gc.Current.Font == nil {
+		return 0
 	}
 
-	gc.rasterizer.Rasterize(painter)
-	gc.rasterizer.Clear()
-	gc.Current.Path.Clear()
+	if gc.shaper == nil {
+		start := x
+		for _, chr := range s {
+			x += gc.FillRune(chr, x, y)
+		}
+		return x - start
+	}
+
+	start := x
+	for _, run := range gc.shaper.ShapeRuns(s, gc.Current.Font) {
+		x += gc.FillGlyphRun(run, x, y)
+	}
+	return x - start
+}
+
+// FillGlyphRun draws a shaped run at the current font size starting at
+// (x, y) and returns its advance in user space.
+func (gc *ImageContext) FillGlyphRun(run GlyphRun, x, y float64) float64 {
+	if run.Font == nil {
+		return 0
+	}
+
+	size := gc.Current.FontSize * float64(gc.dpi) / 72
+	start := x
+	for _, g := range run.Glyphs {
+		gc.fillGlyph(run.Font, g.ID, size, x+g.Offset.X*size, y-g.Offset.Y*size)
+		gc.recordGlyph(run.Font, g, size, x, y)
+		x += g.Advance * size
+	}
+	return x - start
+}
+
+// recordGlyph reports the letters of a glyph drawn at (x, y) to the letter
+// recorder. Ligatures are split into one letter per rune of equal width.
+func (gc *ImageContext) recordGlyph(font Font, g ShapedGlyph, size, x, y float64) {
+	if gc.recorder == nil || len(g.Runes) == 0 {
+		return
+	}
+
+	// letters span the em box rather than the font's bounding box, which is
+	// usually far taller than the text
+	bottom, top := -0.2, 0.8
+	trm := Matrix{size, 0, 0, -size, x, y}.Concat(gc.Current.Trm)
+	width := g.Advance / float64(len(g.Runes))
+	for i, chr := range g.Runes {
+		left, right := float64(i)*width, float64(i+1)*width
+		var c color.Color
+		if gc.Current.FillPattern != nil {
+			origin := trm.TransformPoint(Point{left, 0})
+			c = gc.Current.FillPattern.ColorAt(int(origin.X), int(origin.Y))
+		}
+		gc.recorder(Letter{
+			Rune:          chr,
+			GlyphID:       g.ID,
+			Quad:          trm.TransformQuad(MakeQuad(left, bottom, right, top)),
+			StartBaseline: trm.TransformPoint(Point{left, 0}),
+			EndBaseline:   trm.TransformPoint(Point{right, 0}),
+			FontData:      font.Info(),
+			FontSize:      gc.Current.FontSize,
+			Color:         c,
+			Confidence:    1,
+		})
+	}
+}
+
+func (gc *ImageContext) fillGlyph(font Font, gid GlyphID, size, x, y float64) {
+	if gc.glyphCache == nil {
+		glyph := glyphOutline(font, gid, Matrix{size, 0, 0, -size, x, y})
+		if glyph == nil || glyph.Path == nil {
+			return
+		}
+		gc.Save()
+		gc.BeginPath()
+		gc.SetFillRule(FillRuleWinding)
+		gc.Fill(glyph.Path)
+		gc.Restore()
+		return
+	}
+
+	trm := NewTranslationMatrix(x, y).Concat(gc.Current.Trm)
+	paintMask(gc.painter(gc.Current.FillPattern), gc.glyphCache.Mask(font, gid, size, trm))
 }
 
 func (gc *ImageContext) Clip(paths ...*Path) {
@@ -289,6 +418,7 @@ type ContextStack struct {
 	FillRule      FillRule
 	Cap           LineCap
 	Join          LineJoin
+	MiterLimit    float64
 	FontSize      float64
 	FontData      FontData
 	Font          Font
@@ -309,6 +439,7 @@ func NewStackGraphicContext() *StackGraphicContext {
 			Cap:           RoundCap,
 			FillRule:      FillRuleEvenOdd,
 			Join:          RoundJoin,
+			MiterLimit:    DefaultMiterLimit,
 			FontSize:      10,
 			FontData:      defaultFontData,
 			FillPattern:   defaultFillStyle,
@@ -349,6 +480,7 @@ func (gc *StackGraphicContext) SetStroke(stroke *Stroke) {
 	gc.Current.Cap = stroke.LineCap
 	gc.Current.Join = stroke.LineJoin
 	gc.Current.LineWidth = stroke.LineWidth
+	gc.Current.MiterLimit = stroke.MiterLimit
 }
 
 func (gc *StackGraphicContext) SetStrokeColor(c color.Color) {
@@ -375,6 +507,10 @@ func (gc *StackGraphicContext) SetLineJoin(join LineJoin) {
 	gc.Current.Join = join
 }
 
+func (gc *StackGraphicContext) SetMiterLimit(limit float64) {
+	gc.Current.MiterLimit = limit
+}
+
 func (gc *StackGraphicContext) SetLineDash(dash []float64, dashOffset float64) {
 	gc.Current.Dash = dash
 	gc.Current.DashOffset = dashOffset
@@ -456,6 +592,34 @@ func (gc *StackGraphicContext) ClosePath() {
 	gc.Current.Path.Close()
 }
 
+func (gc *StackGraphicContext) ArcTo(cx, cy, rx, ry, start, angle float64) {
+	gc.Current.Path.ArcTo(cx, cy, rx, ry, start, angle)
+}
+
+func (gc *StackGraphicContext) TangentArcTo(x1, y1, x2, y2, radius float64) {
+	gc.Current.Path.TangentArcTo(x1, y1, x2, y2, radius)
+}
+
+func (gc *StackGraphicContext) Ellipse(cx, cy, rx, ry float64) {
+	gc.Current.Path.Ellipse(cx, cy, rx, ry)
+}
+
+func (gc *StackGraphicContext) Circle(cx, cy, r float64) {
+	gc.Current.Path.Circle(cx, cy, r)
+}
+
+func (gc *StackGraphicContext) RoundedRect(rect Rect, radii CornerRadii) {
+	gc.Current.Path.RoundedRect(rect, radii)
+}
+
+func (gc *StackGraphicContext) Polygon(cx, cy, r float64, sides int, rotation float64) {
+	gc.Current.Path.Polygon(cx, cy, r, sides, rotation)
+}
+
+func (gc *StackGraphicContext) Star(cx, cy, outer, inner float64, points int, rotation float64) {
+	gc.Current.Path.Star(cx, cy, outer, inner, points, rotation)
+}
+
 func (gc *StackGraphicContext) Save() {
 	context := new(ContextStack)
 	context.FontSize = gc.Current.FontSize
@@ -469,6 +633,7 @@ func (gc *StackGraphicContext) Save() {
 	context.DashOffset = gc.Current.DashOffset
 	context.Cap = gc.Current.Cap
 	context.Join = gc.Current.Join
+	context.MiterLimit = gc.Current.MiterLimit
 	context.Path = gc.Current.Path.Copy()
 	context.Scale = gc.Current.Scale
 	context.Trm = gc.Current.Trm
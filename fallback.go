@@ -0,0 +1,295 @@
+package gfx
+
+import (
+	"sync"
+	"unicode"
+)
+
+// FallbackFont draws each rune with the first font in an ordered list that
+// has a glyph for it. The first font is the primary font; it supplies the
+// name and style and draws the .notdef glyph for runes no font covers.
+//
+// System font records may be appended after the loaded fonts. Their cmap
+// coverage is read once per file, and a record is only opened when its
+// coverage contains a rune no font before it covers. Runes no font covers
+// are remembered, so looking them up again costs nothing.
+type FallbackFont struct {
+	mu       sync.Mutex
+	fonts    []Font
+	records  []SystemFontRecord
+	loaded   map[string]Font
+	cache    FontCache
+	resolved map[rune]chainFont
+}
+
+// chainFont is a font of the chain with a comparable key for its place in
+// the chain: its index among the fonts, or the path of its system font
+// record. Fonts themselves may not be comparable.
+type chainFont struct {
+	font  Font
+	index int
+	path  string
+}
+
+func (c chainFont) same(o chainFont) bool { return c.index == o.index && c.path == o.path }
+
+// NewFallbackFont creates a fallback chain that tries fonts in order.
+func NewFallbackFont(fonts ...Font) *FallbackFont {
+	return &FallbackFont{fonts: fonts, loaded: make(map[string]Font), resolved: make(map[rune]chainFont)}
+}
+
+// Append adds fonts to the chain, after the fonts added before but ahead of
+// any system font records.
+func (f *FallbackFont) Append(fonts ...Font) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fonts = append(f.fonts, fonts...)
+	f.resolved = make(map[rune]chainFont)
+}
+
+// AppendFromCache adds the fonts cache holds for each of data. Entries the
+// cache cannot load are skipped.
+func (f *FallbackFont) AppendFromCache(cache FontCache, data ...FontData) {
+	for _, d := range data {
+		if font, err := cache.Load(d); err == nil {
+			f.Append(font)
+		}
+	}
+}
+
+// AppendSystemFonts adds system font records to the end of the chain. A
+// record is loaded the first time its coverage holds a rune no font before
+// it covers; loaded fonts are stored in cache if it is not nil. Records whose
+// coverage cannot be read or that fail to load are dropped.
+func (f *FallbackFont) AppendSystemFonts(cache FontCache, records ...SystemFontRecord) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.records = append(f.records, records...)
+	if cache != nil {
+		f.cache = cache
+	}
+	f.resolved = make(map[rune]chainFont)
+}
+
+// Fonts returns the fonts loaded so far, in fallback order.
+func (f *FallbackFont) Fonts() []Font {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fonts := append([]Font(nil), f.fonts...)
+	for _, record := range f.records {
+		if font, ok := f.loaded[record.Path]; ok {
+			fonts = append(fonts, font)
+		}
+	}
+	return fonts
+}
+
+// FontFor returns the font used to draw chr: the first font covering it, or
+// the primary font if none does.
+func (f *FallbackFont) FontFor(chr rune) Font {
+	return f.fontFor(chr).font
+}
+
+func (f *FallbackFont) fontFor(chr rune) chainFont {
+	f.mu.Lock()
+	if font, ok := f.resolved[chr]; ok {
+		f.mu.Unlock()
+		return font
+	}
+	resolved := f.resolved
+	fonts := append([]Font(nil), f.fonts...)
+	records := append([]SystemFontRecord(nil), f.records...)
+	f.mu.Unlock()
+
+	font, ok := f.resolve(chr, fonts, records)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !ok {
+		font = f.primaryLocked()
+	}
+	resolved[chr] = font
+	return font
+}
+
+// resolve finds the first of fonts and records covering chr. Files are read
+// without holding the lock.
+func (f *FallbackFont) resolve(chr rune, fonts []Font, records []SystemFontRecord) (chainFont, bool) {
+	for i, font := range fonts {
+		if FontHasGlyph(font, chr) {
+			return chainFont{font: font, index: i}, true
+		}
+	}
+
+	for _, record := range records {
+		runes, err := record.Coverage()
+		if err != nil {
+			f.drop(record)
+			continue
+		}
+		if !runes.Contains(float64(chr)) {
+			continue
+		}
+		if font := f.load(record); font != nil && FontHasGlyph(font, chr) {
+			return chainFont{font: font, index: -1, path: record.Path}, true
+		}
+	}
+	return chainFont{}, false
+}
+
+// load returns the font of record, loading it on first use.
+func (f *FallbackFont) load(record SystemFontRecord) Font {
+	f.mu.Lock()
+	font, ok := f.loaded[record.Path]
+	f.mu.Unlock()
+	if ok {
+		return font
+	}
+
+	font, err := record.Load()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err != nil {
+		f.dropLocked(record)
+		return nil
+	}
+	if loaded, ok := f.loaded[record.Path]; ok {
+		return loaded
+	}
+	f.loaded[record.Path] = font
+	if f.cache != nil {
+		f.cache.Store(font)
+	}
+	return font
+}
+
+func (f *FallbackFont) drop(record SystemFontRecord) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.dropLocked(record)
+}
+
+func (f *FallbackFont) dropLocked(record SystemFontRecord) {
+	for i, r := range f.records {
+		if r.Path == record.Path {
+			f.records = append(f.records[:i:i], f.records[i+1:]...)
+			return
+		}
+	}
+}
+
+// FontRun is a span of text covered by a single font.
+type FontRun struct {
+	Font   Font
+	Text   string
+	Offset int
+}
+
+// Runs splits text into runs drawn with the same font. Spaces, control
+// characters and combining marks the current run's font covers stay in that
+// run rather than starting a new one.
+func (f *FallbackFont) Runs(text string) (runs []FontRun) {
+	var current chainFont
+	start := 0
+	for i, chr := range text {
+		if current.font != nil {
+			if unicode.IsSpace(chr) || unicode.IsControl(chr) {
+				continue
+			}
+			if unicode.Is(unicode.Mn, chr) && FontHasGlyph(current.font, chr) {
+				continue
+			}
+		}
+
+		font := f.fontFor(chr)
+		if font.font == nil || current.font != nil && font.same(current) {
+			continue
+		}
+		if current.font != nil {
+			runs = append(runs, FontRun{Font: current.font, Text: text[start:i], Offset: start})
+		}
+		current, start = font, i
+	}
+
+	if current.font != nil && start < len(text) {
+		runs = append(runs, FontRun{Font: current.font, Text: text[start:], Offset: start})
+	}
+	return
+}
+
+func (f *FallbackFont) primary() Font {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.primaryLocked().font
+}
+
+func (f *FallbackFont) primaryLocked() chainFont {
+	if len(f.fonts) > 0 {
+		return chainFont{font: f.fonts[0]}
+	}
+	for _, record := range f.records {
+		if font, ok := f.loaded[record.Path]; ok {
+			return chainFont{font: font, index: -1, path: record.Path}
+		}
+	}
+	return chainFont{}
+}
+
+func (f *FallbackFont) Name() string {
+	if font := f.primary(); font != nil {
+		return font.Name()
+	}
+	return ""
+}
+
+func (f *FallbackFont) Info() FontData {
+	if font := f.primary(); font != nil {
+		return font.Info()
+	}
+	return FontData{}
+}
+
+// BoundingBox returns the union of the bounding boxes of the loaded fonts.
+func (f *FallbackFont) BoundingBox() (bbox Rect) {
+	for i, font := range f.Fonts() {
+		if i == 0 {
+			bbox = font.BoundingBox()
+		} else {
+			bbox = bbox.Union(font.BoundingBox())
+		}
+	}
+	return
+}
+
+// Glyph returns the glyph of chr from the font covering it. Runes no font
+// covers get the primary font's .notdef glyph, and an empty chain gives an
+// empty glyph, so the result is never nil.
+func (f *FallbackFont) Glyph(chr rune, trm Matrix) *Glyph {
+	var glyph *Glyph
+	if font := f.FontFor(chr); font != nil {
+		if sf, ok := font.(ShapingFont); ok && !FontHasGlyph(font, chr) {
+			glyph = sf.GlyphOutline(0, trm)
+		} else {
+			glyph = font.Glyph(chr, trm)
+		}
+	}
+	if glyph == nil {
+		glyph = &Glyph{Path: new(Path)}
+	}
+	return glyph
+}
+
+func (f *FallbackFont) Advance(chr rune, mode int) float64 {
+	if font := f.FontFor(chr); font != nil {
+		return font.Advance(chr, mode)
+	}
+	return 0
+}
+
+// HasGlyph reports whether any font in the chain, including system fonts
+// not loaded yet, covers chr.
+func (f *FallbackFont) HasGlyph(chr rune) bool {
+	font := f.FontFor(chr)
+	return font != nil && FontHasGlyph(font, chr)
+}
@@ -0,0 +1,218 @@
+package gfx_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bryanmatteson/gfx"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// coverFont is a font with glyphs for the runes of a string.
+type coverFont struct {
+	name  string
+	runes string
+}
+
+func (f *coverFont) Name() string                       { return f.name }
+func (f *coverFont) BoundingBox() gfx.Rect              { return gfx.MakeRect(0, 0, 1, 1) }
+func (f *coverFont) Info() gfx.FontData                 { return gfx.FontData{Name: f.name} }
+func (f *coverFont) Advance(chr rune, mode int) float64 { return 1 }
+func (f *coverFont) HasGlyph(chr rune) bool             { return strings.ContainsRune(f.runes, chr) }
+
+func (f *coverFont) Glyph(chr rune, trm gfx.Matrix) *gfx.Glyph {
+	return &gfx.Glyph{Path: new(gfx.Path), Width: trm.A}
+}
+
+func TestFallbackFontChain(t *testing.T) {
+	latin := &coverFont{"latin", "abcdefghijklmnopqrstuvwxyz\u0301"}
+	greek := &coverFont{"greek", "αβγ\u0301"}
+	chain := gfx.NewFallbackFont(latin, greek)
+
+	for chr, want := range map[rune]gfx.Font{'a': latin, 'β': greek, '中': latin} {
+		if got := chain.FontFor(chr); got != want {
+			t.Errorf("FontFor(%q) = %v, want %v", chr, got.Name(), want.Name())
+		}
+	}
+	if !chain.HasGlyph('a') || !chain.HasGlyph('γ') || chain.HasGlyph('中') {
+		t.Error("HasGlyph does not match the fonts' coverage")
+	}
+	if chain.Name() != "latin" || chain.Info().Name != "latin" {
+		t.Errorf("chain named %q", chain.Name())
+	}
+
+	type run struct{ font, text string }
+	var got []run
+	for _, r := range chain.Runs("ab αβ\u0301 ce\u0301 中") {
+		got = append(got, run{r.Font.Name(), r.Text})
+	}
+	// spaces, and marks the current font covers, stay in the current run;
+	// runes no font covers fall back to the primary font
+	want := []run{{"latin", "ab "}, {"greek", "αβ\u0301 "}, {"latin", "ce\u0301 中"}}
+	if len(got) != len(want) {
+		t.Fatalf("Runs = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Runs = %q, want %q", got, want)
+			break
+		}
+	}
+}
+
+// listFont is a font of an uncomparable type, with glyphs for the runes it
+// lists.
+type listFont []rune
+
+func (f listFont) Name() string                       { return string(f) }
+func (f listFont) BoundingBox() gfx.Rect              { return gfx.MakeRect(0, 0, 1, 1) }
+func (f listFont) Info() gfx.FontData                 { return gfx.FontData{Name: string(f)} }
+func (f listFont) Advance(chr rune, mode int) float64 { return 1 }
+
+func (f listFont) Glyph(chr rune, trm gfx.Matrix) *gfx.Glyph {
+	return &gfx.Glyph{Path: new(gfx.Path), Width: trm.A}
+}
+
+func (f listFont) HasGlyph(chr rune) bool {
+	for _, r := range f {
+		if r == chr {
+			return true
+		}
+	}
+	return false
+}
+
+func TestFallbackFontUncomparable(t *testing.T) {
+	chain := gfx.NewFallbackFont(listFont("ab "), listFont("xy"))
+	var got []string
+	for _, r := range chain.Runs("ab xyba") {
+		got = append(got, r.Font.Name()+":"+r.Text)
+	}
+	want := []string{"ab :ab ", "xy:xy", "ab :ba"}
+	if len(got) != len(want) {
+		t.Fatalf("Runs = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Runs = %q, want %q", got, want)
+			break
+		}
+	}
+}
+
+// plainFont is a font that cannot tell which runes it covers.
+type plainFont struct{ name string }
+
+func (f *plainFont) Name() string                       { return f.name }
+func (f *plainFont) BoundingBox() gfx.Rect              { return gfx.MakeRect(0, 0, 1, 1) }
+func (f *plainFont) Info() gfx.FontData                 { return gfx.FontData{Name: f.name} }
+func (f *plainFont) Advance(chr rune, mode int) float64 { return 1 }
+
+func (f *plainFont) Glyph(chr rune, trm gfx.Matrix) *gfx.Glyph {
+	return &gfx.Glyph{Path: new(gfx.Path), Width: trm.A}
+}
+
+func TestFallbackFontCoverage(t *testing.T) {
+	latin := &coverFont{"latin", "abc"}
+	plain := &plainFont{"plain"}
+	if _, ok := gfx.Font(plain).(gfx.GlyphCoverer); ok {
+		t.Fatal("plainFont reports its coverage")
+	}
+
+	// a font that cannot report its coverage is taken to cover everything
+	chain := gfx.NewFallbackFont(latin, plain)
+	if chain.FontFor('a') != latin || chain.FontFor('中') != plain || !chain.HasGlyph('中') {
+		t.Errorf("FontFor('中') = %v", chain.FontFor('中').Name())
+	}
+
+	// a shaping font covers the runes it maps to a glyph
+	regular, err := gfx.ParseFont(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sf := struct{ gfx.ShapingFont }{regular.(gfx.ShapingFont)}
+	if !gfx.FontHasGlyph(sf, 'a') || gfx.FontHasGlyph(sf, '中') {
+		t.Error("FontHasGlyph does not follow the glyph indices of a shaping font")
+	}
+}
+
+func TestFallbackFontEmpty(t *testing.T) {
+	chain := gfx.NewFallbackFont()
+	if chain.FontFor('a') != nil || chain.HasGlyph('a') || chain.Name() != "" {
+		t.Error("empty chain has a font")
+	}
+	if runs := chain.Runs("abc"); len(runs) != 0 {
+		t.Errorf("Runs = %v", runs)
+	}
+	if g := chain.Glyph('a', gfx.IdentityMatrix); g == nil || g.Path == nil || !g.Path.IsEmpty() {
+		t.Errorf("Glyph = %v, want an empty glyph", g)
+	}
+	if a := chain.Advance('a', 0); a != 0 {
+		t.Errorf("Advance = %v", a)
+	}
+}
+
+func TestFallbackFontNotdef(t *testing.T) {
+	regular, err := gfx.ParseFont(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := gfx.NewFallbackFont(regular)
+	glyph := chain.Glyph('中', gfx.IdentityMatrix)
+	notdef := regular.(gfx.ShapingFont).GlyphOutline(0, gfx.IdentityMatrix)
+	if glyph == nil || glyph.Path.IsEmpty() || glyph.Path.SVG() != notdef.Path.SVG() {
+		t.Errorf("glyph for an uncovered rune = %v, want .notdef", glyph)
+	}
+}
+
+func TestFallbackFontSystemRecords(t *testing.T) {
+	dir := t.TempDir()
+	record := func(name string, data []byte) gfx.SystemFontRecord {
+		path := filepath.Join(dir, name+".ttf")
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return gfx.SystemFontRecord{Kind: gfx.TrueType, Path: path, Name: name}
+	}
+	broken := record("broken", []byte("not a font"))
+	regular := record("regular", goregular.TTF)
+	bold := record("bold", gobold.TTF)
+
+	// the cmap coverage matches the glyphs the font has
+	runes, err := regular.Coverage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	font, err := gfx.ParseFont(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for chr := rune(0); chr < 0x3000; chr++ {
+		if runes.Contains(float64(chr)) != gfx.FontHasGlyph(font, chr) {
+			t.Errorf("coverage of %U = %v, font has glyph = %v", chr, runes.Contains(float64(chr)), gfx.FontHasGlyph(font, chr))
+		}
+	}
+
+	latin := &coverFont{"latin", "abc"}
+	chain := gfx.NewFallbackFont(latin)
+	chain.AppendSystemFonts(nil, broken, regular, bold)
+
+	// a rune no font covers loads nothing
+	if chain.FontFor('中') != latin || len(chain.Fonts()) != 1 {
+		t.Errorf("uncovered rune loaded %d fonts", len(chain.Fonts())-1)
+	}
+
+	// the first record covering a rune is loaded, and only that one
+	if got := chain.FontFor('Ж'); got == latin || got.Name() != font.Name() {
+		t.Errorf("FontFor('Ж') = %v", got.Name())
+	}
+	if fonts := chain.Fonts(); len(fonts) != 2 || fonts[0] != latin {
+		t.Errorf("Fonts = %d fonts", len(fonts))
+	}
+	if chain.FontFor('a') != latin {
+		t.Error("loaded font took precedence over the primary font")
+	}
+}
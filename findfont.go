@@ -15,6 +15,8 @@ const (
 	OpenType
 	TrueTypeCollection
 	OpenTypeCollection
+	WOFF
+	WOFF2
 )
 
 type SystemFontRecord struct {
@@ -53,6 +55,10 @@ func isFontFile(fileName string) (FontKind, bool) {
 		return OpenType, true
 	case strings.HasSuffix(lower, ".otc"):
 		return OpenTypeCollection, true
+	case strings.HasSuffix(lower, ".woff"):
+		return WOFF, true
+	case strings.HasSuffix(lower, ".woff2"):
+		return WOFF2, true
 	}
 
 	return Unknown, false
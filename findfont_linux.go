@@ -2,6 +2,11 @@
 
 package gfx
 
+import (
+	"os"
+	"path/filepath"
+)
+
 func getFontDirectories() []string {
 	directories := getUserFontDirs()
 	directories = append(directories, getSystemFontDirs()...)
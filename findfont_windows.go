@@ -2,6 +2,11 @@
 
 package gfx
 
+import (
+	"os"
+	"path/filepath"
+)
+
 func getFontDirectories() (paths []string) {
 	return []string{
 		filepath.Join(os.Getenv("windir"), "Fonts"),
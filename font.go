@@ -25,10 +25,15 @@ type FontData struct {
 	Name   string
 	Style  FontStyle
 	Family FontFamily
+
+	// Synthetic holds the styles that are emulated rather than provided by
+	// a dedicated face. See SynthesizeFont.
+	Synthetic FontStyle
 }
 
 func (f FontData) IsBold() bool       { return f.Style&FontStyleBold == FontStyleBold }
 func (f FontData) IsItalic() bool     { return f.Style&FontStyleItalic == FontStyleItalic }
+func (f FontData) IsSynthetic() bool  { return f.Synthetic != 0 }
 func (f FontData) IsSerif() bool      { return f.Family == FontFamilySerif }
 func (f FontData) IsSansSerif() bool  { return f.Family == FontFamilySans }
 func (f FontData) IsMonospaced() bool { return f.Family == FontFamilyMono }
@@ -68,6 +73,26 @@ type Font interface {
 	Advance(chr rune, mode int) float64
 }
 
+// GlyphCoverer is implemented by fonts that can tell whether they have a
+// glyph of their own for a rune, rather than drawing .notdef for it.
+type GlyphCoverer interface {
+	HasGlyph(chr rune) bool
+}
+
+// FontHasGlyph reports whether font has a glyph for chr. Fonts implementing
+// GlyphCoverer are asked directly. Other shaping fonts cover the runes they
+// map to a glyph other than .notdef, and any other font is taken to cover
+// every rune.
+func FontHasGlyph(font Font, chr rune) bool {
+	switch f := font.(type) {
+	case GlyphCoverer:
+		return f.HasGlyph(chr)
+	case ShapingFont:
+		return f.GlyphIndex(chr) != 0
+	}
+	return true
+}
+
 type FontCache interface {
 	Load(FontData) (Font, error)
 	Store(Font)
@@ -1,6 +1,10 @@
 package afm
 
-import "github.com/bryanmatteson/gfx"
+import (
+	"sort"
+
+	"github.com/bryanmatteson/gfx"
+)
 
 type WritingDirections byte
 
@@ -106,6 +110,17 @@ type Metrics struct {
 
 	// Metrics for the individual characters.
 	CharacterMetrics map[string]IndividualCharacterMetric
+
+	// Pair kerning for writing direction 0, in file order.
+	KernPairs []KernPair
+}
+
+type KernPair struct {
+	// The names of the first and second characters of the pair.
+	First, Second string
+
+	// The change to the x width of the first character.
+	X float64
 }
 
 type IndividualCharacterMetric struct {
@@ -132,6 +147,9 @@ type IndividualCharacterMetric struct {
 
 	// Ligature information.
 	Ligature Ligature
+
+	// Every ligature the character starts, in file order.
+	Ligatures []Ligature
 }
 
 type Ligature struct {
@@ -141,3 +159,80 @@ type Ligature struct {
 	// The current character.
 	Value string
 }
+
+// LigatureLookup returns the ligatures of the metrics as one substitution
+// lookup, mapping glyph names to glyph IDs with glyph.
+// Ligatures built on other ligatures, such as ffi from ff and i, are
+// expanded to the characters they are made of. Ligatures naming a glyph
+// that glyph does not know are left out.
+func (m Metrics) LigatureLookup(glyph func(name string) (gfx.GlyphID, bool)) gfx.SubstitutionLookup {
+	type source struct{ first, successor string }
+	sources := make(map[string]source)
+	var names []string
+	for name, metric := range m.CharacterMetrics {
+		for _, lig := range metric.Ligatures {
+			if _, ok := sources[lig.Value]; !ok {
+				sources[lig.Value] = source{name, lig.Successor}
+				names = append(names, lig.Value)
+			}
+		}
+	}
+	sort.Strings(names)
+
+	var expand func(name string, depth int) []string
+	expand = func(name string, depth int) []string {
+		src, ok := sources[name]
+		if !ok || depth > len(sources) {
+			return []string{name}
+		}
+		return append(expand(src.first, depth+1), src.successor)
+	}
+
+	var lookup gfx.SubstitutionLookup
+next:
+	for _, name := range names {
+		output, ok := glyph(name)
+		if !ok {
+			continue
+		}
+		var input []gfx.GlyphID
+		for _, part := range expand(name, 0) {
+			gid, ok := glyph(part)
+			if !ok {
+				continue next
+			}
+			input = append(input, gid)
+		}
+		lookup.Substitutions = append(lookup.Substitutions, gfx.Substitution{Input: input, Output: output})
+	}
+	return lookup
+}
+
+// ShapingData returns the ligatures of the metrics as standard ligatures and
+// their kern pairs as kerning, ready for gfx.WithShapingData. Glyph names
+// are mapped to glyph IDs with glyph, and kern pairs naming a glyph it does
+// not know are left out.
+func (m Metrics) ShapingData(glyph func(name string) (gfx.GlyphID, bool)) gfx.ShapingData {
+	data := gfx.ShapingData{Substitutions: make(map[string][]gfx.SubstitutionLookup)}
+	if lookup := m.LigatureLookup(glyph); len(lookup.Substitutions) > 0 {
+		data.Substitutions[gfx.FeatureStandardLigatures] = []gfx.SubstitutionLookup{lookup}
+	}
+
+	kerning := make(map[[2]gfx.GlyphID]float64)
+	for _, pair := range m.KernPairs {
+		first, ok := glyph(pair.First)
+		if !ok {
+			continue
+		}
+		second, ok := glyph(pair.Second)
+		if !ok {
+			continue
+		}
+		// afm metrics are in thousandths of an em
+		kerning[[2]gfx.GlyphID{first, second}] = pair.X / 1000
+	}
+	if len(kerning) > 0 {
+		data.Kerning = func(left, right gfx.GlyphID) float64 { return kerning[[2]gfx.GlyphID{left, right}] }
+	}
+	return data
+}
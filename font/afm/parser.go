@@ -283,6 +283,12 @@ func ParseReader(r io.Reader) (metrics Metrics, err error) {
 			if end != EndCharMetrics {
 				return metrics, fmt.Errorf("character metrics section did not end with %s, instead it was %s", EndCharMetrics, end)
 			}
+		case StartKernPairs, StartKernPairs0:
+			pairs, err := parseKernPairs(scanner)
+			if err != nil {
+				return metrics, err
+			}
+			metrics.KernPairs = append(metrics.KernPairs, pairs...)
 		case EndFontMetrics:
 		case StartKernData:
 		default:
@@ -292,6 +298,50 @@ func ParseReader(r io.Reader) (metrics Metrics, err error) {
 	return metrics, nil
 }
 
+// parseKernPairs reads the KPX and KP pairs of a kern pair section up to its
+// EndKernPairs. Pairs given by character code with KPH, and KPY pairs, which
+// only move the baseline, are skipped.
+func parseKernPairs(scanner *scanner) (pairs []KernPair, err error) {
+	// the section starts with the pair count, which is only a hint
+	if _, err = scanner.line(); err != nil {
+		return nil, err
+	}
+
+	for !scanner.eof() {
+		token, err := scanner.word()
+		if err != nil {
+			return nil, err
+		}
+
+		switch token {
+		case EndKernPairs, EndFontMetrics:
+			return pairs, nil
+		case KernPairKpx, KernPairKp:
+			var pair KernPair
+			if pair.First, err = scanner.word(); err != nil {
+				return nil, err
+			}
+			if pair.Second, err = scanner.word(); err != nil {
+				return nil, err
+			}
+			if pair.X, err = scanner.float(); err != nil {
+				return nil, err
+			}
+			if token == KernPairKp {
+				if _, err = scanner.float(); err != nil {
+					return nil, err
+				}
+			}
+			pairs = append(pairs, pair)
+		default:
+			if _, err = scanner.line(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return pairs, nil
+}
+
 func parseCharMetric(scanner *scanner) (metric IndividualCharacterMetric, err error) {
 	line, err := scanner.line()
 	if err != nil {
@@ -411,6 +461,7 @@ func parseCharMetric(scanner *scanner) (metric IndividualCharacterMetric, err er
 		case CharmetricsL:
 			metric.Ligature.Successor = parts[1]
 			metric.Ligature.Value = parts[2]
+			metric.Ligatures = append(metric.Ligatures, metric.Ligature)
 		case CharmetricsN:
 			metric.Name = parts[1]
 		}
@@ -1,10 +1,14 @@
 package afm_test
 
 import (
+	"fmt"
+	"math"
 	"os"
 	"testing"
 
+	"github.com/bryanmatteson/gfx"
 	"github.com/bryanmatteson/gfx/font/afm"
+	"golang.org/x/image/font/gofont/goregular"
 )
 
 func TestParser(t *testing.T) {
@@ -20,3 +24,90 @@ func TestParser(t *testing.T) {
 	}
 	_ = metrics
 }
+
+func TestLigatureLookup(t *testing.T) {
+	const data = `StartFontMetrics 4.1
+FontName Test
+StartCharMetrics 5
+C 102 ; WX 333 ; N f ; B 0 0 300 700 ; L i fi ; L l fl ; L f ff ;
+C 105 ; WX 278 ; N i ; B 0 0 200 700 ;
+C 108 ; WX 278 ; N l ; B 0 0 200 700 ;
+C -1 ; WX 600 ; N ff ; B 0 0 580 700 ; L i ffi ;
+C -1 ; WX 800 ; N ffi ; B 0 0 780 700 ;
+EndCharMetrics
+EndFontMetrics
+`
+	metrics, err := afm.Parse([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := map[string]gfx.GlyphID{"f": 1, "i": 2, "l": 3, "ff": 4, "ffi": 5}
+	lookup := metrics.LigatureLookup(func(name string) (gfx.GlyphID, bool) {
+		gid, ok := ids[name]
+		return gid, ok
+	})
+
+	got := make(map[gfx.GlyphID][]gfx.GlyphID)
+	for _, sub := range lookup.Substitutions {
+		got[sub.Output] = sub.Input
+	}
+	want := map[gfx.GlyphID][]gfx.GlyphID{4: {1, 1}, 5: {1, 1, 2}}
+	if len(got) != 2 || fmt.Sprint(got[4]) != fmt.Sprint(want[4]) || fmt.Sprint(got[5]) != fmt.Sprint(want[5]) {
+		t.Errorf("substitutions = %v, want %v", got, want)
+	}
+}
+
+func TestShapingDataFillString(t *testing.T) {
+	const data = `StartFontMetrics 4.1
+FontName Test
+StartCharMetrics 4
+C 65 ; WX 667 ; N A ; B 0 0 600 700 ;
+C 86 ; WX 667 ; N V ; B 0 0 600 700 ;
+C 102 ; WX 333 ; N f ; B 0 0 300 700 ; L i fi ;
+C 105 ; WX 278 ; N i ; B 0 0 200 700 ;
+EndCharMetrics
+StartKernData
+StartKernPairs 1
+KPX A V -80
+EndKernPairs
+EndKernData
+EndFontMetrics
+`
+	metrics, err := afm.Parse([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metrics.KernPairs) != 1 || metrics.KernPairs[0] != (afm.KernPair{First: "A", Second: "V", X: -80}) {
+		t.Fatalf("kern pairs = %+v", metrics.KernPairs)
+	}
+
+	regular, err := gfx.ParseFont(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a font without shaping data of its own, whose glyph IDs are runes
+	plain := struct{ gfx.Font }{regular}
+	runes := map[string]rune{"A": 'A', "V": 'V', "f": 'f', "i": 'i', "fi": 'ﬁ'}
+	font := gfx.WithShapingData(plain, metrics.ShapingData(func(name string) (gfx.GlyphID, bool) {
+		chr, ok := runes[name]
+		return gfx.GlyphID(chr), ok
+	}))
+
+	gc := gfx.NewContext(100, 40)
+	gc.SetFont(font)
+	gc.SetFontSize(10)
+	var letters []gfx.Letter
+	gc.SetLetterRecorder(func(l gfx.Letter) { letters = append(letters, l) })
+
+	gc.FillString("fi", 0, 20)
+	if len(letters) != 2 || letters[0].GlyphID != 'ﬁ' || letters[1].GlyphID != 'ﬁ' {
+		t.Errorf("letters of fi = %+v, want both drawn with the fi ligature", letters)
+	}
+
+	width := gc.FillString("AV", 0, 20)
+	want := (regular.Advance('A', 0) - 0.08 + regular.Advance('V', 0)) * 10
+	if math.Abs(width-want) > 1e-9 {
+		t.Errorf("width of AV = %v, want %v", width, want)
+	}
+}
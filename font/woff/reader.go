@@ -0,0 +1,104 @@
+package woff
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+type reader struct {
+	b   []byte
+	pos int
+}
+
+func newReader(b []byte) *reader {
+	return &reader{b: b}
+}
+
+func (r *reader) rem() int { return len(r.b) - r.pos }
+
+func (r *reader) bytes(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.b) {
+		return nil, fmt.Errorf("read past end of buffer")
+	}
+	b := r.b[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *reader) uint8() (uint8, error) {
+	b, err := r.bytes(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (r *reader) uint16() (uint16, error) {
+	b, err := r.bytes(2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint16(b), nil
+}
+
+func (r *reader) int16() (int16, error) {
+	v, err := r.uint16()
+	return int16(v), err
+}
+
+func (r *reader) uint32() (uint32, error) {
+	b, err := r.bytes(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(b), nil
+}
+
+// base128 reads a UIntBase128 value as defined by the WOFF2 specification.
+func (r *reader) base128() (uint32, error) {
+	var accum uint32
+	for i := 0; i < 5; i++ {
+		b, err := r.uint8()
+		if err != nil {
+			return 0, err
+		}
+		if i == 0 && b == 0x80 {
+			return 0, fmt.Errorf("base128 value has leading zeros")
+		}
+		if accum&0xfe000000 != 0 {
+			return 0, fmt.Errorf("base128 value overflows")
+		}
+		accum = accum<<7 | uint32(b&0x7f)
+		if b&0x80 == 0 {
+			return accum, nil
+		}
+	}
+	return 0, fmt.Errorf("base128 value exceeds 5 bytes")
+}
+
+// uint255 reads a 255UInt16 value as defined by the WOFF2 specification.
+func (r *reader) uint255() (uint16, error) {
+	const (
+		oneMoreByteCode1 = 255
+		oneMoreByteCode2 = 254
+		wordCode         = 253
+		lowestUCode      = 253
+	)
+
+	code, err := r.uint8()
+	if err != nil {
+		return 0, err
+	}
+
+	switch code {
+	case wordCode:
+		return r.uint16()
+	case oneMoreByteCode1:
+		b, err := r.uint8()
+		return uint16(b) + lowestUCode, err
+	case oneMoreByteCode2:
+		b, err := r.uint8()
+		return uint16(b) + lowestUCode*2, err
+	}
+	return uint16(code), nil
+}
@@ -0,0 +1,86 @@
+package woff
+
+import (
+	"encoding/binary"
+	"sort"
+)
+
+type table struct {
+	tag  uint32
+	data []byte
+}
+
+const (
+	tagHead = 0x68656164 // 'head'
+	tagGlyf = 0x676c7966 // 'glyf'
+	tagLoca = 0x6c6f6361 // 'loca'
+	tagHmtx = 0x686d7478 // 'hmtx'
+	tagHhea = 0x68686561 // 'hhea'
+	tagMaxp = 0x6d617870 // 'maxp'
+)
+
+// writeSFNT assembles tables into a standard SFNT font file with the given
+// flavor (sfnt version), recomputing table checksums and the head checksum
+// adjustment.
+func writeSFNT(flavor uint32, tables []table) []byte {
+	sort.Slice(tables, func(i, j int) bool { return tables[i].tag < tables[j].tag })
+
+	numTables := len(tables)
+	entrySelector := 0
+	for 1<<(entrySelector+1) <= numTables {
+		entrySelector++
+	}
+	searchRange := (1 << entrySelector) * 16
+	rangeShift := numTables*16 - searchRange
+
+	size := 12 + 16*numTables
+	for _, t := range tables {
+		size += pad4(len(t.data))
+	}
+
+	out := make([]byte, size)
+	binary.BigEndian.PutUint32(out[0:], flavor)
+	binary.BigEndian.PutUint16(out[4:], uint16(numTables))
+	binary.BigEndian.PutUint16(out[6:], uint16(searchRange))
+	binary.BigEndian.PutUint16(out[8:], uint16(entrySelector))
+	binary.BigEndian.PutUint16(out[10:], uint16(rangeShift))
+
+	headOffset := -1
+	offset := 12 + 16*numTables
+	for i, t := range tables {
+		data := t.data
+		if t.tag == tagHead && len(data) >= 12 {
+			data = append([]byte(nil), data...)
+			binary.BigEndian.PutUint32(data[8:], 0)
+			headOffset = offset
+		}
+		copy(out[offset:], data)
+
+		rec := out[12+16*i:]
+		binary.BigEndian.PutUint32(rec[0:], t.tag)
+		binary.BigEndian.PutUint32(rec[4:], checksum(out[offset:offset+pad4(len(data))]))
+		binary.BigEndian.PutUint32(rec[8:], uint32(offset))
+		binary.BigEndian.PutUint32(rec[12:], uint32(len(data)))
+		offset += pad4(len(data))
+	}
+
+	if headOffset >= 0 {
+		binary.BigEndian.PutUint32(out[headOffset+8:], 0xb1b0afba-checksum(out))
+	}
+	return out
+}
+
+func checksum(b []byte) (sum uint32) {
+	for len(b) >= 4 {
+		sum += binary.BigEndian.Uint32(b)
+		b = b[4:]
+	}
+	if len(b) > 0 {
+		var last [4]byte
+		copy(last[:], b)
+		sum += binary.BigEndian.Uint32(last[:])
+	}
+	return
+}
+
+func pad4(n int) int { return (n + 3) &^ 3 }
@@ -0,0 +1,140 @@
+// Package woff decodes WOFF and WOFF2 web font containers back into the
+// standard SFNT (TrueType/OpenType) bytes they wrap.
+package woff
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	SignatureWOFF  = 0x774f4646 // 'wOFF'
+	SignatureWOFF2 = 0x774f4632 // 'wOF2'
+)
+
+// maxSFNTSize bounds the size of a decoded font. Table lengths come from the
+// file, so without a limit a small file could claim gigabytes of tables.
+const maxSFNTSize = 256 << 20
+
+// IsWOFF reports whether data starts with the WOFF signature.
+func IsWOFF(data []byte) bool {
+	return len(data) >= 4 && binary.BigEndian.Uint32(data) == SignatureWOFF
+}
+
+// IsWOFF2 reports whether data starts with the WOFF2 signature.
+func IsWOFF2(data []byte) bool {
+	return len(data) >= 4 && binary.BigEndian.Uint32(data) == SignatureWOFF2
+}
+
+// Decode converts a WOFF or WOFF2 file to SFNT bytes, choosing the format from
+// the file signature.
+func Decode(data []byte) ([]byte, error) {
+	switch {
+	case IsWOFF(data):
+		return DecodeWOFF(data)
+	case IsWOFF2(data):
+		return DecodeWOFF2(data)
+	}
+	return nil, fmt.Errorf("not a woff or woff2 file")
+}
+
+// DecodeWOFF converts a WOFF 1.0 file to SFNT bytes. Each table is inflated
+// with zlib if its compressed length is smaller than its original length.
+func DecodeWOFF(data []byte) ([]byte, error) {
+	r := newReader(data)
+
+	signature, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	if signature != SignatureWOFF {
+		return nil, fmt.Errorf("invalid woff signature")
+	}
+
+	flavor, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+
+	length, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	if int(length) != len(data) {
+		return nil, fmt.Errorf("woff length %d does not match file size %d", length, len(data))
+	}
+
+	numTables, err := r.uint16()
+	if err != nil {
+		return nil, err
+	}
+	if numTables == 0 {
+		return nil, fmt.Errorf("woff file has no tables")
+	}
+
+	// reserved, totalSfntSize, version and the metadata/private blocks are
+	// not needed to rebuild the font.
+	if _, err := r.bytes(44 - 14); err != nil {
+		return nil, err
+	}
+
+	tables := make([]table, numTables)
+	var total uint64
+	for i := range tables {
+		tag, err := r.uint32()
+		if err != nil {
+			return nil, err
+		}
+		offset, err := r.uint32()
+		if err != nil {
+			return nil, err
+		}
+		compLength, err := r.uint32()
+		if err != nil {
+			return nil, err
+		}
+		origLength, err := r.uint32()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := r.uint32(); err != nil { // origChecksum
+			return nil, err
+		}
+
+		if total += uint64(origLength); total > maxSFNTSize {
+			return nil, fmt.Errorf("woff tables exceed %d bytes", maxSFNTSize)
+		}
+		if uint64(offset)+uint64(compLength) > uint64(len(data)) {
+			return nil, fmt.Errorf("woff table %s out of bounds", tagString(tag))
+		}
+		src := data[offset : offset+compLength]
+
+		switch {
+		case compLength == origLength:
+			tables[i] = table{tag, src}
+		case compLength < origLength:
+			zr, err := zlib.NewReader(bytes.NewReader(src))
+			if err != nil {
+				return nil, fmt.Errorf("woff table %s: %w", tagString(tag), err)
+			}
+			buf := make([]byte, origLength)
+			if _, err := io.ReadFull(zr, buf); err != nil {
+				return nil, fmt.Errorf("woff table %s: %w", tagString(tag), err)
+			}
+			tables[i] = table{tag, buf}
+		default:
+			return nil, fmt.Errorf("woff table %s compressed length exceeds original length", tagString(tag))
+		}
+	}
+
+	return writeSFNT(flavor, tables), nil
+}
+
+func tagString(tag uint32) string {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], tag)
+	return string(b[:])
+}
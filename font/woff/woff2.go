@@ -0,0 +1,647 @@
+package woff
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/andybalholm/brotli"
+)
+
+const tagTTCF = 0x74746366 // 'ttcf'
+
+// knownTags is the WOFF2 table of known table tags, indexed by the low six
+// bits of a table directory entry's flags.
+var knownTags = [63]string{
+	"cmap", "head", "hhea", "hmtx", "maxp", "name", "OS/2", "post", "cvt ", "fpgm",
+	"glyf", "loca", "prep", "CFF ", "VORG", "EBDT", "EBLC", "gasp", "hdmx", "kern",
+	"LTSH", "PCLT", "VDMX", "vhea", "vmtx", "BASE", "GDEF", "GPOS", "GSUB", "EBSC",
+	"JSTF", "MATH", "CBDT", "CBLC", "COLR", "CPAL", "SVG ", "sbix", "acnt", "avar",
+	"bdat", "bloc", "bsln", "cvar", "fdsc", "feat", "fmtx", "fvar", "gvar", "hsty",
+	"just", "lcar", "mort", "morx", "opbd", "prop", "trak", "Zapf", "Silf", "Glat",
+	"Gloc", "Feat", "Sill",
+}
+
+type woff2Entry struct {
+	tag        uint32
+	version    byte
+	origLength uint32
+	length     uint32
+	data       []byte
+}
+
+func (e *woff2Entry) transformed() bool {
+	if e.tag == tagGlyf || e.tag == tagLoca {
+		return e.version != 3
+	}
+	return e.version != 0
+}
+
+// DecodeWOFF2 converts a WOFF 2.0 file to SFNT bytes. The Brotli stream is
+// decompressed and the transformed glyf, loca and hmtx tables are
+// reconstructed. Font collections are not supported.
+func DecodeWOFF2(data []byte) ([]byte, error) {
+	r := newReader(data)
+
+	signature, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	if signature != SignatureWOFF2 {
+		return nil, fmt.Errorf("invalid woff2 signature")
+	}
+
+	flavor, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	if flavor == tagTTCF {
+		return nil, fmt.Errorf("woff2 font collections are not supported")
+	}
+
+	length, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	if int(length) != len(data) {
+		return nil, fmt.Errorf("woff2 length %d does not match file size %d", length, len(data))
+	}
+
+	numTables, err := r.uint16()
+	if err != nil {
+		return nil, err
+	}
+	if numTables == 0 {
+		return nil, fmt.Errorf("woff2 file has no tables")
+	}
+
+	if _, err := r.bytes(6); err != nil { // reserved, totalSfntSize
+		return nil, err
+	}
+	totalCompressedSize, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	// version and the metadata/private blocks are not needed to rebuild the font.
+	if _, err := r.bytes(24); err != nil {
+		return nil, err
+	}
+
+	entries := make([]*woff2Entry, numTables)
+	var uncompressedSize uint64
+	for i := range entries {
+		e, err := readWOFF2Entry(r)
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = e
+		if uncompressedSize += uint64(e.length); uncompressedSize > maxSFNTSize {
+			return nil, fmt.Errorf("woff2 tables exceed %d bytes", maxSFNTSize)
+		}
+	}
+
+	compressed, err := r.bytes(int(totalCompressedSize))
+	if err != nil {
+		return nil, fmt.Errorf("woff2 compressed data: %w", err)
+	}
+
+	br := brotli.NewReader(bytes.NewReader(compressed))
+	stream := make([]byte, uncompressedSize)
+	if _, err := io.ReadFull(br, stream); err != nil {
+		return nil, fmt.Errorf("woff2 brotli stream: %w", err)
+	}
+	if n, _ := br.Read(make([]byte, 1)); n != 0 {
+		return nil, fmt.Errorf("woff2 brotli stream is longer than the table directory")
+	}
+
+	byTag := make(map[uint32]*woff2Entry, len(entries))
+	for _, e := range entries {
+		e.data, stream = stream[:e.length], stream[e.length:]
+		byTag[e.tag] = e
+	}
+
+	glyf, loca := byTag[tagGlyf], byTag[tagLoca]
+	if (glyf == nil) != (loca == nil) {
+		return nil, fmt.Errorf("woff2 glyf and loca tables must both be present")
+	}
+
+	var xMins []int16
+	if glyf != nil && glyf.transformed() {
+		if !loca.transformed() || loca.length != 0 {
+			return nil, fmt.Errorf("woff2 transformed glyf requires transformed empty loca")
+		}
+		glyfData, locaData, mins, err := reconstructGlyf(glyf.data)
+		if err != nil {
+			return nil, err
+		}
+		if uint32(len(locaData)) != loca.origLength {
+			return nil, fmt.Errorf("woff2 reconstructed loca has wrong length")
+		}
+		glyf.data, loca.data, xMins = glyfData, locaData, mins
+	}
+
+	if hmtx := byTag[tagHmtx]; hmtx != nil && hmtx.transformed() {
+		if hmtx.version != 1 {
+			return nil, fmt.Errorf("woff2 unknown hmtx transform %d", hmtx.version)
+		}
+		if xMins == nil {
+			return nil, fmt.Errorf("woff2 transformed hmtx requires transformed glyf")
+		}
+		hhea, maxp := byTag[tagHhea], byTag[tagMaxp]
+		if hhea == nil || maxp == nil || len(hhea.data) < 36 || len(maxp.data) < 6 {
+			return nil, fmt.Errorf("woff2 transformed hmtx requires hhea and maxp")
+		}
+		numHMetrics := int(binary.BigEndian.Uint16(hhea.data[34:]))
+		numGlyphs := int(binary.BigEndian.Uint16(maxp.data[4:]))
+		hmtxData, err := reconstructHmtx(hmtx.data, numGlyphs, numHMetrics, xMins)
+		if err != nil {
+			return nil, err
+		}
+		hmtx.data = hmtxData
+	}
+
+	tables := make([]table, len(entries))
+	for i, e := range entries {
+		if e.transformed() && e.tag != tagGlyf && e.tag != tagLoca && e.tag != tagHmtx {
+			return nil, fmt.Errorf("woff2 unknown transform for table %s", tagString(e.tag))
+		}
+		tables[i] = table{e.tag, e.data}
+	}
+
+	return writeSFNT(flavor, tables), nil
+}
+
+func readWOFF2Entry(r *reader) (*woff2Entry, error) {
+	flags, err := r.uint8()
+	if err != nil {
+		return nil, err
+	}
+
+	e := &woff2Entry{version: flags >> 6}
+	if idx := flags & 0x3f; idx == 0x3f {
+		if e.tag, err = r.uint32(); err != nil {
+			return nil, err
+		}
+	} else {
+		e.tag = binary.BigEndian.Uint32([]byte(knownTags[idx]))
+	}
+
+	if e.origLength, err = r.base128(); err != nil {
+		return nil, err
+	}
+
+	e.length = e.origLength
+	if e.transformed() {
+		if e.length, err = r.base128(); err != nil {
+			return nil, err
+		}
+		if e.tag == tagLoca && e.length != 0 {
+			return nil, fmt.Errorf("woff2 transformed loca must have zero length")
+		}
+	}
+	return e, nil
+}
+
+// Composite glyph flags.
+const (
+	argsAreWords     = 0x0001
+	haveScale        = 0x0008
+	moreComponents   = 0x0020
+	haveXYScale      = 0x0040
+	haveTwoByTwo     = 0x0080
+	haveInstructions = 0x0100
+)
+
+// Simple glyph flags.
+const (
+	flagOnCurve       = 0x01
+	flagXShort        = 0x02
+	flagYShort        = 0x04
+	flagRepeat        = 0x08
+	flagXSame         = 0x10
+	flagYSame         = 0x20
+	flagOverlapSimple = 0x40
+)
+
+type glyfStreams struct {
+	nContour, nPoints, flag, glyph, composite, bbox, instruction *reader
+	bboxBitmap, overlapBitmap                                    []byte
+}
+
+// reconstructGlyf rebuilds the glyf and loca tables from the WOFF2 transformed
+// glyf table. It also returns each glyph's xMin for hmtx reconstruction.
+func reconstructGlyf(data []byte) (glyf, loca []byte, xMins []int16, err error) {
+	r := newReader(data)
+
+	if _, err = r.uint16(); err != nil { // reserved
+		return
+	}
+	optionFlags, err := r.uint16()
+	if err != nil {
+		return
+	}
+	numGlyphs, err := r.uint16()
+	if err != nil {
+		return
+	}
+	indexFormat, err := r.uint16()
+	if err != nil {
+		return
+	}
+
+	var sizes [7]uint32
+	for i := range sizes {
+		if sizes[i], err = r.uint32(); err != nil {
+			return
+		}
+	}
+
+	var streams [7][]byte
+	for i, size := range sizes {
+		if streams[i], err = r.bytes(int(size)); err != nil {
+			err = fmt.Errorf("woff2 glyf stream %d: %w", i, err)
+			return
+		}
+	}
+
+	s := glyfStreams{
+		nContour:    newReader(streams[0]),
+		nPoints:     newReader(streams[1]),
+		flag:        newReader(streams[2]),
+		glyph:       newReader(streams[3]),
+		composite:   newReader(streams[4]),
+		bbox:        newReader(streams[5]),
+		instruction: newReader(streams[6]),
+	}
+
+	bitmapLength := 4 * ((int(numGlyphs) + 31) / 32)
+	if s.bboxBitmap, err = s.bbox.bytes(bitmapLength); err != nil {
+		err = fmt.Errorf("woff2 glyf bbox bitmap: %w", err)
+		return
+	}
+	if optionFlags&1 != 0 {
+		if s.overlapBitmap, err = r.bytes((int(numGlyphs) + 7) / 8); err != nil {
+			err = fmt.Errorf("woff2 glyf overlap bitmap: %w", err)
+			return
+		}
+	}
+
+	var out bytes.Buffer
+	offsets := make([]uint32, numGlyphs+1)
+	xMins = make([]int16, numGlyphs)
+
+	for i := 0; i < int(numGlyphs); i++ {
+		offsets[i] = uint32(out.Len())
+
+		var nContours int16
+		if nContours, err = s.nContour.int16(); err != nil {
+			return
+		}
+
+		var glyph []byte
+		switch {
+		case nContours == 0:
+			if bitSet(s.bboxBitmap, i) {
+				err = fmt.Errorf("woff2 empty glyph %d has a bounding box", i)
+				return
+			}
+		case nContours < 0:
+			glyph, err = s.compositeGlyph(i)
+		default:
+			glyph, err = s.simpleGlyph(i, int(nContours))
+		}
+		if err != nil {
+			return
+		}
+
+		if len(glyph) >= 10 {
+			xMins[i] = int16(binary.BigEndian.Uint16(glyph[2:]))
+		}
+		out.Write(glyph)
+		for out.Len()%4 != 0 {
+			out.WriteByte(0)
+		}
+	}
+	offsets[numGlyphs] = uint32(out.Len())
+
+	if indexFormat == 0 {
+		loca = make([]byte, 2*len(offsets))
+		for i, off := range offsets {
+			binary.BigEndian.PutUint16(loca[2*i:], uint16(off/2))
+		}
+	} else {
+		loca = make([]byte, 4*len(offsets))
+		for i, off := range offsets {
+			binary.BigEndian.PutUint32(loca[4*i:], off)
+		}
+	}
+
+	return out.Bytes(), loca, xMins, nil
+}
+
+func (s *glyfStreams) compositeGlyph(index int) ([]byte, error) {
+	if !bitSet(s.bboxBitmap, index) {
+		return nil, fmt.Errorf("woff2 composite glyph %d has no bounding box", index)
+	}
+	bbox, err := s.bbox.bytes(8)
+	if err != nil {
+		return nil, err
+	}
+
+	start := s.composite.pos
+	instructions := false
+	for {
+		flags, err := s.composite.uint16()
+		if err != nil {
+			return nil, err
+		}
+
+		n := 2 // glyph index
+		if flags&argsAreWords != 0 {
+			n += 4
+		} else {
+			n += 2
+		}
+		switch {
+		case flags&haveScale != 0:
+			n += 2
+		case flags&haveXYScale != 0:
+			n += 4
+		case flags&haveTwoByTwo != 0:
+			n += 8
+		}
+		if _, err := s.composite.bytes(n); err != nil {
+			return nil, err
+		}
+
+		if flags&haveInstructions != 0 {
+			instructions = true
+		}
+		if flags&moreComponents == 0 {
+			break
+		}
+	}
+	components := s.composite.b[start:s.composite.pos]
+
+	var out bytes.Buffer
+	binary.Write(&out, binary.BigEndian, int16(-1))
+	out.Write(bbox)
+	out.Write(components)
+
+	if instructions {
+		n, err := s.glyph.uint255()
+		if err != nil {
+			return nil, err
+		}
+		code, err := s.instruction.bytes(int(n))
+		if err != nil {
+			return nil, err
+		}
+		binary.Write(&out, binary.BigEndian, n)
+		out.Write(code)
+	}
+	return out.Bytes(), nil
+}
+
+func (s *glyfStreams) simpleGlyph(index, nContours int) ([]byte, error) {
+	endPts := make([]uint16, nContours)
+	numPoints := 0
+	for i := range endPts {
+		n, err := s.nPoints.uint255()
+		if err != nil {
+			return nil, err
+		}
+		numPoints += int(n)
+		if numPoints > 0xffff {
+			return nil, fmt.Errorf("woff2 glyph %d has too many points", index)
+		}
+		endPts[i] = uint16(numPoints - 1)
+	}
+
+	flags, err := s.flag.bytes(numPoints)
+	if err != nil {
+		return nil, err
+	}
+
+	xs, ys := make([]int, numPoints), make([]int, numPoints)
+	x, y := 0, 0
+	for i, flag := range flags {
+		dx, dy, err := s.triplet(flag & 0x7f)
+		if err != nil {
+			return nil, err
+		}
+		x += dx
+		y += dy
+		xs[i], ys[i] = x, y
+	}
+
+	instructionLength, err := s.glyph.uint255()
+	if err != nil {
+		return nil, err
+	}
+	instructions, err := s.instruction.bytes(int(instructionLength))
+	if err != nil {
+		return nil, err
+	}
+
+	var xMin, yMin, xMax, yMax int16
+	if bitSet(s.bboxBitmap, index) {
+		for _, v := range []*int16{&xMin, &yMin, &xMax, &yMax} {
+			if *v, err = s.bbox.int16(); err != nil {
+				return nil, err
+			}
+		}
+	} else if numPoints > 0 {
+		minX, minY, maxX, maxY := xs[0], ys[0], xs[0], ys[0]
+		for i := 1; i < numPoints; i++ {
+			minX, maxX = minInt(minX, xs[i]), maxInt(maxX, xs[i])
+			minY, maxY = minInt(minY, ys[i]), maxInt(maxY, ys[i])
+		}
+		xMin, yMin, xMax, yMax = int16(minX), int16(minY), int16(maxX), int16(maxY)
+	}
+
+	var out bytes.Buffer
+	binary.Write(&out, binary.BigEndian, []int16{int16(nContours), xMin, yMin, xMax, yMax})
+	binary.Write(&out, binary.BigEndian, endPts)
+	binary.Write(&out, binary.BigEndian, instructionLength)
+	out.Write(instructions)
+
+	outFlags := make([]byte, numPoints)
+	var xData, yData []byte
+	lastX, lastY := 0, 0
+	for i := range flags {
+		f := byte(0)
+		if flags[i]&0x80 == 0 {
+			f |= flagOnCurve
+		}
+		if i == 0 && bitSet(s.overlapBitmap, index) {
+			f |= flagOverlapSimple
+		}
+
+		dx, dy := xs[i]-lastX, ys[i]-lastY
+		lastX, lastY = xs[i], ys[i]
+
+		switch {
+		case dx == 0:
+			f |= flagXSame
+		case dx > -256 && dx < 256:
+			f |= flagXShort
+			if dx > 0 {
+				f |= flagXSame
+			} else {
+				dx = -dx
+			}
+			xData = append(xData, byte(dx))
+		default:
+			xData = append(xData, byte(uint16(dx)>>8), byte(dx))
+		}
+
+		switch {
+		case dy == 0:
+			f |= flagYSame
+		case dy > -256 && dy < 256:
+			f |= flagYShort
+			if dy > 0 {
+				f |= flagYSame
+			} else {
+				dy = -dy
+			}
+			yData = append(yData, byte(dy))
+		default:
+			yData = append(yData, byte(uint16(dy)>>8), byte(dy))
+		}
+		outFlags[i] = f
+	}
+
+	for i := 0; i < len(outFlags); {
+		j := i + 1
+		for j < len(outFlags) && outFlags[j] == outFlags[i] && j-i <= 255 {
+			j++
+		}
+		if repeat := j - i - 1; repeat > 1 {
+			out.WriteByte(outFlags[i] | flagRepeat)
+			out.WriteByte(byte(repeat))
+		} else {
+			out.Write(outFlags[i:j])
+		}
+		i = j
+	}
+	out.Write(xData)
+	out.Write(yData)
+	return out.Bytes(), nil
+}
+
+// triplet decodes one point delta from the glyph stream using the WOFF2
+// triplet encoding for the given flag.
+func (s *glyfStreams) triplet(flag byte) (dx, dy int, err error) {
+	withSign := func(flag byte, v int) int {
+		if flag&1 != 0 {
+			return v
+		}
+		return -v
+	}
+
+	var n int
+	switch {
+	case flag < 84:
+		n = 1
+	case flag < 120:
+		n = 2
+	case flag < 124:
+		n = 3
+	default:
+		n = 4
+	}
+
+	b, err := s.glyph.bytes(n)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	f := int(flag)
+	switch {
+	case flag < 10:
+		dy = withSign(flag, (f&14)<<7+int(b[0]))
+	case flag < 20:
+		dx = withSign(flag, ((f-10)&14)<<7+int(b[0]))
+	case flag < 84:
+		b0, b1 := f-20, int(b[0])
+		dx = withSign(flag, 1+(b0&0x30)+(b1>>4))
+		dy = withSign(flag>>1, 1+((b0&0x0c)<<2)+(b1&0x0f))
+	case flag < 120:
+		b0 := f - 84
+		dx = withSign(flag, 1+((b0/12)<<8)+int(b[0]))
+		dy = withSign(flag>>1, 1+(((b0%12)>>2)<<8)+int(b[1]))
+	case flag < 124:
+		b2 := int(b[1])
+		dx = withSign(flag, int(b[0])<<4+b2>>4)
+		dy = withSign(flag>>1, (b2&0x0f)<<8+int(b[2]))
+	default:
+		dx = withSign(flag, int(b[0])<<8+int(b[1]))
+		dy = withSign(flag>>1, int(b[2])<<8+int(b[3]))
+	}
+	return dx, dy, nil
+}
+
+// reconstructHmtx rebuilds the hmtx table from its WOFF2 transformed form,
+// restoring omitted left side bearings from the glyphs' xMin values.
+func reconstructHmtx(data []byte, numGlyphs, numHMetrics int, xMins []int16) ([]byte, error) {
+	if numHMetrics < 1 || numHMetrics > numGlyphs || len(xMins) != numGlyphs {
+		return nil, fmt.Errorf("woff2 hmtx metrics do not match glyph count")
+	}
+
+	r := newReader(data)
+	flags, err := r.uint8()
+	if err != nil {
+		return nil, err
+	}
+
+	advances := make([]uint16, numHMetrics)
+	for i := range advances {
+		if advances[i], err = r.uint16(); err != nil {
+			return nil, err
+		}
+	}
+
+	lsbs := make([]int16, numGlyphs)
+	for i := range lsbs {
+		omitted := flags&1 != 0
+		if i >= numHMetrics {
+			omitted = flags&2 != 0
+		}
+
+		if omitted {
+			lsbs[i] = xMins[i]
+		} else if lsbs[i], err = r.int16(); err != nil {
+			return nil, err
+		}
+	}
+
+	out := make([]byte, 0, 4*numHMetrics+2*(numGlyphs-numHMetrics))
+	for i := 0; i < numGlyphs; i++ {
+		if i < numHMetrics {
+			out = binary.BigEndian.AppendUint16(out, advances[i])
+		}
+		out = binary.BigEndian.AppendUint16(out, uint16(lsbs[i]))
+	}
+	return out, nil
+}
+
+func bitSet(bitmap []byte, i int) bool {
+	return i/8 < len(bitmap) && bitmap[i/8]&(0x80>>(i%8)) != 0
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
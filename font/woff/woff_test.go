@@ -0,0 +1,446 @@
+package woff_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"sort"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/bryanmatteson/gfx/font/woff"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+func TestDecodeWOFF(t *testing.T) {
+	decoded, err := woff.Decode(encodeWOFF(goregular.TTF))
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareFonts(t, goregular.TTF, decoded)
+}
+
+func TestDecodeWOFF2(t *testing.T) {
+	decoded, err := woff.Decode(encodeWOFF2(goregular.TTF))
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareFonts(t, goregular.TTF, decoded)
+}
+
+func TestDecodeBadLengths(t *testing.T) {
+	// WOFF table claiming four gigabytes
+	w := make([]byte, 44, 65)
+	binary.BigEndian.PutUint32(w[0:], woff.SignatureWOFF)
+	binary.BigEndian.PutUint32(w[8:], 65)
+	binary.BigEndian.PutUint16(w[12:], 1)
+	w = append(w, "cmap"...)
+	for _, v := range []uint32{64, 1, 0xffffffff, 0} {
+		w = binary.BigEndian.AppendUint32(w, v)
+	}
+	w = append(w, 0)
+
+	// WOFF2 tables claiming far more than the file could hold
+	brotliStream := func(data []byte) []byte {
+		var b bytes.Buffer
+		bw := brotli.NewWriter(&b)
+		bw.Write(data)
+		bw.Close()
+		return b.Bytes()
+	}
+	woff2 := func(lengths []uint32, stream []byte) []byte {
+		var dir []byte
+		for _, l := range lengths {
+			dir = appendBase128(append(dir, 0), l) // cmap, untransformed
+		}
+		compressed := brotliStream(stream)
+		hdr := make([]byte, 48)
+		binary.BigEndian.PutUint32(hdr[0:], woff.SignatureWOFF2)
+		binary.BigEndian.PutUint32(hdr[8:], uint32(48+len(dir)+len(compressed)))
+		binary.BigEndian.PutUint16(hdr[12:], uint16(len(lengths)))
+		binary.BigEndian.PutUint32(hdr[20:], uint32(len(compressed)))
+		return append(append(hdr, dir...), compressed...)
+	}
+
+	tests := map[string][]byte{
+		"woff huge table":        w,
+		"woff2 huge tables":      woff2([]uint32{0xffffffff, 0xffffffff}, nil),
+		"woff2 stream too long":  woff2([]uint32{4}, make([]byte, 8)),
+		"woff2 stream too short": woff2([]uint32{8}, make([]byte, 4)),
+	}
+	for name, data := range tests {
+		if _, err := woff.Decode(data); err == nil {
+			t.Errorf("%s: decoded without error", name)
+		}
+	}
+}
+
+func compareFonts(t *testing.T, want, got []byte) {
+	t.Helper()
+
+	wf, err := sfnt.Parse(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gf, err := sfnt.Parse(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if wf.NumGlyphs() != gf.NumGlyphs() {
+		t.Fatalf("glyph count: want %d, got %d", wf.NumGlyphs(), gf.NumGlyphs())
+	}
+
+	var wb, gb sfnt.Buffer
+	ppem := fixed.I(int(wf.UnitsPerEm()))
+	for i := 0; i < wf.NumGlyphs(); i++ {
+		x := sfnt.GlyphIndex(i)
+		ws, err := wf.LoadGlyph(&wb, x, ppem, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantSegs := append(sfnt.Segments(nil), ws...)
+		gs, err := gf.LoadGlyph(&gb, x, ppem, nil)
+		if err != nil {
+			t.Fatalf("glyph %d: %v", i, err)
+		}
+		if len(wantSegs) != len(gs) {
+			t.Fatalf("glyph %d: want %d segments, got %d", i, len(wantSegs), len(gs))
+		}
+		for j := range gs {
+			if wantSegs[j] != gs[j] {
+				t.Fatalf("glyph %d segment %d: want %v, got %v", i, j, wantSegs[j], gs[j])
+			}
+		}
+
+		wa, _ := wf.GlyphAdvance(&wb, x, ppem, font.HintingNone)
+		ga, _ := gf.GlyphAdvance(&gb, x, ppem, font.HintingNone)
+		if wa != ga {
+			t.Fatalf("glyph %d: want advance %v, got %v", i, wa, ga)
+		}
+	}
+}
+
+type sfntTable struct {
+	tag  string
+	data []byte
+}
+
+func readTables(data []byte) (flavor uint32, tables []sfntTable) {
+	flavor = binary.BigEndian.Uint32(data)
+	n := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < n; i++ {
+		rec := data[12+16*i:]
+		off, length := binary.BigEndian.Uint32(rec[8:]), binary.BigEndian.Uint32(rec[12:])
+		tables = append(tables, sfntTable{string(rec[:4]), data[off : off+length]})
+	}
+	return
+}
+
+func encodeWOFF(ttf []byte) []byte {
+	flavor, tables := readTables(ttf)
+
+	var body bytes.Buffer
+	dir := make([]byte, 0, 20*len(tables))
+	offset := 44 + 20*len(tables)
+	for _, t := range tables {
+		var z bytes.Buffer
+		w := zlib.NewWriter(&z)
+		w.Write(t.data)
+		w.Close()
+		data := z.Bytes()
+		if len(data) >= len(t.data) {
+			data = t.data
+		}
+
+		dir = append(dir, t.tag...)
+		dir = binary.BigEndian.AppendUint32(dir, uint32(offset+body.Len()))
+		dir = binary.BigEndian.AppendUint32(dir, uint32(len(data)))
+		dir = binary.BigEndian.AppendUint32(dir, uint32(len(t.data)))
+		dir = binary.BigEndian.AppendUint32(dir, 0)
+		body.Write(data)
+		for body.Len()%4 != 0 {
+			body.WriteByte(0)
+		}
+	}
+
+	hdr := make([]byte, 44)
+	binary.BigEndian.PutUint32(hdr[0:], woff.SignatureWOFF)
+	binary.BigEndian.PutUint32(hdr[4:], flavor)
+	binary.BigEndian.PutUint32(hdr[8:], uint32(44+len(dir)+body.Len()))
+	binary.BigEndian.PutUint16(hdr[12:], uint16(len(tables)))
+	return append(append(hdr, dir...), body.Bytes()...)
+}
+
+// encodeWOFF2 builds a WOFF2 file with transformed glyf, loca and hmtx tables.
+func encodeWOFF2(ttf []byte) []byte {
+	flavor, tables := readTables(ttf)
+	byTag := map[string][]byte{}
+	for _, t := range tables {
+		byTag[t.tag] = t.data
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].tag < tables[j].tag })
+
+	indexFormat := binary.BigEndian.Uint16(byTag["head"][50:])
+	numGlyphs := int(binary.BigEndian.Uint16(byTag["maxp"][4:]))
+	glyf, xMins := transformGlyf(byTag["glyf"], byTag["loca"], numGlyphs, indexFormat)
+	hmtx := transformHmtx(byTag["hmtx"], int(binary.BigEndian.Uint16(byTag["hhea"][34:])), numGlyphs, xMins)
+
+	var dir []byte
+	var stream bytes.Buffer
+	for _, t := range tables {
+		var flags byte = 0x3f
+		var transformed []byte
+		switch t.tag {
+		case "glyf":
+			transformed = glyf
+		case "loca":
+			transformed = []byte{}
+		case "hmtx":
+			flags |= 1 << 6
+			transformed = hmtx
+		}
+
+		dir = append(dir, flags)
+		dir = append(dir, t.tag...)
+		dir = appendBase128(dir, uint32(len(t.data)))
+		if transformed != nil {
+			dir = appendBase128(dir, uint32(len(transformed)))
+			stream.Write(transformed)
+		} else {
+			stream.Write(t.data)
+		}
+	}
+
+	var compressed bytes.Buffer
+	w := brotli.NewWriter(&compressed)
+	w.Write(stream.Bytes())
+	w.Close()
+
+	hdr := make([]byte, 48)
+	binary.BigEndian.PutUint32(hdr[0:], woff.SignatureWOFF2)
+	binary.BigEndian.PutUint32(hdr[4:], flavor)
+	binary.BigEndian.PutUint32(hdr[8:], uint32(48+len(dir)+compressed.Len()))
+	binary.BigEndian.PutUint16(hdr[12:], uint16(len(tables)))
+	binary.BigEndian.PutUint32(hdr[20:], uint32(compressed.Len()))
+	return append(append(hdr, dir...), compressed.Bytes()...)
+}
+
+func transformGlyf(glyf, loca []byte, numGlyphs int, indexFormat uint16) ([]byte, []int16) {
+	offset := func(i int) int {
+		if indexFormat == 0 {
+			return 2 * int(binary.BigEndian.Uint16(loca[2*i:]))
+		}
+		return int(binary.BigEndian.Uint32(loca[4*i:]))
+	}
+
+	var nContour, nPoints, flags, glyphs, composite, bbox, instructions bytes.Buffer
+	bitmap := make([]byte, 4*((numGlyphs+31)/32))
+	xMins := make([]int16, numGlyphs)
+
+	for i := 0; i < numGlyphs; i++ {
+		g := glyf[offset(i):offset(i+1)]
+		if len(g) == 0 {
+			nContour.Write([]byte{0, 0})
+			continue
+		}
+		n := int16(binary.BigEndian.Uint16(g))
+		xMins[i] = int16(binary.BigEndian.Uint16(g[2:]))
+		nContour.Write(g[:2])
+
+		if n < 0 {
+			bitmap[i/8] |= 0x80 >> (i % 8)
+			bbox.Write(g[2:10])
+			p, hasInstructions := 10, false
+			for {
+				f := binary.BigEndian.Uint16(g[p:])
+				size := 4 + 2
+				if f&0x0001 != 0 {
+					size += 2
+				}
+				switch {
+				case f&0x0008 != 0:
+					size += 2
+				case f&0x0040 != 0:
+					size += 4
+				case f&0x0080 != 0:
+					size += 8
+				}
+				composite.Write(g[p : p+size])
+				p += size
+				hasInstructions = hasInstructions || f&0x0100 != 0
+				if f&0x0020 == 0 {
+					break
+				}
+			}
+			if hasInstructions {
+				l := int(binary.BigEndian.Uint16(g[p:]))
+				glyphs.Write(append255(nil, uint16(l)))
+				instructions.Write(g[p+2 : p+2+l])
+			}
+			continue
+		}
+
+		// Store explicit bounding boxes for every other glyph to exercise
+		// both reconstruction paths.
+		if i%2 == 0 {
+			bitmap[i/8] |= 0x80 >> (i % 8)
+			bbox.Write(g[2:10])
+		}
+
+		p, last := 10, -1
+		for c := 0; c < int(n); c++ {
+			end := int(binary.BigEndian.Uint16(g[p:]))
+			nPoints.Write(append255(nil, uint16(end-last)))
+			last = end
+			p += 2
+		}
+		count := last + 1
+		l := int(binary.BigEndian.Uint16(g[p:]))
+		ins := g[p+2 : p+2+l]
+		p += 2 + l
+
+		pf := make([]byte, 0, count)
+		for len(pf) < count {
+			f := g[p]
+			p++
+			pf = append(pf, f)
+			if f&0x08 != 0 {
+				r := int(g[p])
+				p++
+				for k := 0; k < r; k++ {
+					pf = append(pf, f)
+				}
+			}
+		}
+		read := func(short, same byte) []int {
+			out := make([]int, count)
+			for k, f := range pf {
+				switch {
+				case f&short != 0:
+					v := int(g[p])
+					p++
+					if f&same == 0 {
+						v = -v
+					}
+					out[k] = v
+				case f&same != 0:
+				default:
+					out[k] = int(int16(binary.BigEndian.Uint16(g[p:])))
+					p += 2
+				}
+			}
+			return out
+		}
+		dxs := read(0x02, 0x10)
+		dys := read(0x04, 0x20)
+
+		for k := range pf {
+			flag, data := encodeTriplet(dxs[k], dys[k])
+			if pf[k]&0x01 == 0 {
+				flag |= 0x80
+			}
+			flags.WriteByte(flag)
+			glyphs.Write(data)
+		}
+		glyphs.Write(append255(nil, uint16(l)))
+		instructions.Write(ins)
+	}
+
+	out := binary.BigEndian.AppendUint16(nil, 0)
+	out = binary.BigEndian.AppendUint16(out, 0)
+	out = binary.BigEndian.AppendUint16(out, uint16(numGlyphs))
+	out = binary.BigEndian.AppendUint16(out, indexFormat)
+	bboxStream := append(bitmap, bbox.Bytes()...)
+	streams := [][]byte{nContour.Bytes(), nPoints.Bytes(), flags.Bytes(), glyphs.Bytes(), composite.Bytes(), bboxStream, instructions.Bytes()}
+	for _, s := range streams {
+		out = binary.BigEndian.AppendUint32(out, uint32(len(s)))
+	}
+	for _, s := range streams {
+		out = append(out, s...)
+	}
+	return out, xMins
+}
+
+func encodeTriplet(dx, dy int) (byte, []byte) {
+	abs := func(v int) int {
+		if v < 0 {
+			return -v
+		}
+		return v
+	}
+	ax, ay := abs(dx), abs(dy)
+	xs, ys := 0, 0
+	if dx > 0 {
+		xs = 1
+	}
+	if dy > 0 {
+		ys = 1
+	}
+	xys := xs + 2*ys
+
+	switch {
+	case dx == 0 && ay < 1280:
+		return byte(((ay & 0xf00) >> 7) + ys), []byte{byte(ay)}
+	case dy == 0 && ax < 1280:
+		return byte(10 + ((ax & 0xf00) >> 7) + xs), []byte{byte(ax)}
+	case ax < 65 && ay < 65:
+		return byte(20 + ((ax - 1) & 0x30) + (((ay - 1) & 0x30) >> 2) + xys), []byte{byte((ax-1)&0xf<<4 | (ay-1)&0xf)}
+	case ax < 769 && ay < 769:
+		return byte(84 + 12*(((ax-1)&0x300)>>8) + (((ay - 1) & 0x300) >> 6) + xys), []byte{byte(ax - 1), byte(ay - 1)}
+	case ax < 4096 && ay < 4096:
+		return byte(120 + xys), []byte{byte(ax >> 4), byte(ax&0xf<<4 | ay>>8), byte(ay)}
+	}
+	return byte(124 + xys), []byte{byte(ax >> 8), byte(ax), byte(ay >> 8), byte(ay)}
+}
+
+func transformHmtx(hmtx []byte, numHMetrics, numGlyphs int, xMins []int16) []byte {
+	out := []byte{0x03}
+	for i := 0; i < numHMetrics; i++ {
+		out = append(out, hmtx[4*i:4*i+2]...)
+	}
+	for i := 0; i < numGlyphs; i++ {
+		var lsb int16
+		if i < numHMetrics {
+			lsb = int16(binary.BigEndian.Uint16(hmtx[4*i+2:]))
+		} else {
+			lsb = int16(binary.BigEndian.Uint16(hmtx[4*numHMetrics+2*(i-numHMetrics):]))
+		}
+		if lsb != xMins[i] {
+			panic("font cannot use the hmtx transform")
+		}
+	}
+	return out
+}
+
+func appendBase128(b []byte, v uint32) []byte {
+	var tmp [5]byte
+	n := 0
+	for {
+		tmp[4-n] = byte(v & 0x7f)
+		if n > 0 {
+			tmp[4-n] |= 0x80
+		}
+		n++
+		v >>= 7
+		if v == 0 {
+			break
+		}
+	}
+	return append(b, tmp[5-n:]...)
+}
+
+func append255(b []byte, v uint16) []byte {
+	switch {
+	case v < 253:
+		return append(b, byte(v))
+	case v < 506:
+		return append(b, 255, byte(v-253))
+	case v < 762:
+		return append(b, 254, byte(v-506))
+	}
+	return append(b, 253, byte(v>>8), byte(v))
+}
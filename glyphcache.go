@@ -0,0 +1,219 @@
+package gfx
+
+import (
+	"container/list"
+	"image"
+	"math"
+	"reflect"
+	"sync"
+
+	"github.com/golang/freetype/raster"
+)
+
+// GlyphSubpixelSteps is the number of horizontal and vertical subpixel
+// positions a glyph mask is rendered at.
+const GlyphSubpixelSteps = 4
+
+// DefaultGlyphCacheSize is the memory bound used by NewGlyphCache when no
+// positive size is given.
+const DefaultGlyphCacheSize = 8 << 20
+
+// glyphEntryOverhead approximates the bookkeeping cost of one cache entry.
+const glyphEntryOverhead = 128
+
+type glyphKey struct {
+	font       Font
+	glyph      GlyphID
+	size       float64
+	a, b, c, d float64
+	subX, subY int
+}
+
+type glyphEntry struct {
+	key  glyphKey
+	mask *image.Alpha
+	size int
+}
+
+// GlyphCache stores rasterized glyph coverage masks keyed by font, glyph,
+// size, subpixel offset and the non-translation part of the device matrix.
+// Least recently used masks are evicted once the cache exceeds its memory
+// bound. A GlyphCache is safe for concurrent use and may be shared between
+// contexts.
+type GlyphCache struct {
+	mu       sync.Mutex
+	maxBytes int
+	bytes    int
+	entries  map[glyphKey]*list.Element
+	lru      *list.List
+}
+
+// NewGlyphCache creates a cache holding at most maxBytes of glyph masks.
+func NewGlyphCache(maxBytes int) *GlyphCache {
+	if maxBytes <= 0 {
+		maxBytes = DefaultGlyphCacheSize
+	}
+	return &GlyphCache{
+		maxBytes: maxBytes,
+		entries:  make(map[glyphKey]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// Len returns the number of cached masks.
+func (c *GlyphCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Bytes returns the approximate memory held by cached masks.
+func (c *GlyphCache) Bytes() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.bytes
+}
+
+// Clear removes all cached masks.
+func (c *GlyphCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[glyphKey]*list.Element)
+	c.lru.Init()
+	c.bytes = 0
+}
+
+// Mask returns the coverage mask of glyph gid drawn with font at size under
+// trm. For fonts that are not a ShapingFont gid is the rune itself. The glyph
+// origin is trm applied to (0, 0); the returned mask's bounds are in device
+// pixels.
+//
+// Masks are cached per font value, so fonts are told apart by identity
+// rather than by name. Fonts whose dynamic type is not comparable are
+// rasterized without caching.
+func (c *GlyphCache) Mask(font Font, gid GlyphID, size float64, trm Matrix) *image.Alpha {
+	ox, oy := trm.E, trm.F
+	ix, subX := quantizeSubpixel(ox)
+	iy, subY := quantizeSubpixel(oy)
+
+	trm.E = float64(subX) / GlyphSubpixelSteps
+	trm.F = float64(subY) / GlyphSubpixelSteps
+	if !reflect.ValueOf(font).Comparable() {
+		return offsetMask(rasterizeGlyph(font, gid, size, trm), ix, iy)
+	}
+
+	key := glyphKey{
+		font:  font,
+		glyph: gid,
+		size:  size,
+		a:     trm.A, b: trm.B, c: trm.C, d: trm.D,
+		subX: subX, subY: subY,
+	}
+
+	if mask := c.lookup(key); mask != nil {
+		return offsetMask(mask, ix, iy)
+	}
+
+	mask := rasterizeGlyph(font, gid, size, trm)
+	c.store(key, mask)
+	return offsetMask(mask, ix, iy)
+}
+
+func (c *GlyphCache) lookup(key glyphKey) *image.Alpha {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(elem)
+		return elem.Value.(*glyphEntry).mask
+	}
+	return nil
+}
+
+func (c *GlyphCache) store(key glyphKey, mask *image.Alpha) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[key]; ok {
+		return
+	}
+
+	entry := &glyphEntry{key: key, mask: mask, size: len(mask.Pix) + glyphEntryOverhead}
+	c.entries[key] = c.lru.PushFront(entry)
+	c.bytes += entry.size
+
+	for c.bytes > c.maxBytes && c.lru.Len() > 1 {
+		oldest := c.lru.Back()
+		evicted := c.lru.Remove(oldest).(*glyphEntry)
+		delete(c.entries, evicted.key)
+		c.bytes -= evicted.size
+	}
+}
+
+// quantizeSubpixel splits v into an integer pixel and a subpixel step.
+func quantizeSubpixel(v float64) (pixel, step int) {
+	fl := math.Floor(v)
+	step = int(math.Round((v - fl) * GlyphSubpixelSteps))
+	pixel = int(fl)
+	if step == GlyphSubpixelSteps {
+		pixel, step = pixel+1, 0
+	}
+	return
+}
+
+// offsetMask returns a view of mask translated by (dx, dy) pixels that shares
+// its pixel data.
+func offsetMask(mask *image.Alpha, dx, dy int) *image.Alpha {
+	return &image.Alpha{Pix: mask.Pix, Stride: mask.Stride, Rect: mask.Rect.Add(image.Pt(dx, dy))}
+}
+
+// rasterizeGlyph renders the glyph outline under trm into an alpha mask whose
+// bounds are in device pixels.
+func rasterizeGlyph(font Font, gid GlyphID, size float64, trm Matrix) *image.Alpha {
+	textMatrix := Matrix{size, 0, 0, -size, 0, 0}
+	glyph := glyphOutline(font, gid, textMatrix.Concat(trm))
+	if glyph == nil || glyph.Path == nil || glyph.Path.IsEmpty() {
+		return image.NewAlpha(image.Rectangle{})
+	}
+
+	bounds := glyph.Path.ApproxBounds()
+	rect := image.Rect(
+		int(math.Floor(bounds.X.Min)), int(math.Floor(bounds.Y.Min)),
+		int(math.Ceil(bounds.X.Max))+1, int(math.Ceil(bounds.Y.Max))+1,
+	)
+
+	mask := image.NewAlpha(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	rasterizer := raster.NewRasterizer(rect.Dx(), rect.Dy())
+	rasterizer.UseNonZeroWinding = true
+
+	toMask := NewTranslationMatrix(-float64(rect.Min.X), -float64(rect.Min.Y))
+	Flatten(glyph.Path, Transformer{Tr: toMask, Flattener: ftLineBuilder{Adder: rasterizer}}, 1)
+	rasterizer.Rasterize(raster.NewAlphaSrcPainter(mask))
+
+	mask.Rect = rect
+	return mask
+}
+
+// paintMask composites a coverage mask through painter as raster spans.
+func paintMask(painter raster.Painter, mask *image.Alpha) {
+	b := mask.Bounds()
+	spans := make([]raster.Span, 0, b.Dx())
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		spans = spans[:0]
+		row := mask.Pix[(y-b.Min.Y)*mask.Stride:]
+		for x := 0; x < b.Dx(); {
+			a := row[x]
+			end := x + 1
+			for end < b.Dx() && row[end] == a {
+				end++
+			}
+			if a != 0 {
+				spans = append(spans, raster.Span{Y: y, X0: b.Min.X + x, X1: b.Min.X + end, Alpha: uint32(a) * 0x101})
+			}
+			x = end
+		}
+		if len(spans) > 0 {
+			painter.Paint(spans, false)
+		}
+	}
+}
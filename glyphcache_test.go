@@ -0,0 +1,104 @@
+package gfx_test
+
+import (
+	"image"
+	"testing"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+// boxFont draws every rune as a filled box of the given width and counts
+// the outlines it hands out per rune.
+type boxFont struct {
+	width float64
+	loads map[rune]int
+}
+
+func newBoxFont(width float64) *boxFont { return &boxFont{width: width, loads: make(map[rune]int)} }
+
+func (f *boxFont) Name() string                       { return "Box" }
+func (f *boxFont) BoundingBox() gfx.Rect              { return gfx.MakeRect(0, 0, f.width, 1) }
+func (f *boxFont) Info() gfx.FontData                 { return gfx.FontData{Name: "Box"} }
+func (f *boxFont) Advance(chr rune, mode int) float64 { return f.width }
+func (f *boxFont) HasGlyph(chr rune) bool             { return true }
+
+func (f *boxFont) Glyph(chr rune, trm gfx.Matrix) *gfx.Glyph {
+	f.loads[chr]++
+	path := new(gfx.Path)
+	path.MoveTo(trm.TransformXY(0, 0))
+	path.LineTo(trm.TransformXY(f.width, 0))
+	path.LineTo(trm.TransformXY(f.width, 1))
+	path.LineTo(trm.TransformXY(0, 1))
+	path.Close()
+	return &gfx.Glyph{Path: path, Width: f.width}
+}
+
+func TestGlyphCacheHits(t *testing.T) {
+	font := newBoxFont(0.5)
+	cache := gfx.NewGlyphCache(0)
+
+	first := cache.Mask(font, 'a', 10, gfx.NewTranslationMatrix(0.1, 20))
+	// the same subpixel step a few whole pixels away reuses the mask
+	moved := cache.Mask(font, 'a', 10, gfx.NewTranslationMatrix(5.05, 23))
+	if font.loads['a'] != 1 || cache.Len() != 1 {
+		t.Errorf("loaded the glyph %d times into %d masks, want once", font.loads['a'], cache.Len())
+	}
+	if got, want := moved.Bounds(), first.Bounds().Add(image.Pt(5, 3)); got != want {
+		t.Errorf("moved mask bounds = %v, first at %v", got, first.Bounds())
+	}
+
+	// a quarter pixel further is a different subpixel step
+	cache.Mask(font, 'a', 10, gfx.NewTranslationMatrix(0.35, 20))
+	// as are a different size and a rotated matrix
+	cache.Mask(font, 'a', 12, gfx.NewTranslationMatrix(0.1, 20))
+	cache.Mask(font, 'a', 10, gfx.NewRotationMatrixDeg(90))
+	if font.loads['a'] != 4 || cache.Len() != 4 {
+		t.Errorf("loaded the glyph %d times into %d masks, want 4", font.loads['a'], cache.Len())
+	}
+
+	// fonts sharing a name and style still get masks of their own
+	wide := newBoxFont(2)
+	if a, b := cache.Mask(font, 'a', 10, gfx.IdentityMatrix), cache.Mask(wide, 'a', 10, gfx.IdentityMatrix); a.Bounds().Dx() >= b.Bounds().Dx() {
+		t.Errorf("narrow mask %v is not narrower than wide mask %v", a.Bounds(), b.Bounds())
+	}
+	if wide.loads['a'] != 1 {
+		t.Errorf("wide font loaded %d times, want once", wide.loads['a'])
+	}
+
+	// synthesizing the same style twice gives the same font
+	bold := newBoxFont(0.5)
+	cache.Mask(gfx.SynthesizeFont(bold, gfx.FontStyleBold), 'a', 10, gfx.IdentityMatrix)
+	cache.Mask(gfx.SynthesizeFont(bold, gfx.FontStyleBold), 'a', 10, gfx.IdentityMatrix)
+	if bold.loads['a'] != 1 {
+		t.Errorf("synthetic bold font loaded %d times, want once", bold.loads['a'])
+	}
+}
+
+func TestGlyphCacheEviction(t *testing.T) {
+	font := newBoxFont(0.5)
+	probe := gfx.NewGlyphCache(0)
+	probe.Mask(font, 'a', 10, gfx.IdentityMatrix)
+	perMask := probe.Bytes()
+
+	// room for two masks; touching a makes b the least recently used
+	cache := gfx.NewGlyphCache(2 * perMask)
+	font = newBoxFont(0.5)
+	for _, chr := range "aba" {
+		cache.Mask(font, gfx.GlyphID(chr), 10, gfx.IdentityMatrix)
+	}
+	cache.Mask(font, 'c', 10, gfx.IdentityMatrix)
+	if cache.Len() != 2 || cache.Bytes() > 2*perMask {
+		t.Errorf("cache holds %d masks in %d bytes, want 2 in %d", cache.Len(), cache.Bytes(), 2*perMask)
+	}
+
+	cache.Mask(font, 'a', 10, gfx.IdentityMatrix)
+	cache.Mask(font, 'b', 10, gfx.IdentityMatrix)
+	if font.loads['a'] != 1 || font.loads['b'] != 2 {
+		t.Errorf("a loaded %d times and b %d times, want 1 and 2", font.loads['a'], font.loads['b'])
+	}
+
+	cache.Clear()
+	if cache.Len() != 0 || cache.Bytes() != 0 {
+		t.Errorf("cleared cache holds %d masks in %d bytes", cache.Len(), cache.Bytes())
+	}
+}
@@ -4,6 +4,7 @@ go 1.20
 
 require (
 	github.com/ahmetb/go-linq v3.0.0+incompatible
+	github.com/andybalholm/brotli v1.0.5
 	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0
 	golang.org/x/image v0.7.0
 	golang.org/x/text v0.9.0
@@ -0,0 +1,176 @@
+package gfx
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	gsubSingle    = 1
+	gsubLigature  = 4
+	gsubExtension = 7
+)
+
+// sfntTable returns the raw bytes of the table with the given tag from SFNT
+// data. For collections the first font's table is returned.
+func sfntTable(data []byte, tag string) (table []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			table, err = nil, fmt.Errorf("invalid font data: %v", r)
+		}
+	}()
+
+	r := otReader(data)
+	offset := 0
+	if r.tag(0) == "ttcf" {
+		offset = int(r.u32(12))
+	}
+
+	numTables := int(r.u16(offset + 4))
+	for i := 0; i < numTables; i++ {
+		rec := offset + 12 + 16*i
+		if r.tag(rec) != tag {
+			continue
+		}
+		start, length := int(r.u32(rec+8)), int(r.u32(rec+12))
+		if start+length > len(data) {
+			return nil, fmt.Errorf("table %s out of bounds", tag)
+		}
+		return data[start : start+length], nil
+	}
+	return nil, nil
+}
+
+// parseGSUB returns the single and ligature substitution lookups referenced
+// by every feature with the given tag, regardless of script and language.
+func parseGSUB(gsub []byte, feature string) (lookups []SubstitutionLookup, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			lookups, err = nil, fmt.Errorf("invalid GSUB table: %v", r)
+		}
+	}()
+
+	if len(gsub) < 10 {
+		return nil, nil
+	}
+
+	r := otReader(gsub)
+	featureList := int(r.u16(6))
+	lookupList := int(r.u16(8))
+
+	indices := make(map[int]bool)
+	for i, n := 0, int(r.u16(featureList)); i < n; i++ {
+		rec := featureList + 2 + 6*i
+		if r.tag(rec) != feature {
+			continue
+		}
+		table := featureList + int(r.u16(rec+4))
+		for j, m := 0, int(r.u16(table+2)); j < m; j++ {
+			indices[int(r.u16(table+4+2*j))] = true
+		}
+	}
+
+	lookupCount := int(r.u16(lookupList))
+	for index := 0; index < lookupCount; index++ {
+		if !indices[index] {
+			continue
+		}
+
+		lookup := lookupList + int(r.u16(lookupList+2+2*index))
+		kind := int(r.u16(lookup))
+		result := SubstitutionLookup{Index: index}
+		for i, n := 0, int(r.u16(lookup+4)); i < n; i++ {
+			sub := lookup + int(r.u16(lookup+6+2*i))
+			subKind := kind
+			if kind == gsubExtension {
+				subKind = int(r.u16(sub + 2))
+				sub += int(r.u32(sub + 4))
+			}
+
+			switch subKind {
+			case gsubSingle:
+				result.Substitutions = append(result.Substitutions, r.singleSubstitutions(sub)...)
+			case gsubLigature:
+				result.Substitutions = append(result.Substitutions, r.ligatureSubstitutions(sub)...)
+			}
+		}
+
+		if len(result.Substitutions) > 0 {
+			lookups = append(lookups, result)
+		}
+	}
+	return lookups, nil
+}
+
+// otReader reads big-endian OpenType values. Out of range reads panic and
+// are recovered by the table parsers.
+type otReader []byte
+
+func (r otReader) u16(off int) uint16 { return binary.BigEndian.Uint16(r[off:]) }
+func (r otReader) u32(off int) uint32 { return binary.BigEndian.Uint32(r[off:]) }
+func (r otReader) tag(off int) string { return string(r[off : off+4]) }
+
+// coverage expands the coverage table at off into its glyphs in coverage
+// index order.
+func (r otReader) coverage(off int) (glyphs []GlyphID) {
+	switch r.u16(off) {
+	case 1:
+		for i, n := 0, int(r.u16(off+2)); i < n; i++ {
+			glyphs = append(glyphs, GlyphID(r.u16(off+4+2*i)))
+		}
+	case 2:
+		for i, n := 0, int(r.u16(off+2)); i < n; i++ {
+			rec := off + 4 + 6*i
+			start, end := int(r.u16(rec)), int(r.u16(rec+2))
+			for gid := start; gid <= end; gid++ {
+				glyphs = append(glyphs, GlyphID(gid))
+			}
+		}
+	}
+	return
+}
+
+func (r otReader) singleSubstitutions(off int) (subs []Substitution) {
+	glyphs := r.coverage(off + int(r.u16(off+2)))
+	switch r.u16(off) {
+	case 1:
+		delta := int(int16(r.u16(off + 4)))
+		for _, gid := range glyphs {
+			subs = append(subs, Substitution{Input: []GlyphID{gid}, Output: GlyphID(uint16(int(gid) + delta))})
+		}
+	case 2:
+		count := int(r.u16(off + 4))
+		for i, gid := range glyphs {
+			if i < count {
+				subs = append(subs, Substitution{Input: []GlyphID{gid}, Output: GlyphID(r.u16(off + 6 + 2*i))})
+			}
+		}
+	}
+	return
+}
+
+func (r otReader) ligatureSubstitutions(off int) (subs []Substitution) {
+	if r.u16(off) != 1 {
+		return nil
+	}
+
+	glyphs := r.coverage(off + int(r.u16(off+2)))
+	setCount := int(r.u16(off + 4))
+	for i, first := range glyphs {
+		if i >= setCount {
+			break
+		}
+		set := off + int(r.u16(off+6+2*i))
+		for j, n := 0, int(r.u16(set)); j < n; j++ {
+			lig := set + int(r.u16(set+2+2*j))
+			components := int(r.u16(lig + 2))
+			input := make([]GlyphID, components)
+			input[0] = first
+			for k := 1; k < components; k++ {
+				input[k] = GlyphID(r.u16(lig + 4 + 2*(k-1)))
+			}
+			subs = append(subs, Substitution{Input: input, Output: GlyphID(r.u16(lig))})
+		}
+	}
+	return
+}
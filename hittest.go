@@ -0,0 +1,176 @@
+package gfx
+
+import "math"
+
+// Contains reports whether pt lies in the area p fills under rule. The
+// winding number is found exactly on curves rather than on a flattening,
+// with every subpath taken to be closed.
+func (p *Path) Contains(pt Point, rule FillRule) bool {
+	w := p.Winding(pt)
+	if rule == FillRuleWinding {
+		return w != 0
+	}
+	return w%2 != 0
+}
+
+// Winding returns the winding number of p around pt, positive for
+// counter-clockwise turns in y-up coordinates. Crossings of the ray from pt
+// along +x are counted with the half-open rule, after cutting curves where
+// they turn vertically so that every piece crosses at most once.
+func (p *Path) Winding(pt Point) (w int) {
+	for _, sub := range splitSubpaths(p) {
+		for _, points := range sub.closedSegments(true) {
+			if b := controlBounds(&Path{Points: points}); pt.Y < b.Y.Min || pt.Y > b.Y.Max || pt.X >= b.X.Max {
+				continue
+			}
+			for _, piece := range yMonotonePieces(points) {
+				w += rayCrossing(piece, pt)
+			}
+		}
+	}
+	return
+}
+
+// StrokeContains reports whether stroking p with stroke covers pt. Curves
+// are flattened finely compared to the line width.
+func (p *Path) StrokeContains(pt Point, stroke *Stroke) bool {
+	halfWidth := stroke.LineWidth / 2
+	if halfWidth <= 0 {
+		return false
+	}
+
+	// nothing of the stroke lies further from the path than a miter tip or
+	// the corner of a square cap
+	reach := halfWidth * math.Sqrt2
+	if stroke.LineJoin == MiterJoin {
+		limit := stroke.MiterLimit
+		if limit <= 0 {
+			limit = DefaultMiterLimit
+		}
+		reach = math.Max(reach, halfWidth*limit)
+	}
+	if p.DistanceTo(pt) > reach {
+		return false
+	}
+
+	inside := false
+	tolerance := math.Min(defaultTolerance(controlBounds(p)), stroke.LineWidth*1e-3)
+	strokePieces(p, stroke, tolerance, func(polygon []Point) {
+		inside = inside || polygonWinding(polygon, pt) != 0
+	})
+	return inside
+}
+
+// DistanceTo returns the distance from pt to the nearest point on p.
+func (p *Path) DistanceTo(pt Point) float64 {
+	_, distance := p.NearestPoint(pt)
+	return distance
+}
+
+// NearestPoint returns the point on p nearest to pt and its distance from
+// pt. Closed subpaths include the line back to their start. An empty path
+// returns pt at an infinite distance.
+func (p *Path) NearestPoint(pt Point) (nearest Point, distance float64) {
+	nearest, distance = pt, math.Inf(1)
+	for _, sub := range splitSubpaths(p) {
+		// lone points are still part of the path
+		if len(sub.segments) == 0 && sub.start.DistanceTo(pt) < distance {
+			nearest, distance = sub.start, sub.start.DistanceTo(pt)
+		}
+		for _, points := range sub.closedSegments(false) {
+			if rectPointDistance(controlBounds(&Path{Points: points}), pt) >= distance {
+				continue
+			}
+			if _, q := bezierNearest(points, pt); q.DistanceTo(pt) < distance {
+				nearest, distance = q, q.DistanceTo(pt)
+			}
+		}
+	}
+	return
+}
+
+// yMonotonePieces cuts a Bézier curve where its y derivative vanishes.
+func yMonotonePieces(points []Point) [][]Point {
+	ys := make([]float64, len(points))
+	for i, pt := range points {
+		ys[i] = pt.Y
+	}
+	ts := bezierTurns(ys)
+
+	var pieces [][]Point
+	prev := 0.0
+	for _, t := range ts {
+		if t > prev && t < 1 {
+			pieces = append(pieces, bezierPiece(points, prev, t))
+			prev = t
+		}
+	}
+	if prev == 0 {
+		return append(pieces, points)
+	}
+	return append(pieces, bezierPiece(points, prev, 1))
+}
+
+// quadraticRoots returns the real roots of a*t*t + b*t + c.
+func quadraticRoots(a, b, c float64) []float64 {
+	if math.Abs(a) < 1e-12*(math.Abs(b)+math.Abs(c)) {
+		if b == 0 {
+			return nil
+		}
+		return []float64{-c / b}
+	}
+	d := b*b - 4*a*c
+	if d < 0 {
+		return nil
+	}
+	// avoid cancellation by taking the larger root first
+	q := -(b + math.Copysign(math.Sqrt(d), b)) / 2
+	if q == 0 {
+		return []float64{0}
+	}
+	return []float64{q / a, c / q}
+}
+
+// rayCrossing returns +1 or -1 if the y-monotone Bézier curve crosses the
+// ray from pt along +x going up or down, and 0 if it does not cross.
+func rayCrossing(points []Point, pt Point) int {
+	a, b := points[0], points[len(points)-1]
+	if (a.Y > pt.Y) == (b.Y > pt.Y) {
+		return 0
+	}
+	dir := -1
+	if b.Y > a.Y {
+		dir = 1
+	}
+
+	bounds := controlBounds(&Path{Points: points})
+	if bounds.X.Min > pt.X {
+		return dir
+	}
+	if bounds.X.Max <= pt.X {
+		return 0
+	}
+
+	// bisect for where the curve meets the ray's line
+	lo, hi := 0.0, 1.0
+	for i := 0; i < 64 && hi-lo > 1e-15; i++ {
+		mid := (lo + hi) / 2
+		if (bezierPoint(points, mid).Y > pt.Y) == (a.Y > pt.Y) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	if bezierPoint(points, (lo+hi)/2).X > pt.X {
+		return dir
+	}
+	return 0
+}
+
+// polygonWinding returns the winding number of a polygon around pt.
+func polygonWinding(polygon []Point, pt Point) (w int) {
+	for i, a := range polygon {
+		w += rayCrossing([]Point{a, polygon[(i+1)%len(polygon)]}, pt)
+	}
+	return
+}
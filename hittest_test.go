@@ -0,0 +1,107 @@
+package gfx_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+func TestPathContains(t *testing.T) {
+	// two circles wound the same way fill the inner one twice
+	rings := circle(0, 0, 10)
+	inner := circle(0, 0, 5)
+	rings.Components = append(rings.Components, inner.Components...)
+	rings.Points = append(rings.Points, inner.Points...)
+
+	at := func(r, deg float64) gfx.Point {
+		a := deg * math.Pi / 180
+		return gfx.Point{X: r * math.Cos(a), Y: r * math.Sin(a)}
+	}
+	tests := []struct {
+		pt               gfx.Point
+		winding, evenOdd bool
+	}{
+		{gfx.Point{0, 0}, true, false},
+		{at(7, 30), true, true},
+		{at(9.999, 45), true, true},
+		{at(10.001, 45), false, false},
+		{at(4.999, 135), true, false},
+		{at(5.001, 135), true, true},
+		// on the level of the circle's top, where the curves turn
+		{gfx.Point{0, 10.001}, false, false},
+		{gfx.Point{-20, 10}, false, false},
+		{gfx.Point{-20, 0}, false, false},
+	}
+	for _, tt := range tests {
+		if got := rings.Contains(tt.pt, gfx.FillRuleWinding); got != tt.winding {
+			t.Errorf("Contains(%v, winding) = %v", tt.pt, got)
+		}
+		if got := rings.Contains(tt.pt, gfx.FillRuleEvenOdd); got != tt.evenOdd {
+			t.Errorf("Contains(%v, even-odd) = %v", tt.pt, got)
+		}
+	}
+
+	// open subpaths fill as if closed
+	open := new(gfx.Path)
+	open.MoveTo(0, 0)
+	open.LineTo(10, 0)
+	open.QuadCurveTo(10, 10, 0, 10)
+	if !open.Contains(gfx.Point{5, 5}, gfx.FillRuleWinding) || open.Contains(gfx.Point{9.9, 9.9}, gfx.FillRuleWinding) {
+		t.Errorf("open path filled wrongly")
+	}
+	if w := square(0, 0, 10, false).Winding(gfx.Point{5, 5}); w != -1 {
+		t.Errorf("clockwise square winding = %d, want -1", w)
+	}
+}
+
+func TestStrokeContains(t *testing.T) {
+	line := new(gfx.Path)
+	line.MoveTo(0, 0)
+	line.LineTo(10, 0)
+
+	tests := []struct {
+		cap  gfx.LineCap
+		pt   gfx.Point
+		want bool
+	}{
+		{gfx.ButtCap, gfx.Point{5, 0.9}, true},
+		{gfx.ButtCap, gfx.Point{5, 1.1}, false},
+		{gfx.ButtCap, gfx.Point{10.5, 0}, false},
+		{gfx.SquareCap, gfx.Point{10.5, 0.9}, true},
+		{gfx.RoundCap, gfx.Point{10.9, 0}, true},
+		{gfx.RoundCap, gfx.Point{10.8, 0.8}, false},
+		{gfx.TriangleCap, gfx.Point{10.4, 0.4}, true},
+		{gfx.TriangleCap, gfx.Point{10.6, 0.6}, false},
+	}
+	for _, tt := range tests {
+		if got := line.StrokeContains(tt.pt, &gfx.Stroke{LineWidth: 2, LineCap: tt.cap}); got != tt.want {
+			t.Errorf("cap %v: StrokeContains(%v) = %v", tt.cap, tt.pt, got)
+		}
+	}
+
+	c := circle(0, 0, 10)
+	if !c.StrokeContains(gfx.Point{0, 10.4}, &gfx.Stroke{LineWidth: 1}) || c.StrokeContains(gfx.Point{0, 9}, &gfx.Stroke{LineWidth: 1}) {
+		t.Errorf("circle stroke picked wrongly")
+	}
+}
+
+func TestPathDistance(t *testing.T) {
+	c := circle(0, 0, 10)
+	if pt, d := c.NearestPoint(gfx.Point{20, 0}); math.Abs(d-10) > 1e-6 || pt.DistanceTo(gfx.Point{10, 0}) > 1e-6 {
+		t.Errorf("NearestPoint = %v, %v", pt, d)
+	}
+	if d := c.DistanceTo(gfx.Point{0, 0}); math.Abs(d-10) > 3e-3 {
+		t.Errorf("distance from center = %v", d)
+	}
+	if d := c.DistanceTo(gfx.Point{30, 40}); math.Abs(d-40) > 3e-3 {
+		t.Errorf("distance = %v, want 40", d)
+	}
+	// the closing line counts
+	if d := square(0, 0, 10, true).DistanceTo(gfx.Point{-1, 5}); math.Abs(d-1) > 1e-9 {
+		t.Errorf("distance to closing edge = %v", d)
+	}
+	if _, d := new(gfx.Path).NearestPoint(gfx.Point{}); !math.IsInf(d, 1) {
+		t.Errorf("empty path distance = %v", d)
+	}
+}
@@ -173,12 +173,14 @@ func (l Lines) GroupIntersecting(minIntersections int) (results []Lines) {
 	clusters := make([][]int, 0)
 	status := make(map[int]int, len(l))
 
+	// intersection points may stray from the segments by a rounding error,
+	// so candidates are searched for with a small margin
+	index := l.Index()
+	margin := Point{1e-9, 1e-9}
+
 	regionQuery := func(id int) (neighbors []int) {
 		obj := l[id]
-		for i := 0; i < len(l); i++ {
-			if id == i {
-				continue
-			}
+		for _, i := range index.intersecting(index.Rects()[id].Expanded(margin), id) {
 			if obj.Intersects(l[i]) {
 				neighbors = append(neighbors, i)
 			}
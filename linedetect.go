@@ -0,0 +1,282 @@
+package gfx
+
+import (
+	"image"
+	"math"
+)
+
+// LineDetectionOptions controls the detection of ruling lines in images.
+// Lengths are in pixels.
+type LineDetectionOptions struct {
+	// Threshold is the gray level at or below which pixels are ink. Zero
+	// picks one with OtsuThreshold.
+	Threshold uint8
+	// MinLength is the length of the shortest line reported.
+	MinLength int
+	// MaxThickness is the thickest stroke that counts as a line; thicker
+	// ink is taken to be a solid area.
+	MaxThickness int
+	// MaxGap is the longest run of background bridged along a line, so
+	// dashed, dotted or damaged rules are found whole.
+	MaxGap int
+	// Angled also detects lines at other angles with a Hough transform.
+	Angled bool
+	// AngleStep is the angular resolution of the Hough transform, in
+	// degrees. Zero means 1.
+	AngleStep float64
+}
+
+func DefaultLineDetectionOptions() LineDetectionOptions {
+	return LineDetectionOptions{MinLength: 30, MaxThickness: 6, MaxGap: 2, AngleStep: 1}
+}
+
+// DetectLines binarizes img and finds its ruling lines. See
+// Bitmap.DetectLines.
+func DetectLines(img image.Image, opts LineDetectionOptions) Lines {
+	return Binarize(img, opts.Threshold).DetectLines(opts)
+}
+
+// DetectLines finds the horizontal and vertical ruling lines of the bitmap
+// by run-length analysis: runs of ink at least MinLength long are stacked
+// across neighbouring rows (or columns) and reported along the middle of the
+// stack, unless it is thicker than MaxThickness. With Angled set, lines at
+// other angles are found with a Hough transform.
+//
+// Lines are in image coordinates, with y growing downwards, and their ends
+// lie on pixel edges. Flip them with Matrix{1, 0, 0, -1, 0, height} before
+// FindTables to get rows ordered top to bottom.
+func (b *Bitmap) DetectLines(opts LineDetectionOptions) (lines Lines) {
+	r := b.Rect
+	for _, run := range detectRuns(r.Dx(), r.Dy(), func(i, j int) bool { return b.Get(r.Min.X+i, r.Min.Y+j) }, opts) {
+		y := float64(r.Min.Y) + run.across
+		lines = append(lines, MakeLine(float64(r.Min.X+run.start), y, float64(r.Min.X+run.end), y))
+	}
+	for _, run := range detectRuns(r.Dy(), r.Dx(), func(i, j int) bool { return b.Get(r.Min.X+j, r.Min.Y+i) }, opts) {
+		x := float64(r.Min.X) + run.across
+		lines = append(lines, MakeLine(x, float64(r.Min.Y+run.start), x, float64(r.Min.Y+run.end)))
+	}
+	if opts.Angled {
+		lines = append(lines, b.thinStrokes(opts).houghLines(opts)...)
+	}
+	return
+}
+
+// thinStrokes returns the ink of b that may belong to an angled line: pixels
+// of runs too long for one (axis-aligned rules) or inside areas thicker than
+// MaxThickness both ways (solid areas) are cleared.
+func (b *Bitmap) thinStrokes(opts LineDetectionOptions) *Bitmap {
+	w, h := b.Rect.Dx(), b.Rect.Dy()
+	runs := func(length, breadth int, index func(i, j int) int) []int {
+		out := make([]int, w*h)
+		for j := 0; j < breadth; j++ {
+			for i := 0; i < length; {
+				if !b.Pix[index(i, j)] {
+					i++
+					continue
+				}
+				end := i
+				for end < length && b.Pix[index(end, j)] {
+					end++
+				}
+				for k := i; k < end; k++ {
+					out[index(k, j)] = end - i
+				}
+				i = end
+			}
+		}
+		return out
+	}
+	across := runs(w, h, func(i, j int) int { return j*w + i })
+	down := runs(h, w, func(i, j int) int { return i*w + j })
+
+	thin := NewBitmap(b.Rect)
+	for i, set := range b.Pix {
+		thin.Pix[i] = set && across[i] < opts.MinLength && down[i] < opts.MinLength &&
+			minInt(across[i], down[i]) <= opts.MaxThickness
+	}
+	return thin
+}
+
+// detectedRun is a stack of runs along one axis: it covers [start, end)
+// along the runs and is centered at across on the other axis.
+type detectedRun struct {
+	start, end int
+	across     float64
+}
+
+// detectRuns finds runs along the first axis of a length by breadth grid
+// and stacks overlapping runs of adjacent rows.
+func detectRuns(length, breadth int, ink func(i, j int) bool, opts LineDetectionOptions) (runs []detectedRun) {
+	type stack struct {
+		start, end  int
+		first, last int
+	}
+
+	emit := func(s stack) {
+		if s.last-s.first+1 <= opts.MaxThickness {
+			runs = append(runs, detectedRun{s.start, s.end, float64(s.first+s.last+1) / 2})
+		}
+	}
+
+	var active []stack
+	for j := 0; j < breadth; j++ {
+		var next []stack
+		for i := 0; i < length; {
+			if !ink(i, j) {
+				i++
+				continue
+			}
+
+			start, end := i, i+1
+			for k := end; k < length && k-end <= opts.MaxGap; k++ {
+				if ink(k, j) {
+					end = k + 1
+				}
+			}
+			i = end
+			if end-start < opts.MinLength {
+				continue
+			}
+
+			run := stack{start, end, j, j}
+			for a := 0; a < len(active); a++ {
+				s := active[a]
+				overlap := math.Min(float64(s.end), float64(end)) - math.Max(float64(s.start), float64(start))
+				if overlap >= 0.5*math.Min(float64(s.end-s.start), float64(end-start)) {
+					run.start, run.end = minInt(run.start, s.start), maxInt(run.end, s.end)
+					run.first = s.first
+					active = append(active[:a], active[a+1:]...)
+					a--
+				}
+			}
+			next = append(next, run)
+		}
+
+		for _, s := range active {
+			emit(s)
+		}
+		active = next
+	}
+	for _, s := range active {
+		emit(s)
+	}
+	return
+}
+
+// houghLines finds lines that are neither horizontal nor vertical. Every ink
+// pixel votes for the lines through it; each local peak of at least
+// MinLength votes is traced across the bitmap and split into segments at
+// gaps longer than MaxGap.
+func (b *Bitmap) houghLines(opts LineDetectionOptions) (lines Lines) {
+	step := opts.AngleStep
+	if step <= 0 {
+		step = 1
+	}
+	r := b.Rect
+	w, h := r.Dx(), r.Dy()
+	diag := int(math.Ceil(math.Hypot(float64(w), float64(h))))
+
+	angles := int(180 / step)
+	sin, cos := make([]float64, angles), make([]float64, angles)
+	for a := range sin {
+		sin[a], cos[a] = math.Sincos(float64(a) * step * math.Pi / 180)
+	}
+
+	rhos := 2*diag + 1
+	acc := make([]int, angles*rhos)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if !b.Pix[y*w+x] {
+				continue
+			}
+			for a := range sin {
+				rho := int(math.Round((float64(x)+0.5)*cos[a]+(float64(y)+0.5)*sin[a])) + diag
+				acc[a*rhos+rho]++
+			}
+		}
+	}
+
+	// axis-aligned lines are left to the run-length pass
+	axis := func(a int) bool {
+		deg := math.Mod(float64(a)*step, 90)
+		return deg <= step || deg >= 90-step
+	}
+
+	window := maxInt(opts.MaxThickness, 1)
+	for a := 0; a < angles; a++ {
+		if axis(a) {
+			continue
+		}
+		for rho := 0; rho < rhos; rho++ {
+			votes := acc[a*rhos+rho]
+			if votes < opts.MinLength || !houghPeak(acc, angles, rhos, a, rho, window) {
+				continue
+			}
+			lines = append(lines, b.traceLine(cos[a], sin[a], float64(rho-diag), opts)...)
+		}
+	}
+	return
+}
+
+// houghPeak reports whether the accumulator cell (a, rho) is the largest in
+// its neighbourhood, ties going to the first cell.
+func houghPeak(acc []int, angles, rhos, a, rho, window int) bool {
+	votes := acc[a*rhos+rho]
+	for da := -2; da <= 2; da++ {
+		na := a + da
+		if na < 0 || na >= angles {
+			continue
+		}
+		for dr := -window; dr <= window; dr++ {
+			nr := rho + dr
+			if nr < 0 || nr >= rhos || (da == 0 && dr == 0) {
+				continue
+			}
+			v := acc[na*rhos+nr]
+			if v > votes || (v == votes && (na < a || (na == a && nr < rho))) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// traceLine walks the line x·cos + y·sin = rho across the bitmap and
+// returns its runs of ink at least MinLength long.
+func (b *Bitmap) traceLine(cos, sin, rho float64, opts LineDetectionOptions) (lines Lines) {
+	r := b.Rect
+	origin := Point{cos * rho, sin * rho}
+	dir := Point{-sin, cos}
+	extent := math.Hypot(float64(r.Dx()), float64(r.Dy()))
+
+	at := func(t float64) Point { return origin.Add(dir.Mul(t)) }
+	ink := func(t float64) bool {
+		p := at(t)
+		x, y := int(math.Floor(p.X)), int(math.Floor(p.Y))
+		return b.Get(r.Min.X+x, r.Min.Y+y)
+	}
+
+	start, end, gap := math.NaN(), 0.0, 0
+	flush := func() {
+		if !math.IsNaN(start) && end-start >= float64(opts.MinLength) {
+			s, e := at(start), at(end)
+			lines = append(lines, MakeLine(float64(r.Min.X)+s.X, float64(r.Min.Y)+s.Y, float64(r.Min.X)+e.X, float64(r.Min.Y)+e.Y))
+		}
+		start = math.NaN()
+	}
+
+	for t := -extent; t <= extent; t++ {
+		if ink(t) {
+			if math.IsNaN(start) {
+				start = t
+			}
+			end, gap = t+1, 0
+			continue
+		}
+		if gap++; gap > opts.MaxGap {
+			flush()
+		}
+	}
+	flush()
+	return
+}
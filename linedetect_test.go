@@ -0,0 +1,57 @@
+package gfx_test
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+func TestDetectLines(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 300, 200))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	fill := func(x0, y0, x1, y1 int) {
+		draw.Draw(img, image.Rect(x0, y0, x1, y1), image.Black, image.Point{}, draw.Src)
+	}
+	fill(10, 10, 290, 12)
+	fill(10, 10, 12, 190)
+	fill(10, 100, 150, 101)
+	fill(155, 100, 290, 101)
+	// dashed, bridged by MaxGap
+	for x := 20; x < 280; x += 4 {
+		fill(x, 150, x+3, 151)
+	}
+	// a solid area is not a line
+	fill(200, 30, 260, 90)
+	// a diagonal
+	for i := 0; i < 80; i++ {
+		img.SetGray(40+i, 190-i/2, color.Gray{})
+	}
+
+	opts := gfx.DefaultLineDetectionOptions()
+	opts.Angled = true
+	lines := gfx.DetectLines(img, opts)
+
+	want := gfx.Lines{
+		gfx.MakeLine(10, 11, 290, 11),
+		gfx.MakeLine(10, 100.5, 150, 100.5),
+		gfx.MakeLine(155, 100.5, 290, 100.5),
+		gfx.MakeLine(20, 150.5, 279, 150.5),
+		gfx.MakeLine(11, 10, 11, 190),
+	}
+	if len(lines) != len(want)+1 {
+		t.Fatalf("lines = %v, want %v and a diagonal", lines, want)
+	}
+	for i, line := range want {
+		if lines[i] != line {
+			t.Errorf("line %d = %v, want %v", i, lines[i], line)
+		}
+	}
+
+	diagonal := lines[len(want)]
+	if diagonal.Length() < 70 || diagonal.IsAxisAligned() {
+		t.Errorf("diagonal = %v", diagonal)
+	}
+}
@@ -186,8 +186,9 @@ func (dasher *DashVertexConverter) LineJoin() {
 	dasher.next.LineJoin()
 }
 
+// Close does not pass the close on, as the dashes of a closed subpath are
+// stroked as open pieces.
 func (dasher *DashVertexConverter) Close() {
-	dasher.next.Close()
 }
 
 func (dasher *DashVertexConverter) End() {
@@ -255,14 +256,20 @@ func (liner ftLineBuilder) LineJoin() {}
 func (liner ftLineBuilder) Close()    {}
 func (liner ftLineBuilder) End()      {}
 
+// LineStroker strokes the polylines it receives and passes the outline of
+// each stroke on to Flattener as closed polygons, to be filled with the
+// nonzero winding rule. A subpath is stroked when it ends, with the cap,
+// join and miter limit set on the stroker.
 type LineStroker struct {
 	Flattener     Flattener
 	HalfLineWidth float64
 	Cap           LineCap
 	Join          LineJoin
-	vertices      []float64
-	rewind        []float64
-	x, y, nx, ny  float64
+	MiterLimit    float64
+	// Tolerance bounds the error of flattened round joins and caps.
+	Tolerance float64
+	points    []Point
+	closed    bool
 }
 
 func NewLineStroker(c LineCap, j LineJoin, flattener Flattener) *LineStroker {
@@ -271,64 +278,53 @@ func NewLineStroker(c LineCap, j LineJoin, flattener Flattener) *LineStroker {
 	l.HalfLineWidth = 0.5
 	l.Cap = c
 	l.Join = j
+	l.MiterLimit = DefaultMiterLimit
+	l.Tolerance = 0.125
 	return l
 }
 
 func (l *LineStroker) MoveTo(x, y float64) {
-	l.x, l.y = x, y
+	l.stroke()
+	l.points = append(l.points, Point{x, y})
 }
 
 func (l *LineStroker) LineTo(x, y float64) {
-	l.line(l.x, l.y, x, y)
+	if l.closed {
+		// drawing on after a close starts a new subpath at the old start
+		start := l.points[0]
+		l.stroke()
+		l.points = append(l.points, start)
+	}
+	l.points = append(l.points, Point{x, y})
 }
 
 func (l *LineStroker) LineJoin() {
 
 }
 
-func (l *LineStroker) line(x1, y1, x2, y2 float64) {
-	dx := (x2 - x1)
-	dy := (y2 - y1)
-	d := vectorDistance(dx, dy)
-	if d != 0 {
-		nx := dy * l.HalfLineWidth / d
-		ny := -(dx * l.HalfLineWidth / d)
-		l.appendVertex(x1+nx, y1+ny, x2+nx, y2+ny, x1-nx, y1-ny, x2-nx, y2-ny)
-		l.x, l.y, l.nx, l.ny = x2, y2, nx, ny
-	}
-}
-
 func (l *LineStroker) Close() {
-	if len(l.vertices) > 1 {
-		l.appendVertex(l.vertices[0], l.vertices[1], l.rewind[0], l.rewind[1])
-	}
+	l.closed = len(l.points) > 0
 }
 
 func (l *LineStroker) End() {
-	if len(l.vertices) > 1 {
-		l.Flattener.MoveTo(l.vertices[0], l.vertices[1])
-		for i, j := 2, 3; j < len(l.vertices); i, j = i+2, j+2 {
-			l.Flattener.LineTo(l.vertices[i], l.vertices[j])
-		}
-	}
-	for i, j := len(l.rewind)-2, len(l.rewind)-1; j > 0; i, j = i-2, j-2 {
-		l.Flattener.LineTo(l.rewind[i], l.rewind[j])
-	}
-	if len(l.vertices) > 1 {
-		l.Flattener.LineTo(l.vertices[0], l.vertices[1])
-	}
+	l.stroke()
 	l.Flattener.End()
+}
 
-	l.vertices = l.vertices[0:0]
-	l.rewind = l.rewind[0:0]
-	l.x, l.y, l.nx, l.ny = 0, 0, 0, 0
-
+func (l *LineStroker) stroke() {
+	if len(l.points) > 0 {
+		g := newStrokeGeometry(l.HalfLineWidth, l.Join, l.Cap, l.MiterLimit, l.Tolerance, l.emit)
+		g.Polyline(l.points, l.closed)
+	}
+	l.points, l.closed = l.points[:0], false
 }
 
-func (l *LineStroker) appendVertex(vertices ...float64) {
-	s := len(vertices) / 2
-	l.vertices = append(l.vertices, vertices[:s]...)
-	l.rewind = append(l.rewind, vertices[s:]...)
+func (l *LineStroker) emit(polygon []Point) {
+	l.Flattener.MoveTo(polygon[0].X, polygon[0].Y)
+	for _, pt := range polygon[1:] {
+		l.Flattener.LineTo(pt.X, pt.Y)
+	}
+	l.Flattener.LineTo(polygon[0].X, polygon[0].Y)
 }
 
 func vectorDistance(dx, dy float64) float64 {
@@ -0,0 +1,249 @@
+package gfx
+
+import (
+	"math"
+	"sort"
+)
+
+// PathMeasure measures distances along a path. Distances run through the
+// subpaths in order, each subpath starting where the previous one ended;
+// closing a subpath adds the line back to its start.
+type PathMeasure struct {
+	subpaths []measuredSubpath
+	length   float64
+}
+
+type measuredSubpath struct {
+	start    Point
+	segments []measuredSegment
+	offset   float64
+	length   float64
+	closed   bool
+}
+
+// measuredSegment is a line, quadratic or cubic segment with a table of
+// arc lengths at parameters chosen while integrating, used to invert the
+// arc length.
+type measuredSegment struct {
+	points []Point
+	offset float64
+	length float64
+	ts, ss []float64
+}
+
+// NewPathMeasure measures p. Curve lengths are integrated with adaptive
+// Gauss–Legendre quadrature to a relative error of about 1e-9.
+func NewPathMeasure(p *Path) *PathMeasure {
+	m := new(PathMeasure)
+	for _, sub := range splitSubpaths(p) {
+		measured := measuredSubpath{start: sub.start, offset: m.length, closed: sub.closed}
+		for _, points := range sub.closedSegments(false) {
+			s := newMeasuredSegment(points)
+			s.offset = m.length
+			measured.segments = append(measured.segments, s)
+			measured.length += s.length
+			m.length += s.length
+		}
+		m.subpaths = append(m.subpaths, measured)
+	}
+	return m
+}
+
+// Length returns the length of the whole path.
+func (m *PathMeasure) Length() float64 { return m.length }
+
+// SubpathLengths returns the length of each subpath.
+func (m *PathMeasure) SubpathLengths() []float64 {
+	lengths := make([]float64, len(m.subpaths))
+	for i, sub := range m.subpaths {
+		lengths[i] = sub.length
+	}
+	return lengths
+}
+
+// PointAt returns the point at distance along the path and the angle of the
+// tangent there, in radians counter-clockwise from the x axis. Distances are
+// clamped to the path.
+func (m *PathMeasure) PointAt(distance float64) (pt Point, angle float64) {
+	if len(m.subpaths) == 0 {
+		return
+	}
+	distance = math.Max(0, math.Min(distance, m.length))
+
+	// take the first subpath reaching the distance, so an empty subpath at
+	// the very start is found
+	i := sort.Search(len(m.subpaths), func(i int) bool {
+		return m.subpaths[i].offset+m.subpaths[i].length >= distance
+	})
+	if i == len(m.subpaths) {
+		i--
+	}
+	sub := m.subpaths[i]
+	if len(sub.segments) == 0 {
+		return sub.start, 0
+	}
+
+	k := sort.Search(len(sub.segments), func(k int) bool {
+		return sub.segments[k].offset+sub.segments[k].length >= distance
+	})
+	if k == len(sub.segments) {
+		k--
+	}
+	s := sub.segments[k]
+	t := s.paramAt(distance - s.offset)
+	d := s.tangent(t)
+	return s.eval(t), math.Atan2(d.Y, d.X)
+}
+
+// SubPath returns the part of the path between distances from and to. Each
+// subpath it crosses starts a new subpath; a closed subpath taken whole stays
+// closed. It is empty if from is not before to.
+func (m *PathMeasure) SubPath(from, to float64) *Path {
+	path := new(Path)
+	from, to = math.Max(from, 0), math.Min(to, m.length)
+	if from >= to {
+		return path
+	}
+
+	for _, sub := range m.subpaths {
+		if sub.offset+sub.length <= from || sub.offset >= to || len(sub.segments) == 0 {
+			continue
+		}
+
+		moved := false
+		for _, s := range sub.segments {
+			a, b := math.Max(from-s.offset, 0), math.Min(to-s.offset, s.length)
+			if a >= b {
+				continue
+			}
+
+			piece := bezierPiece(s.points, s.paramAt(a), s.paramAt(b))
+			if !moved {
+				path.MoveTo(piece[0].X, piece[0].Y)
+				moved = true
+			}
+			switch len(piece) {
+			case 2:
+				path.LineTo(piece[1].X, piece[1].Y)
+			case 3:
+				path.QuadCurveTo(piece[1].X, piece[1].Y, piece[2].X, piece[2].Y)
+			case 4:
+				path.CubicCurveTo(piece[1].X, piece[1].Y, piece[2].X, piece[2].Y, piece[3].X, piece[3].Y)
+			}
+		}
+		if sub.closed && from <= sub.offset && to >= sub.offset+sub.length {
+			path.Close()
+		}
+	}
+	return path
+}
+
+// gaussLegendre holds the nodes on [-1, 1] and weights of five point
+// Gauss–Legendre quadrature.
+var gaussLegendre = [5][2]float64{
+	{0, 0.5688888888888889},
+	{-0.5384693101056831, 0.4786286704993665},
+	{0.5384693101056831, 0.4786286704993665},
+	{-0.9061798459386640, 0.2369268850561891},
+	{0.9061798459386640, 0.2369268850561891},
+}
+
+// measureDepthLimit bounds the subdivision of the arc length integral.
+const measureDepthLimit = 16
+
+func newMeasuredSegment(points []Point) measuredSegment {
+	s := measuredSegment{points: points, ts: []float64{0}, ss: []float64{0}}
+	if len(points) == 2 {
+		s.length = points[0].DistanceTo(points[1])
+		s.ts, s.ss = append(s.ts, 1), append(s.ss, s.length)
+		return s
+	}
+
+	// the control polygon is at least as long as the curve
+	var hull float64
+	for i := 1; i < len(points); i++ {
+		hull += points[i-1].DistanceTo(points[i])
+	}
+	s.subdivide(0, 1, s.integrate(0, 1), hull*1e-10, 0)
+	return s
+}
+
+// subdivide integrates the speed over [a, b] given the estimate whole,
+// halving the interval until the halves agree with it, and appends the end
+// of each accepted interval to the table.
+func (s *measuredSegment) subdivide(a, b, whole, tolerance float64, depth int) {
+	mid := (a + b) / 2
+	left, right := s.integrate(a, mid), s.integrate(mid, b)
+	if depth < measureDepthLimit && math.Abs(left+right-whole) > tolerance {
+		s.subdivide(a, mid, left, tolerance/2, depth+1)
+		s.subdivide(mid, b, right, tolerance/2, depth+1)
+		return
+	}
+	s.length += left + right
+	s.ts, s.ss = append(s.ts, b), append(s.ss, s.length)
+}
+
+// integrate returns the arc length between parameters a and b.
+func (s *measuredSegment) integrate(a, b float64) (length float64) {
+	half, mid := (b-a)/2, (a+b)/2
+	for _, node := range gaussLegendre {
+		length += node[1] * s.derivative(mid+half*node[0]).Norm()
+	}
+	return length * half
+}
+
+// paramAt returns the parameter at arc length distance into the segment,
+// refining the table's linear estimate with Newton steps.
+func (s *measuredSegment) paramAt(distance float64) float64 {
+	if distance <= 0 {
+		return 0
+	}
+	if distance >= s.length {
+		return 1
+	}
+
+	i := sort.SearchFloat64s(s.ss, distance)
+	if i == 0 {
+		return 0
+	}
+	t0, t1, s0, s1 := s.ts[i-1], s.ts[i], s.ss[i-1], s.ss[i]
+	if len(s.points) == 2 || s1 == s0 {
+		return t0 + (t1-t0)*(distance-s0)/(s1-s0)
+	}
+
+	lo, hi := t0, t1
+	t := t0 + (t1-t0)*(distance-s0)/(s1-s0)
+	for iter := 0; iter < 16; iter++ {
+		f := s0 + s.integrate(t0, t) - distance
+		if math.Abs(f) <= 1e-12*math.Max(s.length, 1) {
+			break
+		}
+		if f > 0 {
+			hi = t
+		} else {
+			lo = t
+		}
+		// fall back to bisection where a Newton step leaves the bracket
+		next := t - f/s.derivative(t).Norm()
+		if math.IsNaN(next) || next <= lo || next >= hi {
+			next = (lo + hi) / 2
+		}
+		t = next
+	}
+	return t
+}
+
+func (s *measuredSegment) eval(t float64) Point { return bezierPoint(s.points, t) }
+
+func (s *measuredSegment) derivative(t float64) Point { return bezierDerivative(s.points, t) }
+
+// tangent returns the direction of the segment at t, falling back to the
+// direction between nearby points where the derivative vanishes, as it does
+// at a control point lying on its end point.
+func (s *measuredSegment) tangent(t float64) Point {
+	if d := s.derivative(t); d.Norm() > 1e-12 {
+		return d
+	}
+	a, b := math.Max(t-1e-6, 0), math.Min(t+1e-6, 1)
+	return s.eval(b).Sub(s.eval(a))
+}
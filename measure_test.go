@@ -0,0 +1,76 @@
+package gfx_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+func TestPathMeasure(t *testing.T) {
+	near := func(a, b float64) bool { return math.Abs(a-b) <= 1e-6 }
+	nearPoint := func(a, b gfx.Point) bool { return near(a.X, b.X) && near(a.Y, b.Y) }
+
+	// a straight cubic with uneven control points runs at uneven speed
+	p := new(gfx.Path)
+	p.MoveTo(0, 0)
+	p.CubicCurveTo(1, 0, 2, 0, 10, 0)
+	// a parabola of length sqrt(5) + asinh(2)/2
+	p.MoveTo(0, 0)
+	p.QuadCurveTo(1, 2, 2, 0)
+	p.Close()
+
+	m := gfx.NewPathMeasure(p)
+	parabola := math.Sqrt(5) + math.Asinh(2)/2
+	lengths := m.SubpathLengths()
+	if len(lengths) != 2 || !near(lengths[0], 10) || !near(lengths[1], parabola+2) || !near(m.Length(), 12+parabola) {
+		t.Errorf("lengths = %v, total %v", lengths, m.Length())
+	}
+	if pt, angle := m.PointAt(5); !nearPoint(pt, gfx.Point{5, 0}) || !near(angle, 0) {
+		t.Errorf("PointAt(5) = %v, %v", pt, angle)
+	}
+	if pt, angle := m.PointAt(10 + parabola/2); !nearPoint(pt, gfx.Point{1, 1}) || !near(angle, 0) {
+		t.Errorf("parabola apex = %v, %v", pt, angle)
+	}
+	if pt, angle := m.PointAt(11 + parabola); !nearPoint(pt, gfx.Point{1, 0}) || !near(math.Abs(angle), math.Pi) {
+		t.Errorf("closing line = %v, %v", pt, angle)
+	}
+	if pt, _ := m.PointAt(100); !nearPoint(pt, gfx.Point{0, 0}) {
+		t.Errorf("PointAt past the end = %v", pt)
+	}
+
+	sq := gfx.NewPathMeasure(square(0, 0, 10, true))
+	if pt, angle := sq.PointAt(15); !nearPoint(pt, gfx.Point{10, 5}) || !near(angle, math.Pi/2) {
+		t.Errorf("square PointAt(15) = %v, %v", pt, angle)
+	}
+	sub := sq.SubPath(5, 25)
+	if got := gfx.NewPathMeasure(sub).Length(); !near(got, 20) {
+		t.Errorf("square SubPath length = %v, want 20", got)
+	}
+	if got := sub.Points; len(got) != 4 || !nearPoint(got[0], gfx.Point{5, 0}) || !nearPoint(got[3], gfx.Point{5, 10}) {
+		t.Errorf("square SubPath = %v", sub)
+	}
+	if whole := sq.SubPath(0, 40); whole.Components[len(whole.Components)-1] != gfx.ClosePathComp {
+		t.Errorf("whole square not closed: %v", whole)
+	}
+
+	// pieces of curves keep their length and land on the measured points
+	c := gfx.NewPathMeasure(circle(0, 0, 10))
+	for _, span := range [][2]float64{{0, 10}, {3, 50}, {12.5, 12.75}, {20, 62}} {
+		piece := gfx.NewPathMeasure(c.SubPath(span[0], span[1]))
+		if got := piece.Length(); !near(got, span[1]-span[0]) {
+			t.Errorf("circle SubPath(%v, %v) length = %v", span[0], span[1], got)
+		}
+		start, _ := c.PointAt(span[0])
+		end, _ := c.PointAt(span[1])
+		if a, _ := piece.PointAt(0); !nearPoint(a, start) {
+			t.Errorf("circle SubPath(%v, %v) starts at %v, want %v", span[0], span[1], a, start)
+		}
+		if b, _ := piece.PointAt(piece.Length()); !nearPoint(b, end) {
+			t.Errorf("circle SubPath(%v, %v) ends at %v, want %v", span[0], span[1], b, end)
+		}
+	}
+	if pt, _ := c.PointAt(c.Length() / 4); pt.Norm() < 9.99 || pt.Norm() > 10.01 {
+		t.Errorf("circle point %v off the circle", pt)
+	}
+}
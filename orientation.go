@@ -1,5 +1,10 @@
 package gfx
 
+import (
+	"math"
+	"sort"
+)
+
 type Orientation int
 
 // orientations
@@ -10,3 +15,76 @@ const (
 	PageDown
 	PageLeft
 )
+
+// PageOrientation is the estimated orientation of the text on a page. Angle
+// is the rotation of the dominant text's baselines in degrees: the quarter
+// turn of Orientation plus the fine Skew. Confidence is the share of the
+// text, weighted by baseline length, that runs in the dominant orientation.
+// Matrix rotates the page by -Angle so its text reads PageUp and moves the
+// rotated page back to the origin.
+type PageOrientation struct {
+	Orientation Orientation
+	Confidence  float64
+	Skew        float64
+	Angle       float64
+	Matrix      Matrix
+}
+
+// EstimateOrientation estimates the orientation and skew of a page from the
+// quads of its letters or words. Each quad votes for the quarter turn nearest
+// its baseline angle with the length of its baseline; the skew is the
+// weighted median of the remaining angles of the winning quads. page is the
+// page area used to place the normalized page; if it is empty the bounds of
+// the quads are used.
+func (q Quads) EstimateOrientation(page Rect) (p PageOrientation) {
+	p.Orientation, p.Matrix = OtherOrientation, IdentityMatrix
+	if len(q) == 0 {
+		return
+	}
+
+	type vote struct{ skew, weight float64 }
+	var votes [4][]vote
+	var weights [4]float64
+	total := 0.0
+	for _, quad := range q {
+		weight := quad.BottomRight.DistanceTo(quad.BottomLeft)
+		if weight <= 0 {
+			weight = 1
+		}
+		angle := BoundAngle180(quad.Rotation())
+		turn := int(math.Round(angle / 90))
+		k := (turn%4 + 4) % 4
+		votes[k] = append(votes[k], vote{angle - float64(turn)*90, weight})
+		weights[k] += weight
+		total += weight
+	}
+
+	k := 0
+	for i := range weights {
+		if weights[i] > weights[k] {
+			k = i
+		}
+	}
+
+	sort.Slice(votes[k], func(i, j int) bool { return votes[k][i].skew < votes[k][j].skew })
+	half, sum := weights[k]/2, 0.0
+	for _, v := range votes[k] {
+		sum += v.weight
+		if sum >= half {
+			p.Skew = v.skew
+			break
+		}
+	}
+
+	p.Orientation = [4]Orientation{PageUp, PageLeft, PageDown, PageRight}[k]
+	p.Confidence = weights[k] / total
+	p.Angle = BoundAngle180(float64(k)*90 + p.Skew)
+
+	if page.IsEmpty() {
+		page = q.Normalize().Bounds()
+	}
+	rotation := NewRotationMatrixDeg(-p.Angle)
+	rotated := rotation.TransformRect(page)
+	p.Matrix = rotation.Translated(-rotated.X.Min, -rotated.Y.Min)
+	return
+}
@@ -0,0 +1,299 @@
+package gfx
+
+import "math"
+
+// StrokeOutline returns the area covered by stroking p as a path to be
+// filled, honoring the stroke's width, caps, joins, miter limit and dashes.
+// Curves are flattened to within tolerance; zero picks a tolerance from the
+// size of the path. The outline has no overlaps, with outer contours running
+// counter-clockwise and holes clockwise.
+func (p *Path) StrokeOutline(stroke *Stroke, tolerance float64) *Path {
+	if tolerance <= 0 {
+		tolerance = defaultTolerance(controlBounds(p).ExpandedByMargin(stroke.LineWidth))
+	}
+
+	outline := new(Path)
+	strokePieces(p, stroke, tolerance, pathPolygon(outline))
+	return BooleanPaths(outline, new(Path), PathUnion, FillRuleWinding, tolerance)
+}
+
+// strokePieces flattens p, dashes it and passes the pieces of its stroke to
+// emit as counter-clockwise polygons.
+func strokePieces(p *Path, stroke *Stroke, tolerance float64, emit func([]Point)) {
+	g := newStrokeGeometry(stroke.LineWidth/2, stroke.LineJoin, stroke.LineCap, stroke.MiterLimit, tolerance, emit)
+	for _, line := range flattenPolylines(p, tolerance) {
+		if len(stroke.Dashes) == 0 {
+			g.Polyline(line.points, line.closed)
+			continue
+		}
+		for _, dash := range dashPolyline(line.points, line.closed, stroke.Dashes, stroke.DashPhase) {
+			g.Polyline(dash, false)
+		}
+	}
+}
+
+// Offset grows the area p fills under the winding rule by distance, or
+// shrinks it for negative distances, with corners shaped by join. Every
+// subpath is taken to be closed. Miter joins are cut off at the default
+// miter limit.
+func (p *Path) Offset(distance float64, join LineJoin) *Path {
+	tolerance := defaultTolerance(controlBounds(p).ExpandedByMargin(math.Abs(distance)))
+
+	band := new(Path)
+	g := newStrokeGeometry(math.Abs(distance), join, ButtCap, DefaultMiterLimit, tolerance, pathPolygon(band))
+	for _, line := range flattenPolylines(p, tolerance) {
+		g.Polyline(line.points, true)
+	}
+
+	if distance < 0 {
+		return BooleanPaths(p, band, PathDifference, FillRuleWinding, tolerance)
+	}
+	return BooleanPaths(p, band, PathUnion, FillRuleWinding, tolerance)
+}
+
+// pathPolygon returns a function adding polygons to path as closed subpaths.
+func pathPolygon(path *Path) func([]Point) {
+	return func(polygon []Point) {
+		path.MoveTo(polygon[0].X, polygon[0].Y)
+		for _, pt := range polygon[1:] {
+			path.LineTo(pt.X, pt.Y)
+		}
+		path.Close()
+	}
+}
+
+// strokeGeometry breaks the stroke of a polyline into convex pieces: a
+// rectangle for each segment, a wedge for each join and a shape for each
+// cap. Every piece is handed to emit as a counter-clockwise polygon, so the
+// pieces together fill the stroke under the nonzero winding rule.
+type strokeGeometry struct {
+	halfWidth  float64
+	join       LineJoin
+	cap        LineCap
+	miterLimit float64
+	tolerance  float64
+	emit       func([]Point)
+}
+
+func newStrokeGeometry(halfWidth float64, join LineJoin, cap LineCap, miterLimit, tolerance float64, emit func([]Point)) *strokeGeometry {
+	if miterLimit <= 0 {
+		miterLimit = DefaultMiterLimit
+	}
+	return &strokeGeometry{halfWidth: halfWidth, join: join, cap: cap, miterLimit: miterLimit, tolerance: tolerance, emit: emit}
+}
+
+// Polyline strokes the polyline through points, joined back to its start if
+// closed and capped at both ends otherwise. A polyline of a single point
+// leaves a dot for round and square caps.
+func (g *strokeGeometry) Polyline(points []Point, closed bool) {
+	if g.halfWidth <= 0 || len(points) == 0 {
+		return
+	}
+
+	pts := []Point{points[0]}
+	for _, pt := range points[1:] {
+		if pt != pts[len(pts)-1] {
+			pts = append(pts, pt)
+		}
+	}
+	if closed && len(pts) > 1 && pts[0] == pts[len(pts)-1] {
+		pts = pts[:len(pts)-1]
+	}
+
+	n := len(pts)
+	if n == 1 {
+		g.Dot(pts[0])
+		return
+	}
+
+	segments := n - 1
+	if closed {
+		segments = n
+	}
+	for i := 0; i < segments; i++ {
+		g.Segment(pts[i], pts[(i+1)%n])
+	}
+
+	for i := 1; i < n-1; i++ {
+		g.Join(pts[i-1], pts[i], pts[i+1])
+	}
+	if closed {
+		g.Join(pts[n-1], pts[0], pts[1])
+		g.Join(pts[n-2], pts[n-1], pts[0])
+		return
+	}
+	g.Cap(pts[0], pts[0].Sub(pts[1]))
+	g.Cap(pts[n-1], pts[n-1].Sub(pts[n-2]))
+}
+
+// Segment adds the rectangle covering the segment from a to b.
+func (g *strokeGeometry) Segment(a, b Point) {
+	n := g.normal(b.Sub(a))
+	g.emitPolygon([]Point{a.Sub(n), b.Sub(n), b.Add(n), a.Add(n)})
+}
+
+// Join adds the wedge filling the outside of the corner at p between the
+// segments from a and to b.
+func (g *strokeGeometry) Join(a, p, b Point) {
+	d0, d1 := p.Sub(a), b.Sub(p)
+	turn := math.Atan2(d0.Cross(d1), d0.Dot(d1))
+	if math.Abs(turn) < 1e-9 {
+		return
+	}
+
+	// the outside of a left turn is on the right
+	n0, n1 := g.normal(d0), g.normal(d1)
+	if turn > 0 {
+		n0, n1 = n0.Mul(-1), n1.Mul(-1)
+	}
+
+	switch g.join {
+	case RoundJoin:
+		g.emitPolygon(append([]Point{p, p.Add(n0)}, g.arc(p, n0, turn)...))
+	case MiterJoin:
+		// the tip lies where the outer edges meet, 1/sin(theta/2) half
+		// widths from p for an angle theta between the segments
+		if k := g.halfWidth * g.halfWidth / (g.halfWidth*g.halfWidth + n0.Dot(n1)); !math.IsInf(k, 0) && k > 0 {
+			tip := n0.Add(n1).Mul(k)
+			if tip.Norm() <= g.miterLimit*g.halfWidth {
+				g.emitPolygon([]Point{p, p.Add(n0), p.Add(tip), p.Add(n1)})
+				return
+			}
+		}
+		fallthrough
+	default:
+		g.emitPolygon([]Point{p, p.Add(n0), p.Add(n1)})
+	}
+}
+
+// Cap adds the cap at the end p of a stroke leaving in direction out.
+func (g *strokeGeometry) Cap(p, out Point) {
+	u := out.Normalize().Mul(g.halfWidth)
+	n := u.Ortho()
+	switch g.cap {
+	case RoundCap:
+		g.emitPolygon(append([]Point{p.Add(n)}, g.arc(p, n, -math.Pi)...))
+	case SquareCap:
+		g.emitPolygon([]Point{p.Add(n), p.Add(n).Add(u), p.Sub(n).Add(u), p.Sub(n)})
+	case TriangleCap:
+		g.emitPolygon([]Point{p.Add(n), p.Add(u), p.Sub(n)})
+	}
+}
+
+// Dot adds the mark left by stroking a single point: a disc for round caps
+// and an axis-aligned square for square caps.
+func (g *strokeGeometry) Dot(p Point) {
+	n := Point{g.halfWidth, 0}
+	switch g.cap {
+	case RoundCap:
+		g.emitPolygon(append([]Point{p.Add(n)}, g.arc(p, n, 2*math.Pi)...))
+	case SquareCap:
+		h := g.halfWidth
+		g.emitPolygon([]Point{{p.X - h, p.Y - h}, {p.X + h, p.Y - h}, {p.X + h, p.Y + h}, {p.X - h, p.Y + h}})
+	}
+}
+
+// normal returns the left normal of d, half a line width long.
+func (g *strokeGeometry) normal(d Point) Point {
+	return d.Normalize().Ortho().Mul(g.halfWidth)
+}
+
+// arc returns the points of the arc around center from center+from turning
+// by sweep radians, without its start.
+func (g *strokeGeometry) arc(center, from Point, sweep float64) (points []Point) {
+	var arc pointLiner
+	start := math.Atan2(from.Y, from.X)
+	scale := 0.125 / math.Max(math.Min(g.tolerance, g.halfWidth/2), g.halfWidth*1e-4)
+	x, y := TraceArc(&arc, center.X, center.Y, g.halfWidth, g.halfWidth, start, sweep, scale)
+	return append(arc, Point{x, y})
+}
+
+func (g *strokeGeometry) emitPolygon(polygon []Point) {
+	var area float64
+	for i, p := range polygon {
+		area += p.Cross(polygon[(i+1)%len(polygon)])
+	}
+	if area < 0 {
+		for i, j := 0, len(polygon)-1; i < j; i, j = i+1, j-1 {
+			polygon[i], polygon[j] = polygon[j], polygon[i]
+		}
+	}
+	g.emit(polygon)
+}
+
+// dashPolyline cuts a polyline into the pieces lying under the "on" dashes
+// of a pattern of alternating on and off lengths, starting phase into the
+// pattern. A pattern of odd length repeats with on and off swapped, and a
+// pattern with negative lengths or no length leaves the polyline whole. On a
+// closed polyline, dashes running across the start are joined into one.
+func dashPolyline(points []Point, closed bool, dashes []float64, phase float64) (pieces [][]Point) {
+	total := 0.0
+	for _, d := range dashes {
+		if d < 0 {
+			return [][]Point{points}
+		}
+		total += d
+	}
+	if total <= 0 {
+		return [][]Point{points}
+	}
+	if len(dashes)%2 != 0 {
+		dashes = append(append([]float64(nil), dashes...), dashes...)
+		total *= 2
+	}
+	if closed && len(points) > 1 {
+		points = append(append([]Point(nil), points...), points[0])
+	}
+
+	i, rest := 0, dashes[0]
+	if phase = math.Mod(phase, total); phase < 0 {
+		phase += total
+	}
+	for phase > rest {
+		phase -= rest
+		i = (i + 1) % len(dashes)
+		rest = dashes[i]
+	}
+	rest -= phase
+
+	var piece []Point
+	if i%2 == 0 {
+		piece = []Point{points[0]}
+	}
+	startsOn := i%2 == 0
+	for k := 1; k < len(points); k++ {
+		a, b := points[k-1], points[k]
+		length := a.DistanceTo(b)
+		if length == 0 {
+			continue
+		}
+		for pos := 0.0; ; {
+			if rest > length-pos {
+				rest -= length - pos
+				break
+			}
+			pos += rest
+			pt := a.Add(b.Sub(a).Mul(pos / length))
+			if i%2 == 0 {
+				pieces = append(pieces, append(piece, pt))
+				piece = nil
+			} else {
+				piece = []Point{pt}
+			}
+			i = (i + 1) % len(dashes)
+			rest = dashes[i]
+		}
+		if i%2 == 0 {
+			piece = append(piece, b)
+		}
+	}
+
+	if i%2 == 0 && len(piece) > 0 {
+		if closed && startsOn && len(pieces) > 0 {
+			pieces[0] = append(piece, pieces[0][1:]...)
+		} else {
+			pieces = append(pieces, piece)
+		}
+	}
+	return
+}
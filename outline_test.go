@@ -0,0 +1,167 @@
+package gfx_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+func TestStrokeOutline(t *testing.T) {
+	line := new(gfx.Path)
+	line.MoveTo(0, 0)
+	line.LineTo(10, 0)
+
+	corner := new(gfx.Path)
+	corner.MoveTo(0, 0)
+	corner.LineTo(10, 0)
+	corner.LineTo(10, 10)
+
+	spike := new(gfx.Path)
+	spike.MoveTo(0, 0)
+	spike.LineTo(10, 0)
+	spike.LineTo(0, 1)
+
+	tests := []struct {
+		name     string
+		path     *gfx.Path
+		stroke   gfx.Stroke
+		area     float64
+		contours int
+	}{
+		{"butt", line, gfx.Stroke{LineWidth: 2, LineCap: gfx.ButtCap}, 20, 1},
+		{"square", line, gfx.Stroke{LineWidth: 2, LineCap: gfx.SquareCap}, 24, 1},
+		{"round", line, gfx.Stroke{LineWidth: 2, LineCap: gfx.RoundCap}, 20 + math.Pi, 1},
+		{"triangle", line, gfx.Stroke{LineWidth: 2, LineCap: gfx.TriangleCap}, 22, 1},
+		{"miter", corner, gfx.Stroke{LineWidth: 2, LineJoin: gfx.MiterJoin}, 40, 1},
+		{"bevel", corner, gfx.Stroke{LineWidth: 2, LineJoin: gfx.BevelJoin}, 39.5, 1},
+		{"round join", corner, gfx.Stroke{LineWidth: 2, LineJoin: gfx.RoundJoin}, 39 + math.Pi/4, 1},
+		{"closed", square(0, 0, 10, true), gfx.Stroke{LineWidth: 2, LineJoin: gfx.MiterJoin}, 80, 2},
+		{"dashes", line, gfx.Stroke{LineWidth: 2, Dashes: []float64{2, 2}}, 12, 3},
+		{"dash phase", line, gfx.Stroke{LineWidth: 2, Dashes: []float64{2, 2}, DashPhase: 1}, 10, 3},
+		{"odd dashes", line, gfx.Stroke{LineWidth: 2, Dashes: []float64{3}}, 12, 2},
+	}
+	for _, tt := range tests {
+		outline := tt.path.StrokeOutline(&tt.stroke, 1e-3)
+		if got := polygonArea(outline); math.Abs(got-tt.area) > 1e-2 {
+			t.Errorf("%s: area = %v, want %v", tt.name, got, tt.area)
+		}
+		if got := countContours(outline); got != tt.contours {
+			t.Errorf("%s: %d contours, want %d", tt.name, got, tt.contours)
+		}
+	}
+
+	// the miter of a sharp turn reaches far past the corner unless the
+	// miter limit cuts it to a bevel
+	long := spike.StrokeOutline(&gfx.Stroke{LineWidth: 2, LineJoin: gfx.MiterJoin, MiterLimit: 100}, 1e-3)
+	short := spike.StrokeOutline(&gfx.Stroke{LineWidth: 2, LineJoin: gfx.MiterJoin}, 1e-3)
+	if b := long.Bounds(); b.X.Max < 20 {
+		t.Errorf("miter: bounds = %v, want a long tip", b)
+	}
+	if b := short.Bounds(); b.X.Max > 11.1 {
+		t.Errorf("limited miter: bounds = %v, want a bevel", b)
+	}
+}
+
+func TestOffset(t *testing.T) {
+	tests := []struct {
+		name     string
+		distance float64
+		join     gfx.LineJoin
+		area     float64
+		contours int
+	}{
+		{"outset miter", 1, gfx.MiterJoin, 144, 1},
+		{"outset bevel", 1, gfx.BevelJoin, 142, 1},
+		{"outset round", 1, gfx.RoundJoin, 140 + math.Pi, 1},
+		{"inset", -1, gfx.MiterJoin, 64, 1},
+		{"vanish", -6, gfx.MiterJoin, 0, 0},
+	}
+	for _, tt := range tests {
+		offset := square(0, 0, 10, false).Offset(tt.distance, tt.join)
+		if got := polygonArea(offset); math.Abs(got-tt.area) > 1e-2 {
+			t.Errorf("%s: area = %v, want %v", tt.name, got, tt.area)
+		}
+		if got := countContours(offset); got != tt.contours {
+			t.Errorf("%s: %d contours, want %d", tt.name, got, tt.contours)
+		}
+	}
+}
+
+// pathFlattener collects what a flattener receives as a path.
+type pathFlattener struct{ path *gfx.Path }
+
+func (f pathFlattener) MoveTo(x, y float64) { f.path.MoveTo(x, y) }
+func (f pathFlattener) LineTo(x, y float64) { f.path.LineTo(x, y) }
+func (f pathFlattener) LineJoin()           {}
+func (f pathFlattener) Close()              { f.path.Close() }
+func (f pathFlattener) End()                {}
+
+// strokeLines strokes a path through a LineStroker with a half line width
+// of 1 and returns the filled outline.
+func strokeLines(path *gfx.Path, cap gfx.LineCap, join gfx.LineJoin, limit float64) *gfx.Path {
+	f := pathFlattener{new(gfx.Path)}
+	stroker := gfx.NewLineStroker(cap, join, f)
+	stroker.HalfLineWidth, stroker.MiterLimit, stroker.Tolerance = 1, limit, 1e-3
+	gfx.Flatten(path, stroker, 1)
+	return f.path.Union(new(gfx.Path), gfx.FillRuleWinding)
+}
+
+func TestLineStroker(t *testing.T) {
+	line := new(gfx.Path)
+	line.MoveTo(0, 0)
+	line.LineTo(10, 0)
+
+	corner := new(gfx.Path)
+	corner.MoveTo(0, 0)
+	corner.LineTo(10, 0)
+	corner.LineTo(10, 10)
+
+	// two subpaths far enough apart not to overlap
+	both := new(gfx.Path)
+	both.MoveTo(0, 0)
+	both.LineTo(10, 0)
+	both.MoveTo(0, 10)
+	both.LineTo(10, 10)
+
+	tests := []struct {
+		name     string
+		path     *gfx.Path
+		cap      gfx.LineCap
+		join     gfx.LineJoin
+		area     float64
+		contours int
+	}{
+		{"butt", line, gfx.ButtCap, gfx.MiterJoin, 20, 1},
+		{"square", line, gfx.SquareCap, gfx.MiterJoin, 24, 1},
+		{"round", line, gfx.RoundCap, gfx.MiterJoin, 20 + math.Pi, 1},
+		{"triangle", line, gfx.TriangleCap, gfx.MiterJoin, 22, 1},
+		{"miter", corner, gfx.ButtCap, gfx.MiterJoin, 40, 1},
+		{"bevel", corner, gfx.ButtCap, gfx.BevelJoin, 39.5, 1},
+		{"round join", corner, gfx.ButtCap, gfx.RoundJoin, 39 + math.Pi/4, 1},
+		{"closed", square(0, 0, 10, true), gfx.RoundCap, gfx.MiterJoin, 80, 2},
+		{"subpaths", both, gfx.ButtCap, gfx.MiterJoin, 40, 2},
+	}
+	for _, tt := range tests {
+		outline := strokeLines(tt.path, tt.cap, tt.join, gfx.DefaultMiterLimit)
+		if got := polygonArea(outline); math.Abs(got-tt.area) > 1e-2 {
+			t.Errorf("%s: area = %v, want %v", tt.name, got, tt.area)
+		}
+		if got := countContours(outline); got != tt.contours {
+			t.Errorf("%s: %d contours, want %d", tt.name, got, tt.contours)
+		}
+	}
+
+	// the miter of a sharp turn reaches far past the corner unless the
+	// miter limit cuts it to a bevel
+	spike := new(gfx.Path)
+	spike.MoveTo(0, 0)
+	spike.LineTo(10, 0)
+	spike.LineTo(0, 1)
+	if b := strokeLines(spike, gfx.ButtCap, gfx.MiterJoin, 100).Bounds(); b.X.Max < 20 {
+		t.Errorf("miter: bounds = %v, want a long tip", b)
+	}
+	if b := strokeLines(spike, gfx.ButtCap, gfx.MiterJoin, gfx.DefaultMiterLimit).Bounds(); b.X.Max > 11.1 {
+		t.Errorf("limited miter: bounds = %v, want a bevel", b)
+	}
+}
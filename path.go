@@ -239,58 +239,220 @@ func (p *Path) ApproxBounds() Rect {
 	return MakeRect(minx, miny, maxx, maxy)
 }
 
+// Bounds returns the exact bounds of p, taking in the extremes of its
+// curves rather than their control points.
 func (p *Path) Bounds() Rect {
-	var minx, maxx, miny, maxy float64
-	minx, miny = math.Inf(1), math.Inf(1)
-	maxx, maxy = math.Inf(-1), math.Inf(-1)
-
-	var x0, y0, x1, y1, cx0, cy0, cx1, cy1, ax, ay, bx, by float64
+	minx, miny := math.Inf(1), math.Inf(1)
+	maxx, maxy := math.Inf(-1), math.Inf(-1)
+	add := func(r Rect) {
+		minx, maxx = math.Min(minx, r.X.Min), math.Max(maxx, r.X.Max)
+		miny, maxy = math.Min(miny, r.Y.Min), math.Max(maxy, r.Y.Max)
+	}
 
-	x0, ax = math.Inf(1), math.Inf(1)
-	y0, ay = math.Inf(-1), math.Inf(-1)
+	var start, last Point
 	for i, j := 0, 0; i < len(p.Components); i++ {
 		cmd := p.Components[i]
 		switch cmd {
 		case MoveToComp, LineToComp:
-			x1, y1 = p.Points[j].X, p.Points[j].Y
-			if math.IsInf(x0, 1) {
-				x0 = x1
-			}
-			if math.IsInf(y0, -1) {
-				y0 = y1
+			last = p.Points[j]
+			if cmd == MoveToComp {
+				start = last
 			}
-			ax = math.Min(x0, x1)
-			bx = math.Max(x0, x1)
-			ay = math.Min(y0, y1)
-			by = math.Min(y0, y1)
+			add(Rect{Range{last.X, last.X}, Range{last.Y, last.Y}})
+		case QuadCurveToComp:
+			// the same curve as a cubic
+			c, end := p.Points[j], p.Points[j+1]
+			c1, c2 := last.Add(c.Sub(last).Mul(2.0/3)), end.Add(c.Sub(end).Mul(2.0/3))
+			add(bezierBounds(last.X, last.Y, c1.X, c1.Y, c2.X, c2.Y, end.X, end.Y))
+			last = end
 		case CubicCurveToComp:
-			x1, y1 = p.Points[j+2].X, p.Points[j+2].Y
-			if math.IsInf(x0, 1) {
-				x0 = x1
+			c1, c2, end := p.Points[j], p.Points[j+1], p.Points[j+2]
+			add(bezierBounds(last.X, last.Y, c1.X, c1.Y, c2.X, c2.Y, end.X, end.Y))
+			last = end
+		case ClosePathComp:
+			last = start
+		}
+		j += cmd.PointCount()
+	}
+
+	return MakeRect(minx, miny, maxx, maxy)
+}
+
+// Transform applies m to the points of p in place.
+func (p *Path) Transform(m Matrix) {
+	for i, pt := range p.Points {
+		p.Points[i] = m.TransformPoint(pt)
+	}
+	p.x, p.y = m.TransformXY(p.x, p.y)
+}
+
+// Reverse returns p with each subpath running the other way, the subpaths
+// staying in order. An open subpath starts where it ended; a closed one
+// keeps its start and stays closed, with no line drawn back to the start
+// that the close already draws.
+func (p *Path) Reverse() *Path {
+	out := new(Path)
+	for _, sub := range splitSubpaths(p) {
+		segments := sub.closedSegments(false)
+		if sub.closed {
+			out.MoveTo(sub.start.X, sub.start.Y)
+		} else {
+			end := sub.end()
+			out.MoveTo(end.X, end.Y)
+		}
+		for i := len(segments) - 1; i >= 0; i-- {
+			seg := segments[i]
+			if i == 0 && sub.closed && len(seg) == 2 {
+				break
 			}
-			if math.IsInf(y0, -1) {
-				y0 = y1
+			reversed := make([]Point, len(seg))
+			for k, pt := range seg {
+				reversed[len(seg)-1-k] = pt
 			}
+			out.appendSegment(reversed)
+		}
+		if sub.closed {
+			out.Close()
+		}
+	}
+	return out
+}
 
-			cx0, cy0 = p.Points[j].X, p.Points[j].Y
-			cx1, cy1 = p.Points[j+1].X, p.Points[j+1].Y
+// Subpaths returns each subpath of p as a path of its own.
+func (p *Path) Subpaths() []*Path {
+	subpaths := splitSubpaths(p)
+	paths := make([]*Path, len(subpaths))
+	for i, sub := range subpaths {
+		paths[i] = new(Path)
+		sub.appendTo(paths[i])
+	}
+	return paths
+}
+
+// pathSubpath is a subpath given by its start and its line, quadratic and
+// cubic segments, each as its control points from its start to its end.
+type pathSubpath struct {
+	start    Point
+	segments [][]Point
+	closed   bool
+}
 
-			bounds := bezierBounds(x0, y0, cx0, cy0, cx1, cy1, x1, y1)
-			ax = bounds.X.Min
-			ay = bounds.Y.Min
-			bx = bounds.X.Max
-			by = bounds.Y.Max
+// splitSubpaths breaks p into subpaths. Segments following a close without
+// a moveto start a subpath where the closed one started. Walking the
+// segments of the subpaths is how paths are measured, hit tested, flattened
+// and rebuilt.
+func splitSubpaths(p *Path) (subpaths []pathSubpath) {
+	var last Point
+	open := false
+	for i, j := 0, 0; i < len(p.Components); i++ {
+		cmd := p.Components[i]
+		switch cmd {
+		case MoveToComp:
+			subpaths = append(subpaths, pathSubpath{start: p.Points[j]})
+			last, open = p.Points[j], true
+		case LineToComp, QuadCurveToComp, CubicCurveToComp:
+			if !open {
+				subpaths = append(subpaths, pathSubpath{start: last})
+				open = true
+			}
+			sub := &subpaths[len(subpaths)-1]
+			sub.segments = append(sub.segments, append([]Point{last}, p.Points[j:j+cmd.PointCount()]...))
+			last = p.Points[j+cmd.PointCount()-1]
+		case ClosePathComp:
+			if open {
+				sub := &subpaths[len(subpaths)-1]
+				sub.closed = true
+				last, open = sub.start, false
+			}
 		}
+		j += cmd.PointCount()
+	}
+	return
+}
+
+// end returns the point the subpath's last segment ends at.
+func (s pathSubpath) end() Point {
+	if n := len(s.segments); n > 0 {
+		return s.segments[n-1][len(s.segments[n-1])-1]
+	}
+	return s.start
+}
 
-		x0 = x1
-		y0 = y1
+// closedSegments returns the segments of the subpath followed by the line
+// back to its start, if it is closed or closeAll is set and it does not end
+// there already.
+func (s pathSubpath) closedSegments(closeAll bool) [][]Point {
+	if end := s.end(); (s.closed || closeAll) && end != s.start {
+		return append(s.segments[:len(s.segments):len(s.segments)], []Point{end, s.start})
+	}
+	return s.segments
+}
 
-		minx = math.Min(minx, ax)
-		maxx = math.Max(maxx, bx)
-		miny = math.Min(miny, ay)
-		maxy = math.Max(maxy, by)
-		j += cmd.PointCount()
+func (s pathSubpath) appendTo(out *Path) {
+	out.MoveTo(s.start.X, s.start.Y)
+	for _, seg := range s.segments {
+		out.appendSegment(seg)
 	}
+	if s.closed {
+		out.Close()
+	}
+}
 
-	return MakeRect(minx, miny, maxx, maxy)
+// appendSegment adds a line, quadratic or cubic segment given by its
+// control points, the first of which is the current point.
+func (p *Path) appendSegment(points []Point) {
+	switch len(points) {
+	case 2:
+		p.LineTo(points[1].X, points[1].Y)
+	case 3:
+		p.QuadCurveTo(points[1].X, points[1].Y, points[2].X, points[2].Y)
+	case 4:
+		p.CubicCurveTo(points[1].X, points[1].Y, points[2].X, points[2].Y, points[3].X, points[3].Y)
+	}
+}
+
+// SignedArea returns the area enclosed by p with every subpath taken to be
+// closed, positive where it runs counter-clockwise in y-up coordinates and
+// negative where it runs clockwise. Curves are integrated exactly.
+func (p *Path) SignedArea() (area float64) {
+	for _, sub := range splitSubpaths(p) {
+		for _, points := range sub.closedSegments(true) {
+			if len(points) == 2 {
+				area += points[0].Cross(points[1]) / 2
+				continue
+			}
+			// the integrand is a polynomial of degree at most five,
+			// which the quadrature takes exactly
+			for _, node := range gaussLegendre {
+				t := (node[0] + 1) / 2
+				area += node[1] / 4 * bezierPoint(points, t).Cross(bezierDerivative(points, t))
+			}
+		}
+	}
+	return
+}
+
+// IsClockwise reports whether p runs clockwise in y-up coordinates, taking
+// its subpaths together by their signed area.
+func (p *Path) IsClockwise() bool {
+	return p.SignedArea() < 0
+}
+
+// Append adds the components of other to p. If connect is set, other's
+// first subpath continues the current one with a line to its start instead
+// of a moveto.
+func (p *Path) Append(other *Path, connect bool) {
+	if len(other.Components) == 0 {
+		return
+	}
+	components, points := other.Components, other.Points
+	if connect && len(p.Components) > 0 && components[0] == MoveToComp {
+		if points[0].X != p.x || points[0].Y != p.y {
+			p.appendToPath(LineToComp, points[0])
+		}
+		components, points = components[1:], points[1:]
+	}
+	p.Components = append(p.Components, components...)
+	p.Points = append(p.Points, points...)
+	p.x, p.y = other.x, other.y
 }
@@ -0,0 +1,114 @@
+package gfx_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+func TestPathBounds(t *testing.T) {
+	p, err := gfx.ParseSVGPath("M0 0Q5 10 10 0L10-2C12-2 12-6 10-6Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := p.Bounds()
+	want := gfx.MakeRect(0, -6, 11.5, 5)
+	if math.Abs(b.X.Min-want.X.Min) > 1e-9 || math.Abs(b.X.Max-want.X.Max) > 1e-9 ||
+		math.Abs(b.Y.Min-want.Y.Min) > 1e-9 || math.Abs(b.Y.Max-want.Y.Max) > 1e-9 {
+		t.Errorf("Bounds = %v, want %v", b, want)
+	}
+}
+
+func TestPathTransform(t *testing.T) {
+	p, _ := gfx.ParseSVGPath("M0 0 1 0Q1 1 0 1")
+	p.Transform(gfx.NewTranslationMatrix(2, 3))
+	if got, want := p.SVG(), "M2 3 3 3Q3 4 2 4"; got != want {
+		t.Errorf("translated path = %q, want %q", got, want)
+	}
+	if x, y := p.LastPoint(); x != 2 || y != 4 {
+		t.Errorf("translated last point = %v, %v", x, y)
+	}
+}
+
+func TestPathReverse(t *testing.T) {
+	tests := []struct{ path, want string }{
+		{"M0 0 1 0C2 0 2 1 1 1", "M1 1C2 1 2 0 1 0L0 0"},
+		{"M0 0 1 0 1 1Z", "M0 0 1 1 1 0Z"},
+		{"M0 0 1 0 1 1 0 0Z", "M0 0 1 1 1 0Z"},
+		{"M0 0Q1 1 2 0ZM5 5 6 6", "M0 0 2 0Q1 1 0 0ZM6 6 5 5"},
+	}
+	for _, tt := range tests {
+		p, err := gfx.ParseSVGPath(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := p.Reverse().SVG(); got != tt.want {
+			t.Errorf("%q reversed = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestPathSubpaths(t *testing.T) {
+	p, _ := gfx.ParseSVGPath("M0 0 1 0ZL2 2M5 5Q6 6 7 5")
+	var got []string
+	for _, sub := range p.Subpaths() {
+		got = append(got, sub.SVG())
+	}
+	want := []string{"M0 0 1 0Z", "M0 0 2 2", "M5 5Q6 6 7 5"}
+	if len(got) != len(want) {
+		t.Fatalf("Subpaths = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Subpaths = %q, want %q", got, want)
+			break
+		}
+	}
+}
+
+func TestPathSignedArea(t *testing.T) {
+	// squares both ways, the area under the parabola y = 1 - x*x traced
+	// clockwise, and two squares cancelling out
+	tests := []struct {
+		path string
+		area float64
+	}{
+		{"M0 0 1 0 1 1 0 1Z", 1},
+		{"M0 0 0 1 1 1 1 0", -1},
+		{"M-1 0Q0 2 1 0", -4.0 / 3},
+		{"M0 0 1 0 1 1 0 1ZM2 0 2 1 3 1 3 0Z", 0},
+	}
+	for _, tt := range tests {
+		p, _ := gfx.ParseSVGPath(tt.path)
+		if a := p.SignedArea(); math.Abs(a-tt.area) > 1e-12 {
+			t.Errorf("%q: SignedArea = %v, want %v", tt.path, a, tt.area)
+		}
+		if p.IsClockwise() != (tt.area < 0) {
+			t.Errorf("%q: IsClockwise = %v", tt.path, p.IsClockwise())
+		}
+	}
+
+	c := new(gfx.Path)
+	c.Circle(0, 0, 1)
+	if a := c.SignedArea(); math.Abs(a-math.Pi) > 1e-3 {
+		t.Errorf("circle area = %v", a)
+	}
+}
+
+func TestPathAppend(t *testing.T) {
+	a, _ := gfx.ParseSVGPath("M0 0 1 0")
+	b, _ := gfx.ParseSVGPath("M2 0 3 0")
+	c := a.Copy()
+	c.Append(b, false)
+	if got, want := c.SVG(), "M0 0 1 0M2 0 3 0"; got != want {
+		t.Errorf("Append = %q, want %q", got, want)
+	}
+	a.Append(b, true)
+	if got, want := a.SVG(), "M0 0 1 0 2 0 3 0"; got != want {
+		t.Errorf("connected Append = %q, want %q", got, want)
+	}
+	if x, y := a.LastPoint(); x != 3 || y != 0 {
+		t.Errorf("last point after Append = %v, %v", x, y)
+	}
+}
@@ -0,0 +1,545 @@
+package gfx
+
+import (
+	"math"
+	"sort"
+)
+
+// PathOp is a boolean operation on the areas of two paths.
+type PathOp int
+
+const (
+	PathUnion PathOp = iota
+	PathIntersection
+	PathDifference
+	PathXor
+)
+
+// Union returns the area covered by p or o, filling both with rule.
+func (p *Path) Union(o *Path, rule FillRule) *Path {
+	return BooleanPaths(p, o, PathUnion, rule, 0)
+}
+
+// Intersection returns the area covered by both p and o.
+func (p *Path) Intersection(o *Path, rule FillRule) *Path {
+	return BooleanPaths(p, o, PathIntersection, rule, 0)
+}
+
+// Difference returns the area covered by p but not by o.
+func (p *Path) Difference(o *Path, rule FillRule) *Path {
+	return BooleanPaths(p, o, PathDifference, rule, 0)
+}
+
+// Xor returns the area covered by exactly one of p and o.
+func (p *Path) Xor(o *Path, rule FillRule) *Path {
+	return BooleanPaths(p, o, PathXor, rule, 0)
+}
+
+// BooleanPaths combines the areas of a and b, each filled with rule. Curves
+// are flattened to within tolerance; zero picks a tolerance from the size of
+// the paths. Every subpath is taken to be closed, as when filling.
+//
+// The edges of both paths are split at all their intersections, and the
+// winding numbers of a and b on either side of each edge are found by ray
+// casting. Edges where the result changes between inside and outside are
+// chained into closed contours with the inside on their left, so outer
+// contours run counter-clockwise and holes clockwise in y-up coordinates.
+// The result has the same area under either fill rule.
+func BooleanPaths(a, b *Path, op PathOp, rule FillRule, tolerance float64) *Path {
+	bounds := controlBounds(a, b)
+	scale := 1.0
+	if !bounds.IsEmpty() {
+		scale = math.Max(scale, math.Max(math.Abs(bounds.X.Min), math.Abs(bounds.X.Max)))
+		scale = math.Max(scale, math.Max(math.Abs(bounds.Y.Min), math.Abs(bounds.Y.Max)))
+	}
+	if tolerance <= 0 {
+		tolerance = defaultTolerance(bounds)
+	}
+
+	g := newPathGraph(scale * 1e-9)
+	g.addPath(a, 0, tolerance)
+	g.addPath(b, 1, tolerance)
+	g.split()
+
+	inside := func(w int) bool {
+		if rule == FillRuleWinding {
+			return w != 0
+		}
+		return w%2 != 0
+	}
+	result := func(w [2]int) bool {
+		in, ob := inside(w[0]), inside(w[1])
+		switch op {
+		case PathUnion:
+			return in || ob
+		case PathIntersection:
+			return in && ob
+		case PathDifference:
+			return in && !ob
+		default:
+			return in != ob
+		}
+	}
+
+	windings := g.windings()
+	var boundary [][2]int
+	for i, e := range g.edges {
+		left, right := windings[2*i], windings[2*i+1]
+		switch inLeft, inRight := result(left), result(right); {
+		case inLeft && !inRight:
+			boundary = append(boundary, [2]int{e.u, e.v})
+		case inRight && !inLeft:
+			boundary = append(boundary, [2]int{e.v, e.u})
+		}
+	}
+
+	path := new(Path)
+	for _, contour := range g.chain(boundary) {
+		path.MoveTo(contour[0].X, contour[0].Y)
+		for _, pt := range contour[1:] {
+			path.LineTo(pt.X, pt.Y)
+		}
+		path.Close()
+	}
+	return path
+}
+
+// controlBounds returns the bounds of the points of the paths, control points
+// included. Unlike ApproxBounds it is empty for empty paths.
+func controlBounds(paths ...*Path) Rect {
+	bounds := EmptyRect()
+	for _, p := range paths {
+		for _, pt := range p.Points {
+			bounds = bounds.Union(Rect{Range{pt.X, pt.X}, Range{pt.Y, pt.Y}})
+		}
+	}
+	return bounds
+}
+
+// defaultTolerance returns a flattening tolerance in proportion to bounds.
+func defaultTolerance(bounds Rect) float64 {
+	size := 1.0
+	if !bounds.IsEmpty() {
+		size = math.Max(bounds.Width(), bounds.Height())
+	}
+	return math.Max(size*1e-4, 1e-9)
+}
+
+// polyline is a subpath with its curves flattened.
+type polyline struct {
+	points []Point
+	closed bool
+}
+
+// flattenPolylines returns the subpaths of p with curves flattened to within
+// tolerance.
+func flattenPolylines(p *Path, tolerance float64) (lines []polyline) {
+	threshold := tolerance * tolerance
+	for _, sub := range splitSubpaths(p) {
+		points := pointLiner{sub.start}
+		for _, seg := range sub.segments {
+			switch len(seg) {
+			case 2:
+				points.LineTo(seg[1].X, seg[1].Y)
+			case 3:
+				TraceQuad(&points, seg, threshold)
+			case 4:
+				TraceCubic(&points, seg, threshold)
+			}
+		}
+		lines = append(lines, polyline{points: points, closed: sub.closed})
+	}
+	return
+}
+
+// flattenPath returns the subpaths of p as polygons, with curves flattened to
+// within tolerance.
+func flattenPath(p *Path, tolerance float64) (polygons [][]Point) {
+	for _, line := range flattenPolylines(p, tolerance) {
+		if len(line.points) >= 3 {
+			polygons = append(polygons, line.points)
+		}
+	}
+	return
+}
+
+// pointLiner collects the points it is given.
+type pointLiner []Point
+
+func (l *pointLiner) LineTo(x, y float64) {
+	*l = append(*l, Point{x, y})
+}
+
+// pathGraph is the planar arrangement of the edges of two paths.
+type pathGraph struct {
+	eps      float64
+	vertices []Point
+	grid     map[[2]int64][]int
+	segments []pathSegment
+	edges    []pathEdge
+}
+
+// pathSegment is an input edge of path owner, before splitting.
+type pathSegment struct {
+	a, b  Point
+	owner int
+	cuts  []Point
+}
+
+// pathEdge is an edge between vertices u < v of the arrangement. count
+// holds, per path, how many input edges run from u to v less how many run
+// from v to u.
+type pathEdge struct {
+	u, v  int
+	count [2]int
+}
+
+func newPathGraph(eps float64) *pathGraph {
+	return &pathGraph{eps: eps, grid: make(map[[2]int64][]int)}
+}
+
+func (g *pathGraph) addPath(p *Path, owner int, tolerance float64) {
+	for _, poly := range flattenPath(p, tolerance) {
+		for i, a := range poly {
+			b := poly[(i+1)%len(poly)]
+			if a != b {
+				g.segments = append(g.segments, pathSegment{a: a, b: b, owner: owner})
+			}
+		}
+	}
+}
+
+// vertex returns the id of the vertex within eps of p, adding one if there
+// is none.
+func (g *pathGraph) vertex(p Point) int {
+	cell := [2]int64{int64(math.Floor(p.X / g.eps)), int64(math.Floor(p.Y / g.eps))}
+	for dx := int64(-1); dx <= 1; dx++ {
+		for dy := int64(-1); dy <= 1; dy++ {
+			for _, id := range g.grid[[2]int64{cell[0] + dx, cell[1] + dy}] {
+				if g.vertices[id].DistanceTo(p) <= g.eps {
+					return id
+				}
+			}
+		}
+	}
+
+	id := len(g.vertices)
+	g.vertices = append(g.vertices, p)
+	g.grid[cell] = append(g.grid[cell], id)
+	return id
+}
+
+// split cuts the segments at every point where they meet and collects the
+// resulting edges.
+func (g *pathGraph) split() {
+	rects := make(Rects, len(g.segments))
+	for i, s := range g.segments {
+		rects[i] = MakeRect(s.a.X, s.a.Y, s.b.X, s.b.Y).ExpandedByMargin(g.eps)
+	}
+	index := rects.Index()
+
+	for i := range g.segments {
+		for _, j := range index.Intersecting(rects[i]) {
+			if j > i {
+				g.cut(i, j)
+			}
+		}
+	}
+
+	edges := make(map[[2]int]int)
+	for _, s := range g.segments {
+		d := s.b.Sub(s.a)
+		cuts := append([]Point{s.a, s.b}, s.cuts...)
+		sort.Slice(cuts, func(i, j int) bool { return cuts[i].Sub(s.a).Dot(d) < cuts[j].Sub(s.a).Dot(d) })
+
+		prev := g.vertex(cuts[0])
+		for _, c := range cuts[1:] {
+			next := g.vertex(c)
+			if next == prev {
+				continue
+			}
+
+			key, dir := [2]int{prev, next}, 1
+			if next < prev {
+				key, dir = [2]int{next, prev}, -1
+			}
+			k, ok := edges[key]
+			if !ok {
+				k = len(g.edges)
+				edges[key] = k
+				g.edges = append(g.edges, pathEdge{u: key[0], v: key[1]})
+			}
+			g.edges[k].count[s.owner] += dir
+			prev = next
+		}
+	}
+
+	// edges that cancel out bound nothing
+	kept := g.edges[:0]
+	for _, e := range g.edges {
+		if e.count != [2]int{} {
+			kept = append(kept, e)
+		}
+	}
+	g.edges = kept
+}
+
+// cut records where segments i and j meet: endpoints of either lying on the
+// other, and the crossing point of segments that properly cross.
+func (g *pathGraph) cut(i, j int) {
+	s, t := &g.segments[i], &g.segments[j]
+
+	for _, p := range []Point{t.a, t.b} {
+		if pointSegmentDistance(p, s.a, s.b) <= g.eps {
+			s.cuts = append(s.cuts, p)
+		}
+	}
+	for _, p := range []Point{s.a, s.b} {
+		if pointSegmentDistance(p, t.a, t.b) <= g.eps {
+			t.cuts = append(t.cuts, p)
+		}
+	}
+
+	// a proper crossing needs the ends of each segment clearly on opposite
+	// sides of the other; anything closer was caught above
+	r, q := s.b.Sub(s.a), t.b.Sub(t.a)
+	side := func(d, o, p Point) float64 {
+		return d.Cross(p.Sub(o)) / d.Norm()
+	}
+	ta, tb := side(r, s.a, t.a), side(r, s.a, t.b)
+	sa, sb := side(q, t.a, s.a), side(q, t.a, s.b)
+	if math.Abs(ta) <= g.eps || math.Abs(tb) <= g.eps || math.Abs(sa) <= g.eps || math.Abs(sb) <= g.eps ||
+		(ta > 0) == (tb > 0) || (sa > 0) == (sb > 0) {
+		return
+	}
+	p := s.a.Add(r.Mul(sa / (sa - sb)))
+	s.cuts = append(s.cuts, p)
+	t.cuts = append(t.cuts, p)
+}
+
+// windings returns the winding numbers of both paths in the face left of
+// each half-edge, where half-edge 2i runs along edge i from u to v and 2i+1
+// back. Faces are traced by turning clockwise at every vertex, and windings
+// spread from face to face, crossing an edge from right to left adding its
+// count. A ray cast along +x from the rightmost vertex of each connected
+// piece of the arrangement, counting crossings of the other pieces with the
+// half-open rule, fixes the winding of the piece's outer face.
+func (g *pathGraph) windings() [][2]int {
+	n := 2 * len(g.edges)
+	from := func(h int) int {
+		if h%2 == 0 {
+			return g.edges[h/2].u
+		}
+		return g.edges[h/2].v
+	}
+	to := func(h int) int { return from(h ^ 1) }
+	count := func(h int) [2]int {
+		c := g.edges[h/2].count
+		if h%2 == 1 {
+			c = [2]int{-c[0], -c[1]}
+		}
+		return c
+	}
+	angle := func(h int) float64 {
+		d := g.vertices[to(h)].Sub(g.vertices[from(h)])
+		return math.Atan2(d.Y, d.X)
+	}
+
+	out := make([][]int, len(g.vertices))
+	for h := 0; h < n; h++ {
+		out[from(h)] = append(out[from(h)], h)
+	}
+	pos := make([]int, n)
+	for _, hs := range out {
+		sort.Slice(hs, func(i, j int) bool { return angle(hs[i]) < angle(hs[j]) })
+		for i, h := range hs {
+			pos[h] = i
+		}
+	}
+	next := func(h int) int {
+		hs := out[to(h)]
+		return hs[(pos[h^1]+len(hs)-1)%len(hs)]
+	}
+
+	face := make([]int, n)
+	for h := range face {
+		face[h] = -1
+	}
+	var faces [][]int
+	var areas []float64
+	for h := range face {
+		if face[h] >= 0 {
+			continue
+		}
+		var hs []int
+		var area float64
+		for k := h; face[k] < 0; k = next(k) {
+			face[k] = len(faces)
+			hs = append(hs, k)
+			area += g.vertices[from(k)].Cross(g.vertices[to(k)])
+		}
+		faces, areas = append(faces, hs), append(areas, area)
+	}
+
+	wind := make([][2]int, len(faces))
+	piece := make([]int, len(faces))
+	for f := range piece {
+		piece[f] = -1
+	}
+	var pieces [][]int
+	for f := range faces {
+		if piece[f] >= 0 {
+			continue
+		}
+		piece[f] = len(pieces)
+		members := []int{f}
+		for i := 0; i < len(members); i++ {
+			q := members[i]
+			for _, h := range faces[q] {
+				if r := face[h^1]; piece[r] < 0 {
+					piece[r] = piece[f]
+					c := count(h)
+					wind[r] = [2]int{wind[q][0] - c[0], wind[q][1] - c[1]}
+					members = append(members, r)
+				}
+			}
+		}
+		pieces = append(pieces, members)
+	}
+
+	ys := make([]Range, len(g.edges))
+	for i, e := range g.edges {
+		a, b := g.vertices[e.u], g.vertices[e.v]
+		ys[i] = MakeRange(math.Min(a.Y, b.Y), math.Max(a.Y, b.Y))
+	}
+	index := NewIntervalTree(ys)
+
+	for id, members := range pieces {
+		// the outer face is the one traced clockwise
+		outer, right := members[0], -1
+		for _, f := range members {
+			if areas[f] < areas[outer] {
+				outer = f
+			}
+			for _, h := range faces[f] {
+				if v := from(h); right < 0 || g.vertices[v].X > g.vertices[right].X {
+					right = v
+				}
+			}
+		}
+
+		m := g.vertices[right]
+		var w [2]int
+		for _, k := range index.Stab(m.Y) {
+			if piece[face[2*k]] == id {
+				continue
+			}
+			e := g.edges[k]
+			p, q := g.vertices[e.u], g.vertices[e.v]
+			if (p.Y > m.Y) == (q.Y > m.Y) {
+				continue
+			}
+			if x := p.X + (m.Y-p.Y)*(q.X-p.X)/(q.Y-p.Y); x > m.X {
+				sign := -1
+				if q.Y > p.Y {
+					sign = 1
+				}
+				w[0] += sign * e.count[0]
+				w[1] += sign * e.count[1]
+			}
+		}
+
+		shift := [2]int{w[0] - wind[outer][0], w[1] - wind[outer][1]}
+		for _, f := range members {
+			wind[f] = [2]int{wind[f][0] + shift[0], wind[f][1] + shift[1]}
+		}
+	}
+
+	windings := make([][2]int, n)
+	for h := range windings {
+		windings[h] = wind[face[h]]
+	}
+	return windings
+}
+
+// chain links directed boundary edges into closed contours. Where several
+// edges leave a vertex the sharpest left turn is taken, keeping to the region
+// on the left, so regions touching at a corner stay apart. Points along
+// straight runs are dropped.
+func (g *pathGraph) chain(edges [][2]int) (contours [][]Point) {
+	out := make(map[int][]int)
+	for i, e := range edges {
+		out[e[0]] = append(out[e[0]], i)
+	}
+	used := make([]bool, len(edges))
+
+	for start := range edges {
+		if used[start] {
+			continue
+		}
+
+		var ids []int
+		for e := start; e >= 0 && !used[e]; {
+			used[e] = true
+			ids = append(ids, edges[e][0])
+			from, at := g.vertices[edges[e][0]], edges[e][1]
+			if at == edges[start][0] {
+				break
+			}
+
+			din := g.vertices[at].Sub(from)
+			next, best := -1, math.Inf(-1)
+			for _, k := range out[at] {
+				if used[k] {
+					continue
+				}
+				dout := g.vertices[edges[k][1]].Sub(g.vertices[at])
+				// counter-clockwise angle from the incoming direction, with
+				// turning straight back counted as the sharpest right turn
+				turn := math.Atan2(din.Cross(dout), din.Dot(dout))
+				if turn >= math.Pi-Epsilon {
+					turn = -math.Pi
+				}
+				if turn > best {
+					next, best = k, turn
+				}
+			}
+			e = next
+		}
+
+		if contour := g.simplify(ids); len(contour) >= 3 {
+			contours = append(contours, contour)
+		}
+	}
+	return
+}
+
+// simplify returns the points of a closed contour without those lying on
+// the straight line between their neighbours.
+func (g *pathGraph) simplify(ids []int) (points []Point) {
+	n := len(ids)
+	for i, id := range ids {
+		prev, p, next := g.vertices[ids[(i+n-1)%n]], g.vertices[id], g.vertices[ids[(i+1)%n]]
+		a, b := p.Sub(prev), next.Sub(p)
+		if math.Abs(a.Cross(b)) <= g.eps*(a.Norm()+b.Norm()) && a.Dot(b) > 0 {
+			continue
+		}
+		points = append(points, p)
+	}
+	return
+}
+
+func pointSegmentDistance(p, a, b Point) float64 {
+	return p.DistanceTo(a.Add(b.Sub(a).Mul(projectParam(a, b, p))))
+}
+
+// projectParam returns where pt projects onto the segment from a to b, as a
+// parameter clamped to [0, 1].
+func projectParam(a, b, pt Point) float64 {
+	d := b.Sub(a)
+	l := d.Dot(d)
+	if l == 0 {
+		return 0
+	}
+	return math.Max(0, math.Min(1, pt.Sub(a).Dot(d)/l))
+}
@@ -0,0 +1,118 @@
+package gfx_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+// polygonArea returns the signed area of a path made of straight segments.
+func polygonArea(p *gfx.Path) (area float64) {
+	var start, last gfx.Point
+	for i, j := 0, 0; i < len(p.Components); i++ {
+		switch p.Components[i] {
+		case gfx.MoveToComp:
+			start, last = p.Points[j], p.Points[j]
+		case gfx.LineToComp:
+			area += last.Cross(p.Points[j]) / 2
+			last = p.Points[j]
+		case gfx.ClosePathComp:
+			area += last.Cross(start) / 2
+			last = start
+		}
+		j += p.Components[i].PointCount()
+	}
+	return
+}
+
+func square(x, y, size float64, ccw bool) *gfx.Path {
+	p := new(gfx.Path)
+	p.MoveTo(x, y)
+	if ccw {
+		p.LineTo(x+size, y)
+		p.LineTo(x+size, y+size)
+		p.LineTo(x, y+size)
+	} else {
+		p.LineTo(x, y+size)
+		p.LineTo(x+size, y+size)
+		p.LineTo(x+size, y)
+	}
+	p.Close()
+	return p
+}
+
+func circle(cx, cy, r float64) *gfx.Path {
+	const k = 0.5522847498
+	p := new(gfx.Path)
+	p.MoveTo(cx+r, cy)
+	p.CubicCurveTo(cx+r, cy+k*r, cx+k*r, cy+r, cx, cy+r)
+	p.CubicCurveTo(cx-k*r, cy+r, cx-r, cy+k*r, cx-r, cy)
+	p.CubicCurveTo(cx-r, cy-k*r, cx-k*r, cy-r, cx, cy-r)
+	p.CubicCurveTo(cx+k*r, cy-r, cx+r, cy-k*r, cx+r, cy)
+	p.Close()
+	return p
+}
+
+func countContours(p *gfx.Path) (n int) {
+	for _, c := range p.Components {
+		if c == gfx.MoveToComp {
+			n++
+		}
+	}
+	return
+}
+
+func TestBooleanPaths(t *testing.T) {
+	a, b := square(0, 0, 10, true), square(5, 5, 10, false)
+
+	tests := []struct {
+		name     string
+		result   *gfx.Path
+		area     float64
+		contours int
+	}{
+		{"union", a.Union(b, gfx.FillRuleWinding), 175, 1},
+		{"intersection", a.Intersection(b, gfx.FillRuleWinding), 25, 1},
+		{"difference", a.Difference(b, gfx.FillRuleEvenOdd), 75, 1},
+		{"xor", a.Xor(b, gfx.FillRuleEvenOdd), 150, 2},
+		{"disjoint union", a.Union(square(20, 0, 5, true), gfx.FillRuleWinding), 125, 2},
+		{"touching union", a.Union(square(10, 0, 10, true), gfx.FillRuleWinding), 200, 1},
+		{"corner union", a.Union(square(10, 10, 10, true), gfx.FillRuleWinding), 200, 2},
+		{"hole", a.Difference(square(2, 2, 6, true), gfx.FillRuleWinding), 64, 2},
+		{"same", a.Intersection(square(0, 0, 10, false), gfx.FillRuleWinding), 100, 1},
+		{"empty", a.Intersection(new(gfx.Path), gfx.FillRuleWinding), 0, 0},
+	}
+	for _, tt := range tests {
+		if got := polygonArea(tt.result); math.Abs(got-tt.area) > 1e-9 {
+			t.Errorf("%s: area = %v, want %v", tt.name, got, tt.area)
+		}
+		if got := countContours(tt.result); got != tt.contours {
+			t.Errorf("%s: %d contours, want %d", tt.name, got, tt.contours)
+		}
+	}
+
+	// a square drawn twice in the same direction overlapping itself keeps
+	// the overlap under the winding rule and drops it under even-odd
+	self := square(0, 0, 10, true)
+	self.MoveTo(5, 5)
+	self.LineTo(15, 5)
+	self.LineTo(15, 15)
+	self.LineTo(5, 15)
+	self.Close()
+	if got := polygonArea(self.Union(new(gfx.Path), gfx.FillRuleWinding)); math.Abs(got-175) > 1e-9 {
+		t.Errorf("winding self union: area = %v, want 175", got)
+	}
+	if got := polygonArea(self.Union(new(gfx.Path), gfx.FillRuleEvenOdd)); math.Abs(got-150) > 1e-9 {
+		t.Errorf("even-odd self union: area = %v, want 150", got)
+	}
+
+	// curves are flattened finely enough to keep the area of a circle
+	ring := circle(0, 0, 10).Difference(circle(0, 0, 5), gfx.FillRuleWinding)
+	if got, want := polygonArea(ring), math.Pi*75; math.Abs(got-want) > want*1e-3 {
+		t.Errorf("ring: area = %v, want %v", got, want)
+	}
+	if got := countContours(ring); got != 2 {
+		t.Errorf("ring: %d contours, want 2", got)
+	}
+}
@@ -0,0 +1,104 @@
+package gfx
+
+import "math"
+
+// CornerRadii holds the radius of each corner of a rounded rectangle, the
+// bottom being the side of smaller y.
+type CornerRadii struct {
+	BottomLeft  float64
+	BottomRight float64
+	TopRight    float64
+	TopLeft     float64
+}
+
+// MakeCornerRadii returns radii of r for all four corners.
+func MakeCornerRadii(r float64) CornerRadii {
+	return CornerRadii{r, r, r, r}
+}
+
+// RoundedRect adds rect with rounded corners as a closed subpath running
+// counter-clockwise in y-up coordinates. Negative radii count as zero, and
+// radii too large for the sides they share are all scaled down by the same
+// factor, as CSS does for border radii.
+func (p *Path) RoundedRect(rect Rect, radii CornerRadii) {
+	if rect.IsEmpty() {
+		return
+	}
+
+	bl, br := math.Max(radii.BottomLeft, 0), math.Max(radii.BottomRight, 0)
+	tr, tl := math.Max(radii.TopRight, 0), math.Max(radii.TopLeft, 0)
+	scale := 1.0
+	for _, side := range [4][3]float64{
+		{rect.Width(), bl, br},
+		{rect.Width(), tl, tr},
+		{rect.Height(), bl, tl},
+		{rect.Height(), br, tr},
+	} {
+		if sum := side[1] + side[2]; sum > side[0] {
+			scale = math.Min(scale, side[0]/sum)
+		}
+	}
+	bl, br, tr, tl = bl*scale, br*scale, tr*scale, tl*scale
+
+	x0, y0, x1, y1 := rect.X.Min, rect.Y.Min, rect.X.Max, rect.Y.Max
+	p.MoveTo(x0+bl, y0)
+	p.roundedCorner(Point{x1, y0}, br, Point{-1, 0}, Point{0, 1})
+	p.roundedCorner(Point{x1, y1}, tr, Point{0, -1}, Point{-1, 0})
+	p.roundedCorner(Point{x0, y1}, tl, Point{1, 0}, Point{0, -1})
+	p.roundedCorner(Point{x0, y0}, bl, Point{0, 1}, Point{1, 0})
+	p.Close()
+}
+
+// roundedCorner adds the side leading to corner and the arc of radius r
+// rounding it, with the arc starting r along in and ending r along out from
+// the corner.
+func (p *Path) roundedCorner(corner Point, r float64, in, out Point) {
+	from, to := corner.Add(in.Mul(r)), corner.Add(out.Mul(r))
+	if x, y := p.LastPoint(); x != from.X || y != from.Y {
+		p.LineTo(from.X, from.Y)
+	}
+	if r > 0 {
+		center := corner.Add(in.Add(out).Mul(r))
+		p.ellipticalArc(center.X, center.Y, r, r, 0, math.Atan2(-out.Y, -out.X), math.Pi/2)
+		p.Points[len(p.Points)-1] = to
+		p.x, p.y = to.X, to.Y
+	}
+}
+
+// Polygon adds the regular polygon with the given number of sides inscribed
+// in the circle of radius r around (cx, cy) as a closed subpath, its first
+// vertex at angle rotation and running counter-clockwise in y-up
+// coordinates. It adds nothing for fewer than three sides.
+func (p *Path) Polygon(cx, cy, r float64, sides int, rotation float64) {
+	if sides < 3 {
+		return
+	}
+	p.starPoints(cx, cy, []float64{r}, sides, rotation)
+}
+
+// Star adds a star with the given number of points as a closed subpath, its
+// tips on the circle of radius outer around (cx, cy) and the corners between
+// them on the circle of radius inner. The first tip is at angle rotation,
+// and the outline runs counter-clockwise in y-up coordinates. It adds nothing
+// for fewer than two points.
+func (p *Path) Star(cx, cy, outer, inner float64, points int, rotation float64) {
+	if points < 2 {
+		return
+	}
+	p.starPoints(cx, cy, []float64{outer, inner}, points*2, rotation)
+}
+
+// starPoints adds a closed subpath through n points evenly spaced in angle
+// around (cx, cy), at distances cycling through radii.
+func (p *Path) starPoints(cx, cy float64, radii []float64, n int, rotation float64) {
+	for i := 0; i < n; i++ {
+		sin, cos := math.Sincos(rotation + 2*math.Pi*float64(i)/float64(n))
+		r := radii[i%len(radii)]
+		if i == 0 {
+			p.MoveTo(cx+r*cos, cy+r*sin)
+		} else {
+			p.LineTo(cx+r*cos, cy+r*sin)
+		}
+	}
+	p.Close()
+}
@@ -0,0 +1,103 @@
+package gfx_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+func TestPathArcs(t *testing.T) {
+	near := func(a, b float64) bool { return math.Abs(a-b) <= 1e-3 }
+	nearPoint := func(a, b gfx.Point) bool { return near(a.X, b.X) && near(a.Y, b.Y) }
+
+	// a left turn rounded with radius 2 and a right turn the other way
+	for _, tt := range []struct {
+		y2       float64
+		end, mid gfx.Point
+	}{
+		{10, gfx.Point{10, 2}, gfx.Point{8 + math.Sqrt2, 2 - math.Sqrt2}},
+		{-10, gfx.Point{10, -2}, gfx.Point{8 + math.Sqrt2, -2 + math.Sqrt2}},
+	} {
+		p := new(gfx.Path)
+		p.MoveTo(0, 0)
+		p.TangentArcTo(10, 0, 10, tt.y2, 2)
+		if x, y := p.LastPoint(); x != tt.end.X || y != tt.end.Y {
+			t.Errorf("TangentArcTo to y %v ends at %v, %v", tt.y2, x, y)
+		}
+		m := gfx.NewPathMeasure(p)
+		if math.Abs(m.Length()-8-math.Pi) > math.Pi*1e-3 {
+			t.Errorf("TangentArcTo to y %v: length = %v, want %v", tt.y2, m.Length(), 8+math.Pi)
+		}
+		if pt, _ := m.PointAt(8 + math.Pi/2); !nearPoint(pt, tt.mid) {
+			t.Errorf("TangentArcTo to y %v: arc midpoint = %v, want %v", tt.y2, pt, tt.mid)
+		}
+	}
+
+	p := new(gfx.Path)
+	p.MoveTo(0, 0)
+	p.TangentArcTo(10, 0, 20, 0, 2)
+	if len(p.Components) != 2 || p.Components[1] != gfx.LineToComp {
+		t.Errorf("TangentArcTo along a straight line = %v", p)
+	}
+
+	p = new(gfx.Path)
+	p.ArcTo(0, 0, 5, 5, 0, math.Pi)
+	m := gfx.NewPathMeasure(p)
+	// cubic arcs run long by about 1.4e-4 of their length
+	nearLength := func(a, b float64) bool { return math.Abs(a-b) <= b*1e-3 }
+	if !nearLength(m.Length(), 5*math.Pi) {
+		t.Errorf("ArcTo length = %v, want %v", m.Length(), 5*math.Pi)
+	}
+	if pt, _ := m.PointAt(m.Length() / 2); !nearPoint(pt, gfx.Point{0, 5}) {
+		t.Errorf("ArcTo midpoint = %v", pt)
+	}
+
+	p = new(gfx.Path)
+	p.Circle(1, 1, 3)
+	if l := gfx.NewPathMeasure(p).Length(); !nearLength(l, 6*math.Pi) {
+		t.Errorf("circle length = %v, want %v", l, 6*math.Pi)
+	}
+	if !p.Contains(gfx.Point{3, 3}, gfx.FillRuleWinding) || p.Contains(gfx.Point{3.2, 3.2}, gfx.FillRuleWinding) {
+		t.Error("circle covers the wrong area")
+	}
+}
+
+func TestPathShapes(t *testing.T) {
+	// radii too large for the height are scaled down to half of it
+	p := new(gfx.Path)
+	p.RoundedRect(gfx.MakeRect(0, 0, 10, 4), gfx.MakeCornerRadii(5))
+	if l, want := gfx.NewPathMeasure(p).Length(), 12+4*math.Pi; math.Abs(l-want) > 1e-2 {
+		t.Errorf("rounded rect length = %v, want %v", l, want)
+	}
+	if p.Contains(gfx.Point{0.2, 0.2}, gfx.FillRuleWinding) || !p.Contains(gfx.Point{5, 0.1}, gfx.FillRuleWinding) {
+		t.Error("rounded rect covers the wrong area")
+	}
+
+	p = new(gfx.Path)
+	p.RoundedRect(gfx.MakeRect(0, 0, 10, 4), gfx.CornerRadii{TopRight: 3})
+	if !p.Contains(gfx.Point{0.01, 0.01}, gfx.FillRuleWinding) || p.Contains(gfx.Point{9.9, 3.9}, gfx.FillRuleWinding) {
+		t.Error("rect with one rounded corner covers the wrong area")
+	}
+
+	p = new(gfx.Path)
+	p.Polygon(0, 0, math.Sqrt2, 4, math.Pi/4)
+	want := []gfx.Point{{1, 1}, {-1, 1}, {-1, -1}, {1, -1}}
+	if len(p.Points) != 4 || p.Components[4] != gfx.ClosePathComp {
+		t.Fatalf("square = %v", p)
+	}
+	for i, pt := range p.Points {
+		if pt.DistanceTo(want[i]) > 1e-12 {
+			t.Errorf("square vertex %d = %v, want %v", i, pt, want[i])
+		}
+	}
+
+	p = new(gfx.Path)
+	p.Star(0, 0, 10, 4, 5, math.Pi/2)
+	if len(p.Points) != 10 || p.Points[0].DistanceTo(gfx.Point{0, 10}) > 1e-12 {
+		t.Errorf("star = %v", p)
+	}
+	if !p.Contains(gfx.Point{0, 9}, gfx.FillRuleWinding) || p.Contains(gfx.Point{0, -5}, gfx.FillRuleWinding) {
+		t.Error("star covers the wrong area")
+	}
+}
@@ -97,12 +97,21 @@ func (q Quads) Normalize() (n Quad) {
 	for _, quad := range q {
 		left = math.Min(left, quad.Left())
 		bottom = math.Min(bottom, quad.Bottom())
-		right = math.Min(right, quad.Right())
-		top = math.Min(top, quad.Top())
+		right = math.Max(right, quad.Right())
+		top = math.Max(top, quad.Top())
 	}
 	return MakeQuad(left, bottom, right, top)
 }
 
+// Rects returns the bounding rects of the quads.
+func (q Quads) Rects() Rects {
+	rects := make(Rects, len(q))
+	for i, quad := range q {
+		rects[i] = quad.Bounds()
+	}
+	return rects
+}
+
 func (q Quads) Orientation() (orientation Orientation) {
 	if len(q) == 0 {
 		return OtherOrientation
@@ -122,40 +131,33 @@ func (q Quads) Orientation() (orientation Orientation) {
 }
 
 func (q Quads) Union() (u Quad) {
-	var left, bottom, right, top float64
+	if len(q) == 0 {
+		return
+	}
+
 	switch q.Orientation() {
-	case PageUp:
-		left, bottom, right, top = math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
-		for _, quad := range q {
-			left = math.Min(left, quad.Left())
-			bottom = math.Min(bottom, quad.Bottom())
-			right = math.Max(right, quad.Right())
-			top = math.Max(top, quad.Top())
+	case PageUp, PageDown, PageLeft, PageRight:
+		r := q[0].Bounds()
+		for _, quad := range q[1:] {
+			r = r.Union(quad.Bounds())
 		}
-	case PageDown:
-		left, bottom, right, top = math.Inf(-1), math.Inf(-1), math.Inf(1), math.Inf(1)
-		for _, quad := range q {
-			right = math.Min(right, quad.Left())
-			top = math.Min(top, quad.Bottom())
-			left = math.Max(left, quad.Right())
-			bottom = math.Max(bottom, quad.Top())
-		}
-	case PageLeft:
-		left, bottom, right, top = math.Inf(1), math.Inf(-1), math.Inf(-1), math.Inf(1)
-		for _, quad := range q {
-			top = math.Min(top, quad.Left())
-			left = math.Min(left, quad.Bottom())
-			bottom = math.Max(bottom, quad.Right())
-			right = math.Max(right, quad.Top())
-		}
-	case PageRight:
-		left, bottom, right, top = math.Inf(-1), math.Inf(1), math.Inf(1), math.Inf(-1)
-		for _, quad := range q {
-			bottom = math.Min(bottom, quad.Left())
-			right = math.Min(right, quad.Bottom())
-			top = math.Max(top, quad.Right())
-			left = math.Max(left, quad.Top())
+
+		// place each corner of the union on the side of the bounds the
+		// matching corner of the first quad is on, which keeps rotated and
+		// y-down quads the right way round
+		first := q[0]
+		c := first.Centroid()
+		corner := func(p Point) Point {
+			pt := Point{r.X.Min, r.Y.Min}
+			if p.X > c.X {
+				pt.X = r.X.Max
+			}
+			if p.Y > c.Y {
+				pt.Y = r.Y.Max
+			}
+			return pt
 		}
+		return Quad{corner(first.BottomLeft), corner(first.TopLeft), corner(first.TopRight), corner(first.BottomRight)}
 	default:
 		baselines := make([]Point, 0, len(q)*2)
 		var xAvg, yAvg float64
@@ -195,9 +197,9 @@ func (q Quads) Union() (u Quad) {
 		left, bottom, right, top := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
 		for _, pt := range pts {
 			left = math.Min(left, pt.X)
-			right = math.Min(right, pt.X)
+			right = math.Max(right, pt.X)
 			bottom = math.Min(bottom, pt.Y)
-			top = math.Min(top, pt.Y)
+			top = math.Max(top, pt.Y)
 		}
 
 		aabb := MakeQuad(left, bottom, right, top)
@@ -237,7 +239,11 @@ func (q Quads) Union() (u Quad) {
 		if deltaAngle3 < deltaAngle {
 			obb = obb3
 		}
+
+		// y-down quads have their tops on the other side of the baseline
+		if obb.TopLeft.Sub(obb.BottomLeft).Dot(firstq.TopLeft.Sub(firstq.BottomLeft)) < 0 {
+			obb = Quad{BottomLeft: obb.TopLeft, TopLeft: obb.BottomLeft, TopRight: obb.BottomRight, BottomRight: obb.TopRight}
+		}
 		return obb
 	}
-	return MakeQuad(left, bottom, right, top)
 }
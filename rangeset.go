@@ -0,0 +1,191 @@
+package gfx
+
+import (
+	"math"
+	"sort"
+)
+
+// RangeSet is a set of reals held as sorted, disjoint ranges. Ranges that
+// touch are merged, so a set is determined by its points up to the
+// boundaries between ranges: subtracting a single point leaves a set
+// unchanged. Build sets with MakeRangeSet to keep them normalized.
+type RangeSet []Range
+
+// MakeRangeSet returns the union of ranges as a normalized set. Empty ranges
+// are dropped.
+func MakeRangeSet(ranges ...Range) (s RangeSet) {
+	sorted := make([]Range, 0, len(ranges))
+	for _, r := range ranges {
+		if !r.IsEmpty() {
+			sorted = append(sorted, r)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Min < sorted[j].Min })
+
+	for _, r := range sorted {
+		if n := len(s); n > 0 && r.Min <= s[n-1].Max {
+			s[n-1].Max = math.Max(s[n-1].Max, r.Max)
+			continue
+		}
+		s = append(s, r)
+	}
+	return
+}
+
+func (s RangeSet) IsEmpty() bool { return len(s) == 0 }
+
+// Bounds returns the smallest range containing the set.
+func (s RangeSet) Bounds() Range {
+	if len(s) == 0 {
+		return EmptyRange()
+	}
+	return Range{s[0].Min, s[len(s)-1].Max}
+}
+
+// Length returns the total length of the ranges in the set.
+func (s RangeSet) Length() (length float64) {
+	for _, r := range s {
+		length += r.Length()
+	}
+	return
+}
+
+func (s RangeSet) Contains(x float64) bool {
+	i := sort.Search(len(s), func(i int) bool { return s[i].Max >= x })
+	return i < len(s) && s[i].Contains(x)
+}
+
+// Intersects reports whether any range of the set intersects r.
+func (s RangeSet) Intersects(r Range) bool {
+	if r.IsEmpty() {
+		return false
+	}
+	i := sort.Search(len(s), func(i int) bool { return s[i].Max >= r.Min })
+	return i < len(s) && s[i].Min <= r.Max
+}
+
+func (s RangeSet) Union(o RangeSet) RangeSet {
+	return MakeRangeSet(append(append([]Range(nil), s...), o...)...)
+}
+
+func (s RangeSet) Intersect(o RangeSet) (out RangeSet) {
+	for i, j := 0, 0; i < len(s) && j < len(o); {
+		if r := s[i].Intersection(o[j]); !r.IsEmpty() {
+			out = append(out, r)
+		}
+		if s[i].Max < o[j].Max {
+			i++
+		} else {
+			j++
+		}
+	}
+	return MakeRangeSet(out...)
+}
+
+// Subtract returns the parts of s outside o. Pieces that shrink to a point
+// are dropped.
+func (s RangeSet) Subtract(o RangeSet) (out RangeSet) {
+	j := 0
+	for _, r := range s {
+		for j < len(o) && o[j].Max < r.Min {
+			j++
+		}
+
+		start := r.Min
+		for k := j; k < len(o) && o[k].Min <= r.Max; k++ {
+			if o[k].Min > start {
+				out = append(out, Range{start, o[k].Min})
+			}
+			start = math.Max(start, o[k].Max)
+		}
+		if start < r.Max {
+			out = append(out, Range{start, r.Max})
+		}
+	}
+	return MakeRangeSet(out...)
+}
+
+// Complement returns the parts of bounds outside the set.
+func (s RangeSet) Complement(bounds Range) RangeSet {
+	return MakeRangeSet(bounds).Subtract(s)
+}
+
+// Gaps returns the stretches between consecutive ranges of the set, in
+// ascending order.
+func (s RangeSet) Gaps() (gaps RangeSet) {
+	for i := 1; i < len(s); i++ {
+		gaps = append(gaps, Range{s[i-1].Max, s[i].Min})
+	}
+	return
+}
+
+// IntervalTree answers stabbing and overlap queries over a fixed list of
+// ranges. Queries return the indices of matching ranges in ascending order.
+type IntervalTree struct {
+	ranges []Range
+	order  []int
+	maxEnd []float64
+}
+
+func NewIntervalTree(ranges []Range) *IntervalTree {
+	t := &IntervalTree{ranges: ranges}
+	for i, r := range ranges {
+		if !r.IsEmpty() {
+			t.order = append(t.order, i)
+		}
+	}
+	sort.SliceStable(t.order, func(i, j int) bool { return ranges[t.order[i]].Min < ranges[t.order[j]].Min })
+
+	t.maxEnd = make([]float64, len(t.order))
+	t.build(0, len(t.order))
+	return t
+}
+
+// build fills maxEnd for the implicit balanced tree over order[lo:hi],
+// rooted at its middle element, and returns the subtree's largest end.
+func (t *IntervalTree) build(lo, hi int) float64 {
+	if lo >= hi {
+		return math.Inf(-1)
+	}
+	mid := (lo + hi) / 2
+	end := math.Max(t.ranges[t.order[mid]].Max, math.Max(t.build(lo, mid), t.build(mid+1, hi)))
+	t.maxEnd[mid] = end
+	return end
+}
+
+func (t *IntervalTree) Len() int { return len(t.ranges) }
+
+// Stab returns the ranges containing x.
+func (t *IntervalTree) Stab(x float64) []int {
+	return t.Overlapping(Range{x, x})
+}
+
+// Overlapping returns the ranges that intersect r.
+func (t *IntervalTree) Overlapping(r Range) (ids []int) {
+	if r.IsEmpty() {
+		return
+	}
+
+	var visit func(lo, hi int)
+	visit = func(lo, hi int) {
+		if lo >= hi {
+			return
+		}
+		mid := (lo + hi) / 2
+		if t.maxEnd[mid] < r.Min {
+			return
+		}
+
+		visit(lo, mid)
+		if o := t.ranges[t.order[mid]]; o.Min > r.Max {
+			return
+		} else if o.Max >= r.Min {
+			ids = append(ids, t.order[mid])
+		}
+		visit(mid+1, hi)
+	}
+	visit(0, len(t.order))
+
+	sort.Ints(ids)
+	return
+}
@@ -0,0 +1,67 @@
+package gfx_test
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+func TestRangeSet(t *testing.T) {
+	a := gfx.MakeRangeSet(gfx.Range{Min: 5, Max: 8}, gfx.Range{Min: 0, Max: 2}, gfx.Range{Min: 1, Max: 3}, gfx.Range{Min: 8, Max: 9}, gfx.EmptyRange())
+	b := gfx.MakeRangeSet(gfx.Range{Min: 2, Max: 6}, gfx.Range{Min: 7, Max: 7.5})
+
+	tests := []struct {
+		name      string
+		got, want gfx.RangeSet
+	}{
+		{"normalized", a, gfx.RangeSet{{Min: 0, Max: 3}, {Min: 5, Max: 9}}},
+		{"union", a.Union(b), gfx.RangeSet{{Min: 0, Max: 9}}},
+		{"intersect", a.Intersect(b), gfx.RangeSet{{Min: 2, Max: 3}, {Min: 5, Max: 6}, {Min: 7, Max: 7.5}}},
+		{"subtract", a.Subtract(b), gfx.RangeSet{{Min: 0, Max: 2}, {Min: 6, Max: 7}, {Min: 7.5, Max: 9}}},
+		{"complement", a.Complement(gfx.Range{Min: -1, Max: 10}), gfx.RangeSet{{Min: -1, Max: 0}, {Min: 3, Max: 5}, {Min: 9, Max: 10}}},
+		{"gaps", a.Gaps(), gfx.RangeSet{{Min: 3, Max: 5}}},
+	}
+	for _, test := range tests {
+		if !reflect.DeepEqual(test.got, test.want) {
+			t.Errorf("%s = %v, want %v", test.name, test.got, test.want)
+		}
+	}
+
+	if !a.Contains(3) || a.Contains(4) || a.Length() != 7 {
+		t.Errorf("contains(3) = %v, contains(4) = %v, length = %v", a.Contains(3), a.Contains(4), a.Length())
+	}
+}
+
+func TestIntervalTree(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var ranges []gfx.Range
+	for i := 0; i < 500; i++ {
+		x := rng.Float64() * 100
+		ranges = append(ranges, gfx.Range{Min: x, Max: x + rng.Float64()*10})
+	}
+	ranges = append(ranges, gfx.EmptyRange())
+	tree := gfx.NewIntervalTree(ranges)
+
+	for n := 0; n < 100; n++ {
+		x := rng.Float64() * 110
+		q := gfx.Range{Min: x, Max: x + rng.Float64()*5}
+
+		var stab, overlap []int
+		for i, r := range ranges {
+			if r.Contains(x) {
+				stab = append(stab, i)
+			}
+			if r.Intersects(q) {
+				overlap = append(overlap, i)
+			}
+		}
+		if got := tree.Stab(x); !reflect.DeepEqual(got, stab) {
+			t.Fatalf("stab(%v) = %v, want %v", x, got, stab)
+		}
+		if got := tree.Overlapping(q); !reflect.DeepEqual(got, overlap) {
+			t.Fatalf("overlapping(%v) = %v, want %v", q, got, overlap)
+		}
+	}
+}
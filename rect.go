@@ -229,9 +229,25 @@ func (r Rect) MinDistanceTo(other Rect) float64 {
 
 type Rects []Rect
 
+// GroupRows splits the rects into rows of vertically overlapping rects, in
+// ascending Y. Each rect appears once, in the row where it starts.
 func (r Rects) GroupRows() []Rects {
+	rows := make([]Rects, 0)
+	for _, ids := range r.GroupRowIndices() {
+		row := make(Rects, len(ids))
+		for i, id := range ids {
+			row[i] = r[id]
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// GroupRowIndices is GroupRows returning the indices of the rects in each
+// row rather than the rects.
+func (r Rects) GroupRowIndices() [][]int {
 	if len(r) == 0 {
-		return []Rects{}
+		return nil
 	}
 
 	events := make(map[float64]struct{})
@@ -245,24 +261,19 @@ func (r Rects) GroupRows() []Rects {
 	}
 	sort.Float64s(ys)
 
-	rows := make([]Rects, 0)
-	row := make(Rects, 0)
+	var rows [][]int
+	var row []int
 
 	count := 0
 	for _, y := range ys {
-		for _, rect := range r {
-			if !(EqualEpsilon(rect.Y.Min, y) || EqualEpsilon(rect.Y.Max, y)) {
-				continue
-			}
-
+		for i, rect := range r {
 			if EqualEpsilon(rect.Y.Min, y) {
 				count++
+				row = append(row, i)
 			}
 			if EqualEpsilon(rect.Y.Max, y) {
 				count--
 			}
-
-			row = append(row, rect)
 		}
 
 		if count == 0 {
@@ -279,20 +290,29 @@ func (r Rects) Cluster(minRects int, maxDistance float64) (results []Rects) {
 		return
 	}
 
+	clusters := r.ClusterIndices(minRects, maxDistance)
+	results = make([]Rects, len(clusters))
+	for i, grp := range clusters {
+		for _, idx := range grp {
+			results[i] = append(results[i], r[idx])
+		}
+	}
+	return
+}
+
+// ClusterIndices is Cluster returning the indices of the rects in each
+// cluster rather than the rects.
+func (r Rects) ClusterIndices(minRects int, maxDistance float64) [][]int {
+	if len(r) == 0 {
+		return nil
+	}
+
 	clusters := make([][]int, 0)
 	status := make(map[int]int, len(r))
+	index := r.Index()
 
 	regionQuery := func(id int) (neighbors []int) {
-		obj := r[id]
-		for i := 0; i < len(r); i++ {
-			if id == i {
-				continue
-			}
-			if obj.MinDistanceTo(r[i]) < maxDistance {
-				neighbors = append(neighbors, i)
-			}
-		}
-		return
+		return index.withinDistance(r[id], maxDistance, id)
 	}
 
 	var expandCluster func(id int, cluster int, neighbors []int)
@@ -331,14 +351,7 @@ func (r Rects) Cluster(minRects int, maxDistance float64) (results []Rects) {
 		}
 	}
 
-	results = make([]Rects, len(clusters))
-	for i, grp := range clusters {
-		for _, idx := range grp {
-			results[i] = append(results[i], r[idx])
-		}
-	}
-
-	return
+	return clusters
 }
 
 func (r Rects) Coalesce() Rects {
@@ -347,6 +360,7 @@ func (r Rects) Coalesce() Rects {
 	}
 
 	groups := make([]map[int]struct{}, 0)
+	index := r.Index()
 
 	for idx, rect := range r {
 		var group map[int]struct{}
@@ -364,9 +378,8 @@ func (r Rects) Coalesce() Rects {
 			group = grp
 		}
 
-		for i := idx + 1; i < len(r); i++ {
-			next := r[i]
-			if rect.Intersects(next) {
+		for _, i := range index.Intersecting(rect) {
+			if i > idx {
 				group[i] = struct{}{}
 			}
 		}
@@ -0,0 +1,60 @@
+package gfx_test
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+func TestRectsGroupRows(t *testing.T) {
+	rects := gfx.Rects{
+		gfx.MakeRect(0, 20, 10, 30),
+		gfx.MakeRect(0, 0, 10, 10),
+		gfx.MakeRect(20, 5, 30, 15),
+		gfx.MakeRect(40, 12, 50, 12), // no height, inside the first row
+	}
+
+	rows := rects.GroupRows()
+	want := []gfx.Rects{{rects[1], rects[2], rects[3]}, {rects[0]}}
+	if len(rows) != len(want) {
+		t.Fatalf("GroupRows = %v, want %v", rows, want)
+	}
+	for i := range want {
+		// every rect appears once, not once per edge
+		if len(rows[i]) != len(want[i]) {
+			t.Errorf("row %d = %v, want %v", i, rows[i], want[i])
+			continue
+		}
+		for j := range want[i] {
+			if rows[i][j] != want[i][j] {
+				t.Errorf("row %d = %v, want %v", i, rows[i], want[i])
+				break
+			}
+		}
+	}
+}
+
+func TestRectsIndices(t *testing.T) {
+	// two identical rects must map back to both of their indices
+	rects := gfx.Rects{
+		gfx.MakeRect(0, 0, 10, 10),
+		gfx.MakeRect(30, 0, 40, 10),
+		gfx.MakeRect(0, 0, 10, 10),
+		gfx.MakeRect(0, 50, 10, 60),
+	}
+
+	if got := fmt.Sprint(rects.GroupRowIndices()); got != "[[0 1 2] [3]]" {
+		t.Errorf("GroupRowIndices = %v", got)
+	}
+
+	clusters := rects.ClusterIndices(0, 5)
+	for _, ids := range clusters {
+		sort.Ints(ids)
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i][0] < clusters[j][0] })
+	if got := fmt.Sprint(clusters); got != "[[0 2] [1] [3]]" {
+		t.Errorf("ClusterIndices = %v", got)
+	}
+}
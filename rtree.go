@@ -0,0 +1,249 @@
+package gfx
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// rtreeCapacity is the number of entries packed into each R-tree node.
+const rtreeCapacity = 16
+
+// RTree is a static R-tree over rects, bulk loaded with the
+// Sort-Tile-Recursive algorithm. Queries return the indices of matching
+// rects in ascending order. Empty rects are kept aside and checked on every
+// query, so predicates see exactly the rects they would in a linear scan.
+type RTree struct {
+	rects Rects
+	nodes []rtreeNode
+	root  int
+	loose []int
+}
+
+type rtreeNode struct {
+	bounds  Rect
+	entries []int
+	leaf    bool
+}
+
+func NewRTree(rects Rects) *RTree {
+	t := &RTree{rects: rects, root: -1}
+
+	var entries []int
+	var bounds Rects
+	for i, r := range rects {
+		if r.IsEmpty() {
+			t.loose = append(t.loose, i)
+			continue
+		}
+		entries = append(entries, i)
+		bounds = append(bounds, r)
+	}
+
+	leaf := true
+	for len(entries) > 0 {
+		entries, bounds = t.pack(entries, bounds, leaf)
+		leaf = false
+		if len(entries) == 1 {
+			t.root = entries[0]
+			break
+		}
+	}
+	return t
+}
+
+// pack groups entries into nodes of rtreeCapacity, tiling them into
+// vertical slices by x and then runs by y, and returns the new nodes.
+func (t *RTree) pack(entries []int, bounds Rects, leaf bool) (nodes []int, nodeBounds Rects) {
+	order := make([]int, len(entries))
+	for i := range order {
+		order[i] = i
+	}
+	center := func(i int) Point {
+		r := bounds[i]
+		return Point{(r.X.Min + r.X.Max) / 2, (r.Y.Min + r.Y.Max) / 2}
+	}
+
+	count := (len(entries) + rtreeCapacity - 1) / rtreeCapacity
+	slices := int(math.Ceil(math.Sqrt(float64(count))))
+	sliceSize := slices * rtreeCapacity
+
+	sort.SliceStable(order, func(i, j int) bool { return center(order[i]).X < center(order[j]).X })
+	for s := 0; s < len(order); s += sliceSize {
+		slice := order[s:minInt(s+sliceSize, len(order))]
+		sort.SliceStable(slice, func(i, j int) bool { return center(slice[i]).Y < center(slice[j]).Y })
+
+		for n := 0; n < len(slice); n += rtreeCapacity {
+			node := rtreeNode{bounds: EmptyRect(), leaf: leaf}
+			for _, k := range slice[n:minInt(n+rtreeCapacity, len(slice))] {
+				node.entries = append(node.entries, entries[k])
+				node.bounds = node.bounds.Union(bounds[k])
+			}
+			nodes = append(nodes, len(t.nodes))
+			nodeBounds = append(nodeBounds, node.bounds)
+			t.nodes = append(t.nodes, node)
+		}
+	}
+	return
+}
+
+func (t *RTree) Len() int { return len(t.rects) }
+
+// Rects returns the rects the tree was built from.
+func (t *RTree) Rects() Rects { return t.rects }
+
+// search returns the rects other than skip accepted by match, looking only
+// below the nodes accepted by visit. Empty rects are always checked, and an
+// empty query falls back to a linear scan, as pruning by bounds means
+// nothing for it.
+func (t *RTree) search(query Rect, skip int, visit func(Rect) bool, match func(Rect) bool) (ids []int) {
+	if query.IsEmpty() {
+		for i, r := range t.rects {
+			if i != skip && match(r) {
+				ids = append(ids, i)
+			}
+		}
+		return
+	}
+
+	for _, i := range t.loose {
+		if i != skip && match(t.rects[i]) {
+			ids = append(ids, i)
+		}
+	}
+
+	if t.root >= 0 {
+		stack := []int{t.root}
+		for len(stack) > 0 {
+			node := t.nodes[stack[len(stack)-1]]
+			stack = stack[:len(stack)-1]
+			if !visit(node.bounds) {
+				continue
+			}
+			for _, e := range node.entries {
+				switch {
+				case !node.leaf:
+					stack = append(stack, e)
+				case e != skip && match(t.rects[e]):
+					ids = append(ids, e)
+				}
+			}
+		}
+	}
+
+	sort.Ints(ids)
+	return
+}
+
+// Intersecting returns the rects that r intersects.
+func (t *RTree) Intersecting(r Rect) []int {
+	return t.intersecting(r, -1)
+}
+
+func (t *RTree) intersecting(r Rect, skip int) []int {
+	return t.search(r, skip,
+		func(b Rect) bool { return r.Intersects(b) },
+		func(o Rect) bool { return r.Intersects(o) })
+}
+
+// Within returns the rects contained in r.
+func (t *RTree) Within(r Rect) []int {
+	return t.search(r, -1,
+		func(b Rect) bool { return r.Intersects(b) },
+		func(o Rect) bool { return r.Contains(o) })
+}
+
+// Containing returns the rects that contain r.
+func (t *RTree) Containing(r Rect) []int {
+	return t.search(r, -1,
+		func(b Rect) bool { return b.Contains(r) },
+		func(o Rect) bool { return o.Contains(r) })
+}
+
+// WithinDistance returns the rects whose distance from r, as
+// r.MinDistanceTo, is less than distance.
+func (t *RTree) WithinDistance(r Rect, distance float64) []int {
+	return t.withinDistance(r, distance, -1)
+}
+
+func (t *RTree) withinDistance(r Rect, distance float64, skip int) []int {
+	return t.search(r, skip,
+		func(b Rect) bool { return r.MinDistanceTo(b) < distance },
+		func(o Rect) bool { return r.MinDistanceTo(o) < distance })
+}
+
+// Nearest returns the k rects nearest to p, nearest first. Rects at the
+// same distance are ordered by index; empty rects are never returned.
+func (t *RTree) Nearest(p Point, k int) (ids []int) {
+	if t.root < 0 || k <= 0 {
+		return
+	}
+
+	queue := &rtreeQueue{{node: t.root, distance: rectPointDistance(t.nodes[t.root].bounds, p)}}
+	for queue.Len() > 0 && len(ids) < k {
+		item := heap.Pop(queue).(rtreeItem)
+		if item.node < 0 {
+			ids = append(ids, item.rect)
+			continue
+		}
+
+		node := t.nodes[item.node]
+		for _, e := range node.entries {
+			if node.leaf {
+				heap.Push(queue, rtreeItem{node: -1, rect: e, distance: rectPointDistance(t.rects[e], p)})
+			} else {
+				heap.Push(queue, rtreeItem{node: e, distance: rectPointDistance(t.nodes[e].bounds, p)})
+			}
+		}
+	}
+	return
+}
+
+// rtreeItem is a node (node >= 0) or a rect waiting in a nearest neighbour
+// search.
+type rtreeItem struct {
+	node, rect int
+	distance   float64
+}
+
+type rtreeQueue []rtreeItem
+
+func (q rtreeQueue) Len() int { return len(q) }
+func (q rtreeQueue) Less(i, j int) bool {
+	if q[i].distance != q[j].distance {
+		return q[i].distance < q[j].distance
+	}
+	// expand nodes before reporting rects at the same distance, so ties
+	// are broken by index
+	if (q[i].node < 0) != (q[j].node < 0) {
+		return q[i].node >= 0
+	}
+	return q[i].rect < q[j].rect
+}
+func (q rtreeQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *rtreeQueue) Push(x interface{}) { *q = append(*q, x.(rtreeItem)) }
+func (q *rtreeQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+func rectPointDistance(r Rect, p Point) float64 {
+	dx := math.Max(0, math.Max(r.X.Min-p.X, p.X-r.X.Max))
+	dy := math.Max(0, math.Max(r.Y.Min-p.Y, p.Y-r.Y.Max))
+	return math.Hypot(dx, dy)
+}
+
+func (r Rects) Index() *RTree { return NewRTree(r) }
+
+func (q Quads) Index() *RTree { return NewRTree(q.Rects()) }
+
+// Index returns an R-tree over the bounds of the lines.
+func (l Lines) Index() *RTree {
+	rects := make(Rects, len(l))
+	for i, line := range l {
+		rects[i] = MakeRect(math.Min(line.Start.X, line.End.X), math.Min(line.Start.Y, line.End.Y), math.Max(line.Start.X, line.End.X), math.Max(line.Start.Y, line.End.Y))
+	}
+	return NewRTree(rects)
+}
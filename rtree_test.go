@@ -0,0 +1,64 @@
+package gfx_test
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+func TestRTree(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := func() gfx.Rect {
+		x, y := rng.Float64()*1000, rng.Float64()*1000
+		return gfx.MakeRect(x, y, x+rng.Float64()*40, y+rng.Float64()*40)
+	}
+
+	var rects gfx.Rects
+	for i := 0; i < 2000; i++ {
+		rects = append(rects, random())
+	}
+	rects = append(rects, gfx.EmptyRect(), rects[3])
+	tree := rects.Index()
+
+	scan := func(match func(gfx.Rect) bool) (ids []int) {
+		for i, r := range rects {
+			if match(r) {
+				ids = append(ids, i)
+			}
+		}
+		return
+	}
+
+	for n := 0; n < 50; n++ {
+		q := random()
+		q.X.Max += 60
+		tests := []struct {
+			name      string
+			got, want []int
+		}{
+			{"intersecting", tree.Intersecting(q), scan(func(r gfx.Rect) bool { return q.Intersects(r) })},
+			{"within", tree.Within(q), scan(func(r gfx.Rect) bool { return q.Contains(r) })},
+			{"containing", tree.Containing(rects[n]), scan(func(r gfx.Rect) bool { return r.Contains(rects[n]) })},
+			{"distance", tree.WithinDistance(q, 25), scan(func(r gfx.Rect) bool { return q.MinDistanceTo(r) < 25 })},
+		}
+		for _, test := range tests {
+			if !reflect.DeepEqual(test.got, test.want) {
+				t.Fatalf("%s(%v) = %v, want %v", test.name, q, test.got, test.want)
+			}
+		}
+
+		p := gfx.Point{X: rng.Float64() * 1000, Y: rng.Float64() * 1000}
+		distance := func(r gfx.Rect) float64 {
+			return math.Hypot(math.Max(0, math.Max(r.X.Min-p.X, p.X-r.X.Max)), math.Max(0, math.Max(r.Y.Min-p.Y, p.Y-r.Y.Max)))
+		}
+		want := scan(func(r gfx.Rect) bool { return !r.IsEmpty() })
+		sort.SliceStable(want, func(i, j int) bool { return distance(rects[want[i]]) < distance(rects[want[j]]) })
+		if got := tree.Nearest(p, 10); !reflect.DeepEqual(got, want[:10]) {
+			t.Fatalf("nearest(%v) = %v, want %v", p, got, want[:10])
+		}
+	}
+}
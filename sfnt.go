@@ -0,0 +1,241 @@
+package gfx
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/bryanmatteson/gfx/font/woff"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// LoadFont reads a TrueType, OpenType, WOFF or WOFF2 font file. For
+// collections the first font is returned.
+func LoadFont(path string) (Font, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseFont(data)
+}
+
+// ParseFont parses font data in any of the formats accepted by LoadFont. WOFF
+// and WOFF2 containers are decoded to SFNT bytes before parsing.
+func ParseFont(data []byte) (Font, error) {
+	if woff.IsWOFF(data) || woff.IsWOFF2(data) {
+		decoded, err := woff.Decode(data)
+		if err != nil {
+			return nil, err
+		}
+		data = decoded
+	}
+
+	var f *sfnt.Font
+	var err error
+	if bytes.HasPrefix(data, []byte("ttcf")) {
+		var coll *sfnt.Collection
+		if coll, err = sfnt.ParseCollection(data); err == nil {
+			f, err = coll.Font(0)
+		}
+	} else {
+		f, err = sfnt.Parse(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newSfntFont(f, data)
+}
+
+// Load loads the font described by the record.
+func (r SystemFontRecord) Load() (Font, error) {
+	return LoadFont(r.Path)
+}
+
+type sfntFont struct {
+	font *sfnt.Font
+	name string
+	info FontData
+	bbox Rect
+	ppem fixed.Int26_6
+	gsub []byte
+
+	mu      sync.Mutex
+	buf     sfnt.Buffer
+	lookups map[string][]SubstitutionLookup
+}
+
+func newSfntFont(f *sfnt.Font, data []byte) (*sfntFont, error) {
+	sf := &sfntFont{font: f, ppem: fixed.I(int(f.UnitsPerEm())), lookups: make(map[string][]SubstitutionLookup)}
+
+	family, err := f.Name(&sf.buf, sfnt.NameIDFamily)
+	if err != nil {
+		return nil, fmt.Errorf("font has no family name: %w", err)
+	}
+
+	sf.name, err = f.Name(&sf.buf, sfnt.NameIDPostScript)
+	if err != nil || sf.name == "" {
+		sf.name = family
+	}
+
+	sf.info = FontData{Name: family, Family: FontFamilySans}
+	subfamily, _ := f.Name(&sf.buf, sfnt.NameIDSubfamily)
+	subfamily = strings.ToLower(subfamily)
+	if strings.Contains(subfamily, "bold") {
+		sf.info.Style |= FontStyleBold
+	}
+	if strings.Contains(subfamily, "italic") || strings.Contains(subfamily, "oblique") {
+		sf.info.Style |= FontStyleItalic
+	}
+
+	lower := strings.ToLower(family)
+	switch {
+	case f.PostTable() != nil && f.PostTable().IsFixedPitch, strings.Contains(lower, "mono"):
+		sf.info.Family = FontFamilyMono
+	case strings.Contains(lower, "serif") && !strings.Contains(lower, "sans"):
+		sf.info.Family = FontFamilySerif
+	}
+
+	bounds, err := f.Bounds(&sf.buf, sf.ppem, font.HintingNone)
+	if err != nil {
+		return nil, err
+	}
+	sf.bbox = MakeRect(sf.units(bounds.Min.X), -sf.units(bounds.Max.Y), sf.units(bounds.Max.X), -sf.units(bounds.Min.Y))
+
+	if sf.gsub, err = sfntTable(data, "GSUB"); err != nil {
+		return nil, err
+	}
+
+	return sf, nil
+}
+
+// units converts a 26.6 value loaded at ppem == unitsPerEm into em units.
+func (f *sfntFont) units(v fixed.Int26_6) float64 {
+	return float64(v) / float64(f.ppem)
+}
+
+func (f *sfntFont) Name() string      { return f.name }
+func (f *sfntFont) BoundingBox() Rect { return f.bbox }
+func (f *sfntFont) Info() FontData    { return f.info }
+
+// Glyph returns the outline of chr in em units with the y axis pointing up,
+// transformed by trm. Runes without a glyph produce the .notdef outline.
+func (f *sfntFont) Glyph(chr rune, trm Matrix) *Glyph {
+	return f.GlyphOutline(f.GlyphIndex(chr), trm)
+}
+
+// Advance returns the horizontal advance of chr in em units. Vertical
+// writing modes use a fixed advance of one em.
+func (f *sfntFont) Advance(chr rune, mode int) float64 {
+	if mode != 0 {
+		return 1
+	}
+	return f.GlyphAdvance(f.GlyphIndex(chr))
+}
+
+// GlyphIndex returns the glyph for chr, or 0 (.notdef) if the font has none.
+func (f *sfntFont) GlyphIndex(chr rune) GlyphID {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	idx, err := f.font.GlyphIndex(&f.buf, chr)
+	if err != nil {
+		return 0
+	}
+	return GlyphID(idx)
+}
+
+// HasGlyph reports whether the font maps chr to a glyph other than .notdef.
+func (f *sfntFont) HasGlyph(chr rune) bool {
+	return f.GlyphIndex(chr) != 0
+}
+
+// GlyphAdvance returns the horizontal advance of gid in em units.
+func (f *sfntFont) GlyphAdvance(gid GlyphID) float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	advance, err := f.font.GlyphAdvance(&f.buf, sfnt.GlyphIndex(gid), f.ppem, font.HintingNone)
+	if err != nil {
+		return 0
+	}
+	return f.units(advance)
+}
+
+// GlyphOutline returns the outline of gid in em units with the y axis
+// pointing up, transformed by trm.
+func (f *sfntFont) GlyphOutline(gid GlyphID, trm Matrix) *Glyph {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	idx := sfnt.GlyphIndex(gid)
+	glyph := &Glyph{Path: new(Path)}
+	if advance, err := f.font.GlyphAdvance(&f.buf, idx, f.ppem, font.HintingNone); err == nil {
+		glyph.Width = trm.TransformVec(Point{f.units(advance), 0}).X
+	}
+
+	segments, err := f.font.LoadGlyph(&f.buf, idx, f.ppem, nil)
+	if err != nil {
+		return glyph
+	}
+
+	pt := func(p fixed.Point26_6) (float64, float64) {
+		return trm.TransformXY(f.units(p.X), -f.units(p.Y))
+	}
+
+	path := glyph.Path
+	for _, seg := range segments {
+		switch seg.Op {
+		case sfnt.SegmentOpMoveTo:
+			if !path.IsEmpty() {
+				path.Close()
+			}
+			path.MoveTo(pt(seg.Args[0]))
+		case sfnt.SegmentOpLineTo:
+			path.LineTo(pt(seg.Args[0]))
+		case sfnt.SegmentOpQuadTo:
+			cx, cy := pt(seg.Args[0])
+			x, y := pt(seg.Args[1])
+			path.QuadCurveTo(cx, cy, x, y)
+		case sfnt.SegmentOpCubeTo:
+			cx1, cy1 := pt(seg.Args[0])
+			cx2, cy2 := pt(seg.Args[1])
+			x, y := pt(seg.Args[2])
+			path.CubicCurveTo(cx1, cy1, cx2, cy2, x, y)
+		}
+	}
+	if !path.IsEmpty() {
+		path.Close()
+	}
+	return glyph
+}
+
+// Kerning returns the pair adjustment between left and right in em units,
+// read from the kern table or GPOS pair positioning.
+func (f *sfntFont) Kerning(left, right GlyphID) float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	kern, err := f.font.Kern(&f.buf, sfnt.GlyphIndex(left), sfnt.GlyphIndex(right), f.ppem, font.HintingNone)
+	if err != nil {
+		return 0
+	}
+	return f.units(kern)
+}
+
+// Substitutions returns the GSUB lookups registered for feature. Malformed
+// tables yield no substitutions.
+func (f *sfntFont) Substitutions(feature string) []SubstitutionLookup {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if lookups, ok := f.lookups[feature]; ok {
+		return lookups
+	}
+	lookups, _ := parseGSUB(f.gsub, feature)
+	f.lookups[feature] = lookups
+	return lookups
+}
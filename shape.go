@@ -0,0 +1,312 @@
+package gfx
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// GlyphID identifies a glyph within a font. For fonts that do not implement
+// ShapingFont the glyph ID of a character is the rune itself.
+type GlyphID int
+
+// ShapingFont is implemented by fonts that expose glyph level data for text
+// shaping. All metrics are in em units.
+type ShapingFont interface {
+	Font
+	GlyphIndex(chr rune) GlyphID
+	GlyphAdvance(gid GlyphID) float64
+	GlyphOutline(gid GlyphID, trm Matrix) *Glyph
+	Kerning(left, right GlyphID) float64
+	Substitutions(feature string) []SubstitutionLookup
+}
+
+// Substitution replaces a sequence of glyphs with a single glyph. Single
+// substitutions have one input glyph; ligatures have several.
+type Substitution struct {
+	Input  []GlyphID
+	Output GlyphID
+}
+
+// SubstitutionLookup is an ordered group of substitutions. Lookups are
+// applied in ascending Index order, matching the font's lookup list.
+type SubstitutionLookup struct {
+	Index         int
+	Substitutions []Substitution
+}
+
+// ShapingData is substitution and kerning data kept apart from a font, such
+// as the ligatures and kern pairs of an AFM file. Substitutions holds
+// lookups by feature tag, and Kerning, when set, returns pair kerning in em
+// units.
+type ShapingData struct {
+	Substitutions map[string][]SubstitutionLookup
+	Kerning       func(left, right GlyphID) float64
+}
+
+// dataLookupIndex is added to the index of every ShapingData lookup so that
+// they apply after all of a font's own lookups, whose GSUB indices are 16
+// bit.
+const dataLookupIndex = 1 << 16
+
+// WithShapingData returns font as a ShapingFont that also applies data. The
+// lookups of data apply after the font's own for the same features, and its
+// kerning adds to the font's. A font that is not a ShapingFont keeps its
+// rune glyph IDs, so data must name glyphs by rune.
+func WithShapingData(font Font, data ShapingData) ShapingFont {
+	return &dataFont{Font: font, data: data}
+}
+
+type dataFont struct {
+	Font
+	data ShapingData
+}
+
+func (f *dataFont) HasGlyph(chr rune) bool { return FontHasGlyph(f.Font, chr) }
+
+func (f *dataFont) GlyphIndex(chr rune) GlyphID {
+	if sf, ok := f.Font.(ShapingFont); ok {
+		return sf.GlyphIndex(chr)
+	}
+	return GlyphID(chr)
+}
+
+func (f *dataFont) GlyphAdvance(gid GlyphID) float64 {
+	if sf, ok := f.Font.(ShapingFont); ok {
+		return sf.GlyphAdvance(gid)
+	}
+	return f.Font.Advance(rune(gid), 0)
+}
+
+func (f *dataFont) GlyphOutline(gid GlyphID, trm Matrix) *Glyph {
+	return glyphOutline(f.Font, gid, trm)
+}
+
+func (f *dataFont) Kerning(left, right GlyphID) (kerning float64) {
+	if sf, ok := f.Font.(ShapingFont); ok {
+		kerning = sf.Kerning(left, right)
+	}
+	if f.data.Kerning != nil {
+		kerning += f.data.Kerning(left, right)
+	}
+	return kerning
+}
+
+func (f *dataFont) Substitutions(feature string) []SubstitutionLookup {
+	var lookups []SubstitutionLookup
+	if sf, ok := f.Font.(ShapingFont); ok {
+		lookups = append(lookups, sf.Substitutions(feature)...)
+	}
+	for _, lookup := range f.data.Substitutions[feature] {
+		lookup.Index += dataLookupIndex
+		lookups = append(lookups, lookup)
+	}
+	return lookups
+}
+
+// ShapedGlyph is a positioned glyph produced by a Shaper. Advance includes
+// kerning. Cluster is the byte offset in the source text of the first rune
+// the glyph represents. Offset moves the glyph from its pen position; the
+// Shaper does not apply GPOS positioning, so it is currently always zero.
+type ShapedGlyph struct {
+	ID      GlyphID
+	Cluster int
+	Runes   []rune
+	Advance float64
+	Offset  Point
+}
+
+// GlyphRun is a sequence of shaped glyphs drawn with a single font.
+type GlyphRun struct {
+	Font   Font
+	Glyphs []ShapedGlyph
+}
+
+// Advance returns the total advance of the run in em units.
+func (r GlyphRun) Advance() (advance float64) {
+	for _, g := range r.Glyphs {
+		advance += g.Advance
+	}
+	return
+}
+
+// String returns the source text covered by the run.
+func (r GlyphRun) String() string {
+	var runes []rune
+	for _, g := range r.Glyphs {
+		runes = append(runes, g.Runes...)
+	}
+	return string(runes)
+}
+
+// Shaping feature tags understood by Shaper. Any other GSUB feature tag
+// offered by a font, such as "smcp" or "onum", may be enabled as well.
+const (
+	FeatureKerning                = "kern"
+	FeatureStandardLigatures      = "liga"
+	FeatureContextualLigatures    = "clig"
+	FeatureRequiredLigatures      = "rlig"
+	FeatureDiscretionaryLigatures = "dlig"
+	FeatureHistoricalLigatures    = "hlig"
+)
+
+// Shaper turns text into positioned glyph runs, applying pair kerning and
+// glyph substitutions for the enabled features. The zero value has no
+// features enabled.
+type Shaper struct {
+	Features map[string]bool
+}
+
+// NewShaper creates a shaper with kerning and the standard, contextual and
+// required ligatures enabled.
+func NewShaper() *Shaper {
+	return &Shaper{Features: map[string]bool{
+		FeatureKerning:             true,
+		FeatureStandardLigatures:   true,
+		FeatureContextualLigatures: true,
+		FeatureRequiredLigatures:   true,
+	}}
+}
+
+// Enable turns on the feature with the given tag.
+func (s *Shaper) Enable(tag string) { s.set(tag, true) }
+
+// Disable turns off the feature with the given tag.
+func (s *Shaper) Disable(tag string) { s.set(tag, false) }
+
+func (s *Shaper) set(tag string, enabled bool) {
+	if s.Features == nil {
+		s.Features = make(map[string]bool)
+	}
+	s.Features[tag] = enabled
+}
+
+// Shape converts text into a glyph run for font. A font that is not a
+// ShapingFont gets one glyph per rune without kerning or ligatures unless it
+// is given them with WithShapingData.
+func (s *Shaper) Shape(text string, font Font) GlyphRun {
+	run := GlyphRun{Font: font, Glyphs: make([]ShapedGlyph, 0, utf8.RuneCountInString(text))}
+
+	sf, ok := font.(ShapingFont)
+	if !ok {
+		for i, chr := range text {
+			run.Glyphs = append(run.Glyphs, ShapedGlyph{
+				ID:      GlyphID(chr),
+				Cluster: i,
+				Runes:   []rune{chr},
+				Advance: font.Advance(chr, 0),
+			})
+		}
+		return run
+	}
+
+	for i, chr := range text {
+		run.Glyphs = append(run.Glyphs, ShapedGlyph{ID: sf.GlyphIndex(chr), Cluster: i, Runes: []rune{chr}})
+	}
+
+	for _, lookup := range s.lookups(sf) {
+		run.Glyphs = applySubstitutions(run.Glyphs, lookup.Substitutions)
+	}
+
+	for i := range run.Glyphs {
+		run.Glyphs[i].Advance = sf.GlyphAdvance(run.Glyphs[i].ID)
+		if i > 0 && s.Features[FeatureKerning] {
+			run.Glyphs[i-1].Advance += sf.Kerning(run.Glyphs[i-1].ID, run.Glyphs[i].ID)
+		}
+	}
+	return run
+}
+
+// ShapeRuns shapes text into one glyph run per font. A FallbackFont splits
+// text by the font covering each rune; any other font yields a single run.
+// Glyph clusters are byte offsets into text.
+func (s *Shaper) ShapeRuns(text string, font Font) []GlyphRun {
+	fallback, ok := font.(*FallbackFont)
+	if !ok {
+		return []GlyphRun{s.Shape(text, font)}
+	}
+
+	var runs []GlyphRun
+	for _, fr := range fallback.Runs(text) {
+		run := s.Shape(fr.Text, fr.Font)
+		for i := range run.Glyphs {
+			run.Glyphs[i].Cluster += fr.Offset
+		}
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+// lookups collects the substitution lookups of all enabled features in
+// lookup list order.
+func (s *Shaper) lookups(font ShapingFont) []SubstitutionLookup {
+	seen := make(map[int]bool)
+	var lookups []SubstitutionLookup
+	for tag, enabled := range s.Features {
+		if !enabled || tag == FeatureKerning {
+			continue
+		}
+		for _, lookup := range font.Substitutions(tag) {
+			if !seen[lookup.Index] {
+				seen[lookup.Index] = true
+				lookups = append(lookups, lookup)
+			}
+		}
+	}
+	sort.Slice(lookups, func(i, j int) bool { return lookups[i].Index < lookups[j].Index })
+	return lookups
+}
+
+// applySubstitutions runs one lookup over glyphs, replacing the longest
+// matching input sequence at each position.
+func applySubstitutions(glyphs []ShapedGlyph, subs []Substitution) []ShapedGlyph {
+	byFirst := make(map[GlyphID][]int)
+	for k, sub := range subs {
+		if len(sub.Input) > 0 {
+			byFirst[sub.Input[0]] = append(byFirst[sub.Input[0]], k)
+		}
+	}
+
+	out := make([]ShapedGlyph, 0, len(glyphs))
+	for i := 0; i < len(glyphs); {
+		best := -1
+		for _, k := range byFirst[glyphs[i].ID] {
+			input := subs[k].Input
+			if len(input) > len(glyphs)-i || (best >= 0 && len(input) <= len(subs[best].Input)) {
+				continue
+			}
+			matched := true
+			for j, gid := range input[1:] {
+				if glyphs[i+j+1].ID != gid {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				best = k
+			}
+		}
+
+		if best < 0 {
+			out = append(out, glyphs[i])
+			i++
+			continue
+		}
+
+		n := len(subs[best].Input)
+		merged := ShapedGlyph{ID: subs[best].Output, Cluster: glyphs[i].Cluster}
+		for _, g := range glyphs[i : i+n] {
+			merged.Runes = append(merged.Runes, g.Runes...)
+		}
+		out = append(out, merged)
+		i += n
+	}
+	return out
+}
+
+// glyphOutline returns the outline of a shaped glyph.
+func glyphOutline(font Font, gid GlyphID, trm Matrix) *Glyph {
+	if sf, ok := font.(ShapingFont); ok {
+		return sf.GlyphOutline(gid, trm)
+	}
+	return font.Glyph(rune(gid), trm)
+}
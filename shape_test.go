@@ -0,0 +1,182 @@
+package gfx_test
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/bryanmatteson/gfx"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// u16s encodes values as big-endian 16-bit words.
+func u16s(values ...int) []byte {
+	b := make([]byte, 2*len(values))
+	for i, v := range values {
+		binary.BigEndian.PutUint16(b[2*i:], uint16(v))
+	}
+	return b
+}
+
+func concat(parts ...[]byte) (b []byte) {
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return
+}
+
+// withTables returns the SFNT font data with extra tables added.
+func withTables(data []byte, extra map[string][]byte) []byte {
+	type record struct {
+		tag  string
+		data []byte
+	}
+	var records []record
+	for i, n := 0, int(binary.BigEndian.Uint16(data[4:])); i < n; i++ {
+		rec := data[12+16*i:]
+		offset, length := binary.BigEndian.Uint32(rec[8:]), binary.BigEndian.Uint32(rec[12:])
+		records = append(records, record{string(rec[:4]), data[offset : offset+length]})
+	}
+	for tag, table := range extra {
+		records = append(records, record{tag, table})
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].tag < records[j].tag })
+
+	out := append([]byte(nil), data[:12]...)
+	binary.BigEndian.PutUint16(out[4:], uint16(len(records)))
+	offset := 12 + 16*len(records)
+	var body []byte
+	for _, r := range records {
+		out = append(out, r.tag...)
+		out = append(out, 0, 0, 0, 0)
+		out = binary.BigEndian.AppendUint32(out, uint32(offset+len(body)))
+		out = binary.BigEndian.AppendUint32(out, uint32(len(r.data)))
+		body = append(body, r.data...)
+		for len(body)%4 != 0 {
+			body = append(body, 0)
+		}
+	}
+	return append(out, body...)
+}
+
+// shapingTestFont returns Go Regular with a kern pair for "AV", the
+// standard ligatures fi and ffi mapped onto the glyphs of '1' and '2', and
+// an "smcp" feature replacing 'a' with 'A'.
+func shapingTestFont(t *testing.T) gfx.ShapingFont {
+	t.Helper()
+	plain, err := gfx.ParseFont(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sf := plain.(gfx.ShapingFont)
+	gid := func(r rune) int { return int(sf.GlyphIndex(r)) }
+
+	kern := concat(u16s(0, 1), u16s(0, 14+6, 1, 1, 6, 0, 0), u16s(gid('A'), gid('V'), -205))
+
+	// ligature substitution format 1 for the glyph f
+	ligSet := concat(u16s(2, 6, 14), u16s(gid('2'), 3, gid('f'), gid('i')), u16s(gid('1'), 2, gid('i')))
+	ligature := concat(u16s(1, 8, 1, 14), u16s(1, 1, gid('f')), ligSet)
+	// single substitution format 2 for the glyph a
+	single := concat(u16s(2, 8, 1, gid('A')), u16s(1, 1, gid('a')))
+
+	lookups := [][]byte{concat(u16s(4, 0, 1, 8), ligature), concat(u16s(1, 0, 1, 8), single)}
+	lookupList := concat(u16s(2, 6, 6+len(lookups[0])), lookups[0], lookups[1])
+	featureList := concat(u16s(2), []byte("liga"), u16s(14), []byte("smcp"), u16s(20), u16s(0, 1, 0), u16s(0, 1, 1))
+	scriptList := u16s(0)
+	gsub := concat(u16s(1, 0, 10, 12, 12+len(featureList)), scriptList, featureList, lookupList)
+
+	f, err := gfx.ParseFont(withTables(goregular.TTF, map[string][]byte{"GSUB": gsub, "kern": kern}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f.(gfx.ShapingFont)
+}
+
+func TestShapeLatin(t *testing.T) {
+	font := shapingTestFont(t)
+	shaper := gfx.NewShaper()
+
+	run := shaper.Shape("fine office", font)
+	want := []struct {
+		text    string
+		cluster int
+	}{{"fi", 0}, {"n", 2}, {"e", 3}, {" ", 4}, {"o", 5}, {"ffi", 6}, {"c", 9}, {"e", 10}}
+	if len(run.Glyphs) != len(want) {
+		t.Fatalf("shaped %d glyphs, want %d: %+v", len(run.Glyphs), len(want), run.Glyphs)
+	}
+	for i, w := range want {
+		if g := run.Glyphs[i]; string(g.Runes) != w.text || g.Cluster != w.cluster {
+			t.Errorf("glyph %d covers %q at %d, want %q at %d", i, string(g.Runes), g.Cluster, w.text, w.cluster)
+		}
+	}
+	if run.Glyphs[0].ID != font.GlyphIndex('1') || run.Glyphs[5].ID != font.GlyphIndex('2') {
+		t.Errorf("ligature glyphs = %v, %v", run.Glyphs[0].ID, run.Glyphs[5].ID)
+	}
+	if run.String() != "fine office" {
+		t.Errorf("run text = %q", run.String())
+	}
+
+	shaper.Disable(gfx.FeatureStandardLigatures)
+	if n := len(shaper.Shape("fine office", font).Glyphs); n != 11 {
+		t.Errorf("shaped %d glyphs without ligatures, want 11", n)
+	}
+
+	kerned := shaper.Shape("AV", font)
+	plain := font.GlyphAdvance(font.GlyphIndex('A'))
+	if a := kerned.Glyphs[0].Advance; math.Abs(a-(plain-205.0/2048)) > 1e-9 {
+		t.Errorf("kerned advance of A = %v, want %v", a, plain-205.0/2048)
+	}
+	shaper.Disable(gfx.FeatureKerning)
+	if a := shaper.Shape("AV", font).Glyphs[0].Advance; a != plain {
+		t.Errorf("advance of A without kerning = %v, want %v", a, plain)
+	}
+
+	shaper.Enable("smcp")
+	if g := shaper.Shape("a", font).Glyphs[0]; g.ID != font.GlyphIndex('A') || string(g.Runes) != "a" {
+		t.Errorf("smcp glyph for a = %+v", g)
+	}
+}
+
+func TestShapeCyrillicGreek(t *testing.T) {
+	font := shapingTestFont(t)
+	for _, text := range []string{"Привет, мир", "Γειά σου κόσμε"} {
+		run := gfx.NewShaper().Shape(text, font)
+		if run.String() != text || len(run.Glyphs) != len([]rune(text)) {
+			t.Errorf("%q shaped into %d glyphs covering %q", text, len(run.Glyphs), run.String())
+			continue
+		}
+		var advance float64
+		for _, g := range run.Glyphs {
+			if g.ID == 0 {
+				t.Errorf("%q: no glyph for %q", text, string(g.Runes))
+			}
+			if g.Advance != font.GlyphAdvance(g.ID) {
+				t.Errorf("%q: advance of %q = %v, want %v", text, string(g.Runes), g.Advance, font.GlyphAdvance(g.ID))
+			}
+			advance += g.Advance
+		}
+		if run.Advance() != advance {
+			t.Errorf("%q: run advance = %v, want %v", text, run.Advance(), advance)
+		}
+	}
+}
+
+func TestShaperZeroValue(t *testing.T) {
+	font := shapingTestFont(t)
+
+	var shaper gfx.Shaper
+	if n := len(shaper.Shape("fi", font).Glyphs); n != 2 {
+		t.Errorf("zero shaper shaped %d glyphs, want 2", n)
+	}
+	shaper.Enable(gfx.FeatureStandardLigatures)
+	if n := len(shaper.Shape("fi", font).Glyphs); n != 1 {
+		t.Errorf("shaped %d glyphs with liga enabled, want 1", n)
+	}
+
+	var disabled gfx.Shaper
+	disabled.Disable(gfx.FeatureKerning)
+	if disabled.Features[gfx.FeatureKerning] {
+		t.Errorf("kern enabled after Disable")
+	}
+}
@@ -0,0 +1,330 @@
+package gfx
+
+import "math"
+
+// Simplify returns p with each run of consecutive lines thinned by the
+// Ramer–Douglas–Peucker algorithm, keeping only the points needed for the
+// lines to stay within tolerance of the original ones. Curves, subpaths and
+// close flags are kept as they are.
+func (p *Path) Simplify(tolerance float64) *Path {
+	return mapLineRuns(p, func(out *Path, points []Point, ring bool) {
+		for _, pt := range douglasPeucker(points, tolerance)[1:] {
+			out.LineTo(pt.X, pt.Y)
+		}
+	})
+}
+
+// Clean returns p without the segments that go nowhere: lines and curves
+// whose points all lie within tolerance of where they start, lines closing a
+// subpath that the close already draws, and movetos followed directly by
+// another moveto.
+func (p *Path) Clean(tolerance float64) *Path {
+	out := new(Path)
+	subpaths := splitSubpaths(p)
+	for i, sub := range subpaths {
+		if len(sub.segments) == 0 && !sub.closed && i+1 < len(subpaths) {
+			continue
+		}
+
+		var kept [][]Point
+		last := sub.start
+		for _, seg := range sub.segments {
+			short := true
+			for _, pt := range seg[1:] {
+				short = short && pt.DistanceTo(last) <= tolerance
+			}
+			if !short {
+				// start from the end of the last segment kept
+				kept = append(kept, append([]Point{last}, seg[1:]...))
+				last = seg[len(seg)-1]
+			}
+		}
+		if n := len(kept); sub.closed && n > 0 && len(kept[n-1]) == 2 && kept[n-1][1].DistanceTo(sub.start) <= tolerance {
+			kept = kept[:n-1]
+		}
+
+		sub.segments = kept
+		sub.appendTo(out)
+	}
+	return out
+}
+
+// MergeCollinear returns p with consecutive lines running on in the same
+// direction merged into one wherever the merged line passes within
+// tolerance of the points it replaces. Lines doubling back are kept.
+func (p *Path) MergeCollinear(tolerance float64) *Path {
+	return mapLineRuns(p, func(out *Path, points []Point, ring bool) {
+		anchor := 0
+		for i := 1; i < len(points)-1; i++ {
+			if !collinearRun(points[anchor:i+2], tolerance) {
+				out.LineTo(points[i].X, points[i].Y)
+				anchor = i
+			}
+		}
+		out.LineTo(points[len(points)-1].X, points[len(points)-1].Y)
+	})
+}
+
+// collinearRun reports whether the line from the first to the last of points
+// passes within tolerance of all of them, visiting them in order.
+func collinearRun(points []Point, tolerance float64) bool {
+	a, c := points[0], points[len(points)-1]
+	for k := 1; k < len(points)-1; k++ {
+		if points[k].Sub(points[k-1]).Dot(c.Sub(a)) < 0 || pointSegmentDistance(points[k], a, c) > tolerance {
+			return false
+		}
+	}
+	return points[len(points)-1].Sub(points[len(points)-2]).Dot(c.Sub(a)) >= 0
+}
+
+// fitCornerAngle is the smallest turn FitCurves keeps as a corner.
+const fitCornerAngle = math.Pi / 3
+
+// FitCurves returns p with each run of consecutive lines replaced by cubic
+// curves fitted by least squares, as in Schneider's algorithm from Graphics
+// Gems, passing within tolerance of every point of the run. Turns of 60
+// degrees or more stay corners, and a closed subpath of lines is fitted
+// smoothly through its start unless it has a corner there. Curves,
+// subpaths and close flags are kept as they are.
+func (p *Path) FitCurves(tolerance float64) *Path {
+	return mapLineRuns(p, func(out *Path, points []Point, ring bool) {
+		pts := []Point{points[0]}
+		for _, pt := range points[1:] {
+			if pt != pts[len(pts)-1] {
+				pts = append(pts, pt)
+			}
+		}
+		n := len(pts)
+		if n < 3 {
+			out.LineTo(pts[n-1].X, pts[n-1].Y)
+			return
+		}
+
+		corner := func(a, b, c Point) bool {
+			d0, d1 := b.Sub(a), c.Sub(b)
+			return math.Abs(math.Atan2(d0.Cross(d1), d0.Dot(d1))) >= fitCornerAngle
+		}
+		ends := []int{0}
+		for i := 1; i < n-1; i++ {
+			if corner(pts[i-1], pts[i], pts[i+1]) {
+				ends = append(ends, i)
+			}
+		}
+		ends = append(ends, n-1)
+
+		startTangent, endTangent := pts[1].Sub(pts[0]).Normalize(), pts[n-2].Sub(pts[n-1]).Normalize()
+		if ring && n > 3 && !corner(pts[n-2], pts[0], pts[1]) {
+			startTangent = pts[1].Sub(pts[n-2]).Normalize()
+			endTangent = startTangent.Mul(-1)
+		}
+
+		emit := func(bez []Point) {
+			if len(bez) == 2 {
+				out.LineTo(bez[1].X, bez[1].Y)
+			} else {
+				out.CubicCurveTo(bez[1].X, bez[1].Y, bez[2].X, bez[2].Y, bez[3].X, bez[3].Y)
+			}
+		}
+		for k := 1; k < len(ends); k++ {
+			a, b := ends[k-1], ends[k]
+			t1, t2 := pts[a+1].Sub(pts[a]).Normalize(), pts[b-1].Sub(pts[b]).Normalize()
+			if a == 0 {
+				t1 = startTangent
+			}
+			if b == n-1 {
+				t2 = endTangent
+			}
+			fitCubic(pts[a:b+1], t1, t2, tolerance, emit)
+		}
+	})
+}
+
+// fitCubic fits a cubic curve to points leaving the first along t1 and
+// arriving at the last from t2, both unit vectors pointing into the curve.
+// Where no curve comes within tolerance of every point it splits at the
+// worst point and fits both halves. Two points make a line.
+func fitCubic(points []Point, t1, t2 Point, tolerance float64, emit func([]Point)) {
+	if len(points) == 2 {
+		emit(points)
+		return
+	}
+
+	u := chordLengthParams(points)
+	bez := leastSquaresCubic(points, u, t1, t2)
+	worst, split := cubicFitError(points, u, bez, tolerance)
+	if worst <= tolerance {
+		emit(bez)
+		return
+	}
+	// a near miss is often fixed by moving the parameters closer to the
+	// nearest points on the curve
+	if worst <= 4*tolerance {
+		for i := 0; i < 4; i++ {
+			u = reparameterize(points, u, bez)
+			bez = leastSquaresCubic(points, u, t1, t2)
+			if worst, split = cubicFitError(points, u, bez, tolerance); worst <= tolerance {
+				emit(bez)
+				return
+			}
+		}
+	}
+
+	center := points[split-1].Sub(points[split+1]).Normalize()
+	if center == (Point{}) {
+		center = points[split-1].Sub(points[split]).Normalize()
+	}
+	fitCubic(points[:split+1], t1, center, tolerance, emit)
+	fitCubic(points[split:], center.Mul(-1), t2, tolerance, emit)
+}
+
+// chordLengthParams returns parameters for points in proportion to the
+// distance along them.
+func chordLengthParams(points []Point) []float64 {
+	u := make([]float64, len(points))
+	for i := 1; i < len(points); i++ {
+		u[i] = u[i-1] + points[i].DistanceTo(points[i-1])
+	}
+	for i := range u {
+		u[i] /= u[len(u)-1]
+	}
+	return u
+}
+
+// leastSquaresCubic returns the cubic curve from the first to the last of
+// points with tangents t1 and t2 whose control point distances best fit the
+// points at parameters u.
+func leastSquaresCubic(points []Point, u []float64, t1, t2 Point) []Point {
+	first, last := points[0], points[len(points)-1]
+	var c00, c01, c11, x0, x1 float64
+	for i, t := range u {
+		mt := 1 - t
+		b0, b1, b2, b3 := mt*mt*mt, 3*t*mt*mt, 3*t*t*mt, t*t*t
+		a1, a2 := t1.Mul(b1), t2.Mul(b2)
+		c00 += a1.Dot(a1)
+		c01 += a1.Dot(a2)
+		c11 += a2.Dot(a2)
+		rest := points[i].Sub(first.Mul(b0 + b1)).Sub(last.Mul(b2 + b3))
+		x0 += a1.Dot(rest)
+		x1 += a2.Dot(rest)
+	}
+
+	chord := first.DistanceTo(last)
+	alpha1, alpha2 := chord/3, chord/3
+	if det := c00*c11 - c01*c01; math.Abs(det) > 1e-12*c00*c11 {
+		a1, a2 := (x0*c11-x1*c01)/det, (c00*x1-c01*x0)/det
+		// control points on the wrong side or on the end points fall back
+		// to the usual third of the chord
+		if a1 > 1e-6*chord && a2 > 1e-6*chord {
+			alpha1, alpha2 = a1, a2
+		}
+	}
+	return []Point{first, first.Add(t1.Mul(alpha1)), last.Add(t2.Mul(alpha2)), last}
+}
+
+// cubicFitError returns the largest distance between a point and the curve,
+// and the index of that point. The distance to the curve at the point's
+// parameter is taken where it is within tolerance, and the true distance
+// otherwise.
+func cubicFitError(points []Point, u []float64, bez []Point, tolerance float64) (worst float64, index int) {
+	index = len(points) / 2
+	for i := 1; i < len(points)-1; i++ {
+		d := bezierPoint(bez, u[i]).DistanceTo(points[i])
+		if d > tolerance {
+			_, q := bezierNearest(bez, points[i])
+			d = q.DistanceTo(points[i])
+		}
+		if d > worst {
+			worst, index = d, i
+		}
+	}
+	return
+}
+
+// reparameterize moves each parameter a Newton step closer to the point on
+// the curve nearest its point.
+func reparameterize(points []Point, u []float64, bez []Point) []float64 {
+	next := make([]float64, len(u))
+	for i, t := range u {
+		d := bezierPoint(bez, t).Sub(points[i])
+		d1 := bezierDerivative(bez, t)
+		d2 := bez[2].Sub(bez[1].Mul(2)).Add(bez[0]).Mul(6 * (1 - t)).Add(bez[3].Sub(bez[2].Mul(2)).Add(bez[1]).Mul(6 * t))
+		next[i] = t
+		if den := d1.Dot(d1) + d.Dot(d2); den != 0 {
+			next[i] = math.Max(0, math.Min(1, t-d.Dot(d1)/den))
+		}
+	}
+	return next
+}
+
+// douglasPeucker returns the points of a polyline the Ramer–Douglas–Peucker
+// algorithm keeps for tolerance, always including both ends.
+func douglasPeucker(points []Point, tolerance float64) []Point {
+	n := len(points)
+	keep := make([]bool, n)
+	keep[0], keep[n-1] = true, true
+	stack := [][2]int{{0, n - 1}}
+	for len(stack) > 0 {
+		r := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		far, dist := -1, tolerance
+		for i := r[0] + 1; i < r[1]; i++ {
+			if d := pointSegmentDistance(points[i], points[r[0]], points[r[1]]); d > dist {
+				far, dist = i, d
+			}
+		}
+		if far >= 0 {
+			keep[far] = true
+			stack = append(stack, [2]int{r[0], far}, [2]int{far, r[1]})
+		}
+	}
+
+	kept := make([]Point, 0, n)
+	for i, pt := range points {
+		if keep[i] {
+			kept = append(kept, pt)
+		}
+	}
+	return kept
+}
+
+// mapLineRuns rebuilds p, passing every run of consecutive lines to fn as
+// the points from the run's start to its end, with the line closing a
+// subpath included. fn adds its replacement for the run to out, which is at
+// the run's start, and must end at the run's end. ring is set if the run is
+// a whole closed subpath.
+func mapLineRuns(p *Path, fn func(out *Path, points []Point, ring bool)) *Path {
+	out := new(Path)
+	for _, sub := range splitSubpaths(p) {
+		out.MoveTo(sub.start.X, sub.start.Y)
+		run := []Point{sub.start}
+		ring := sub.closed
+		for _, seg := range sub.segments {
+			if len(seg) == 2 {
+				run = append(run, seg[1])
+				continue
+			}
+			if len(run) > 1 {
+				fn(out, run, false)
+			}
+			out.appendSegment(seg)
+			run, ring = []Point{seg[len(seg)-1]}, false
+		}
+
+		closing := sub.closed && run[len(run)-1] != sub.start
+		if closing {
+			run = append(run, sub.start)
+		}
+		if len(run) > 1 {
+			fn(out, run, ring)
+		}
+		// the close draws the closing line again
+		if n := len(out.Components); closing && out.Components[n-1] == LineToComp && out.Points[len(out.Points)-1] == sub.start {
+			out.Components = out.Components[:n-1]
+			out.Points = out.Points[:len(out.Points)-1]
+		}
+		if sub.closed {
+			out.Close()
+		}
+	}
+	return out
+}
@@ -0,0 +1,91 @@
+package gfx_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+func TestPathSimplify(t *testing.T) {
+	// a noisy square, with a curve kept as it is
+	p := new(gfx.Path)
+	p.MoveTo(0, 0)
+	for i := 1; i < 100; i++ {
+		p.LineTo(float64(i)/10, 0.01*math.Sin(float64(i)))
+	}
+	p.LineTo(10, 0)
+	p.LineTo(10, 10)
+	p.LineTo(0, 10)
+	p.Close()
+	p.MoveTo(20, 0)
+	p.LineTo(21, 0.01)
+	p.LineTo(22, 0)
+	p.QuadCurveTo(23, 1, 24, 0)
+
+	got := p.Simplify(0.1).SVG()
+	if want := "M0 0 10 0 10 10 0 10ZM20 0 22 0Q23 1 24 0"; got != want {
+		t.Errorf("Simplify = %q, want %q", got, want)
+	}
+}
+
+func TestPathClean(t *testing.T) {
+	p, err := gfx.ParseSVGPath("M5 5M0 0L0 0 1 0 1 1e-9Q1 0 1 0L1 1 0 1 0 0ZM3 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := p.Clean(1e-6).SVG(), "M0 0 1 0 1 1 0 1ZM3 3"; got != want {
+		t.Errorf("Clean = %q, want %q", got, want)
+	}
+}
+
+func TestPathMergeCollinear(t *testing.T) {
+	p, err := gfx.ParseSVGPath("M0 0 1 0 2 0 2 1 2 2 1 2 0 2ZM5 0 7 0 6 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := p.MergeCollinear(1e-9).SVG(), "M0 0 2 0 2 2 0 2ZM5 0 7 0 6 0"; got != want {
+		t.Errorf("MergeCollinear = %q, want %q", got, want)
+	}
+}
+
+func TestPathFitCurves(t *testing.T) {
+	const tolerance = 0.01
+	p := new(gfx.Path)
+	var samples []gfx.Point
+	for i := 0; i < 64; i++ {
+		sin, cos := math.Sincos(2 * math.Pi * float64(i) / 64)
+		samples = append(samples, gfx.Point{10 * cos, 10 * sin})
+	}
+	p.MoveTo(samples[0].X, samples[0].Y)
+	for _, pt := range samples[1:] {
+		p.LineTo(pt.X, pt.Y)
+	}
+	p.Close()
+	// a right angle stays a corner
+	p.MoveTo(20, 0)
+	p.LineTo(30, 0)
+	p.LineTo(30, 10)
+
+	fit := p.FitCurves(tolerance)
+	curves, lines := 0, 0
+	for _, cmd := range fit.Components {
+		switch cmd {
+		case gfx.CubicCurveToComp:
+			curves++
+		case gfx.LineToComp:
+			lines++
+		}
+	}
+	if curves == 0 || curves > 8 || lines != 2 {
+		t.Errorf("fitted %d curves and %d lines:\n%v", curves, lines, fit)
+	}
+	if fit.Components[len(fit.Components)-4] != gfx.ClosePathComp {
+		t.Errorf("closed circle left open:\n%v", fit)
+	}
+	for _, pt := range samples {
+		if d := fit.DistanceTo(pt); d > tolerance {
+			t.Errorf("sample %v is %v from the fitted path", pt, d)
+		}
+	}
+}
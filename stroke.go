@@ -1,5 +1,8 @@
 package gfx
 
+// DefaultMiterLimit is the initial miter limit in PDF and PostScript.
+const DefaultMiterLimit = 10
+
 type Stroke struct {
 	LineCap   LineCap
 	LineJoin  LineJoin
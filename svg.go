@@ -0,0 +1,272 @@
+package gfx
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ParseSVGPath parses SVG path data, as found in the d attribute of a path
+// element. All commands are supported in absolute and relative form;
+// elliptical arcs become cubic curves. On an error the path parsed up to the
+// bad command is returned with it, as SVG renderers draw that much.
+func ParseSVGPath(d string) (*Path, error) {
+	s := svgPathScanner{data: d}
+	path := new(Path)
+
+	var cur, start, ctrl Point
+	var cmd, prev byte
+	for {
+		s.skipSpace()
+		if s.pos >= len(s.data) {
+			return path, nil
+		}
+
+		// commands repeat while numbers follow, a moveto turning into a
+		// lineto
+		if c := s.data[s.pos]; strings.IndexByte("MmZzLlHhVvCcSsQqTtAa", c) >= 0 {
+			cmd = c
+			s.pos++
+		} else if cmd == 0 || cmd == 'Z' || cmd == 'z' {
+			return path, fmt.Errorf("svg path: expected command at offset %d", s.pos)
+		} else if cmd == 'M' {
+			cmd = 'L'
+		} else if cmd == 'm' {
+			cmd = 'l'
+		}
+		if prev == 0 && cmd != 'M' && cmd != 'm' {
+			return path, fmt.Errorf("svg path: path data must start with a moveto")
+		}
+
+		var base Point
+		if cmd >= 'a' {
+			base = cur
+		}
+
+		var args [7]float64
+		var err error
+		switch n := svgArgCount[cmd|0x20]; {
+		case cmd == 'A' || cmd == 'a':
+			err = s.arcArgs(&args)
+		default:
+			for i := 0; i < n && err == nil; i++ {
+				args[i], err = s.number()
+			}
+		}
+		if err != nil {
+			return path, err
+		}
+
+		// build the segment on a scratch path to check it before adding it
+		var seg Path
+		seg.MoveTo(cur.X, cur.Y)
+		next, nextCtrl := cur, Point{}
+		switch cmd | 0x20 {
+		case 'm':
+			next = Point{base.X + args[0], base.Y + args[1]}
+		case 'z':
+			next = start
+		case 'l':
+			next = Point{base.X + args[0], base.Y + args[1]}
+			seg.LineTo(next.X, next.Y)
+		case 'h':
+			next = Point{base.X + args[0], cur.Y}
+			seg.LineTo(next.X, next.Y)
+		case 'v':
+			next = Point{cur.X, base.Y + args[0]}
+			seg.LineTo(next.X, next.Y)
+		case 'c', 's':
+			var c1 Point
+			if cmd|0x20 == 'c' {
+				c1 = Point{base.X + args[0], base.Y + args[1]}
+				args[0], args[1], args[2], args[3] = args[2], args[3], args[4], args[5]
+			} else if c1 = cur; strings.IndexByte("CcSs", prev) >= 0 {
+				c1 = cur.Add(cur.Sub(ctrl))
+			}
+			nextCtrl = Point{base.X + args[0], base.Y + args[1]}
+			next = Point{base.X + args[2], base.Y + args[3]}
+			seg.CubicCurveTo(c1.X, c1.Y, nextCtrl.X, nextCtrl.Y, next.X, next.Y)
+		case 'q', 't':
+			if cmd|0x20 == 'q' {
+				nextCtrl = Point{base.X + args[0], base.Y + args[1]}
+				args[0], args[1] = args[2], args[3]
+			} else if nextCtrl = cur; strings.IndexByte("QqTt", prev) >= 0 {
+				nextCtrl = cur.Add(cur.Sub(ctrl))
+			}
+			next = Point{base.X + args[0], base.Y + args[1]}
+			seg.QuadCurveTo(nextCtrl.X, nextCtrl.Y, next.X, next.Y)
+		case 'a':
+			next = Point{base.X + args[5], base.Y + args[6]}
+			seg.svgArcTo(cur.X, cur.Y, args[0], args[1], args[2], args[3] != 0, args[4] != 0, next.X, next.Y)
+		}
+		for _, pt := range append(seg.Points, next) {
+			if math.IsInf(pt.X, 0) || math.IsInf(pt.Y, 0) || math.IsNaN(pt.X) || math.IsNaN(pt.Y) {
+				return path, fmt.Errorf("svg path: coordinate out of range at offset %d", s.pos)
+			}
+		}
+
+		switch cmd | 0x20 {
+		case 'm':
+			path.MoveTo(next.X, next.Y)
+			start = next
+		case 'z':
+			path.Close()
+		default:
+			path.Components = append(path.Components, seg.Components[1:]...)
+			path.Points = append(path.Points, seg.Points[1:]...)
+			path.x, path.y = next.X, next.Y
+		}
+		cur, ctrl, prev = next, nextCtrl, cmd
+	}
+}
+
+// svgArgCount is the number of arguments each command takes.
+var svgArgCount = map[byte]int{'m': 2, 'z': 0, 'l': 2, 'h': 1, 'v': 1, 'c': 6, 's': 4, 'q': 4, 't': 2, 'a': 7}
+
+type svgPathScanner struct {
+	data string
+	pos  int
+}
+
+// skipSpace skips white space and at most one comma.
+func (s *svgPathScanner) skipSpace() {
+	comma := false
+	for s.pos < len(s.data) {
+		switch c := s.data[s.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+		case c == ',' && !comma:
+			comma = true
+		default:
+			return
+		}
+		s.pos++
+	}
+}
+
+func (s *svgPathScanner) number() (float64, error) {
+	s.skipSpace()
+	start := s.pos
+	digits := func() int {
+		n := 0
+		for s.pos < len(s.data) && s.data[s.pos] >= '0' && s.data[s.pos] <= '9' {
+			s.pos++
+			n++
+		}
+		return n
+	}
+
+	if s.pos < len(s.data) && (s.data[s.pos] == '+' || s.data[s.pos] == '-') {
+		s.pos++
+	}
+	n := digits()
+	if s.pos < len(s.data) && s.data[s.pos] == '.' {
+		s.pos++
+		n += digits()
+	}
+	if n == 0 {
+		s.pos = start
+		return 0, fmt.Errorf("svg path: expected number at offset %d", start)
+	}
+	if s.pos < len(s.data) && (s.data[s.pos] == 'e' || s.data[s.pos] == 'E') {
+		mark := s.pos
+		s.pos++
+		if s.pos < len(s.data) && (s.data[s.pos] == '+' || s.data[s.pos] == '-') {
+			s.pos++
+		}
+		if digits() == 0 {
+			s.pos = mark
+		}
+	}
+
+	v, err := strconv.ParseFloat(s.data[start:s.pos], 64)
+	if err != nil {
+		return 0, fmt.Errorf("svg path: number out of range at offset %d", start)
+	}
+	return v, nil
+}
+
+// flag reads an arc flag, which needs no separator from what follows.
+func (s *svgPathScanner) flag() (float64, error) {
+	s.skipSpace()
+	if s.pos < len(s.data) && (s.data[s.pos] == '0' || s.data[s.pos] == '1') {
+		s.pos++
+		return float64(s.data[s.pos-1] - '0'), nil
+	}
+	return 0, fmt.Errorf("svg path: expected flag at offset %d", s.pos)
+}
+
+func (s *svgPathScanner) arcArgs(args *[7]float64) (err error) {
+	for i := 0; i < 7 && err == nil; i++ {
+		if i == 3 || i == 4 {
+			args[i], err = s.flag()
+		} else {
+			args[i], err = s.number()
+		}
+	}
+	return
+}
+
+// SVG returns the path as compact SVG path data with absolute coordinates,
+// which ParseSVGPath reads back exactly.
+func (p *Path) SVG() string {
+	var b strings.Builder
+	var prev byte
+	last := ""
+
+	command := func(cmd byte) {
+		// repeated commands other than moveto and closepath may be left out,
+		// as may a lineto following a moveto
+		if cmd != prev || cmd == 'M' || cmd == 'Z' {
+			if !(cmd == 'L' && prev == 'M') {
+				b.WriteByte(cmd)
+				last = ""
+			}
+		}
+		prev = cmd
+	}
+	number := func(v float64) {
+		s := svgNumber(v)
+		if last != "" && s[0] != '-' && !(s[0] == '.' && strings.ContainsAny(last, ".e")) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(s)
+		last = s
+	}
+
+	for i, j := 0, 0; i < len(p.Components); i++ {
+		cmd := p.Components[i]
+		switch cmd {
+		case MoveToComp:
+			command('M')
+		case LineToComp:
+			command('L')
+		case QuadCurveToComp:
+			command('Q')
+		case CubicCurveToComp:
+			command('C')
+		case ClosePathComp:
+			command('Z')
+		}
+		for _, pt := range p.Points[j : j+cmd.PointCount()] {
+			number(pt.X)
+			number(pt.Y)
+		}
+		j += cmd.PointCount()
+	}
+	return b.String()
+}
+
+// svgNumber formats v as briefly as possible while reading back exactly.
+func svgNumber(v float64) string {
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	if g := strconv.FormatFloat(v, 'g', -1, 64); len(g) < len(s) {
+		s = g
+	}
+	if strings.HasPrefix(s, "0.") {
+		s = s[1:]
+	} else if strings.HasPrefix(s, "-0.") {
+		s = "-" + s[2:]
+	}
+	return s
+}
@@ -0,0 +1,112 @@
+package gfx_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+func TestParseSVGPath(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{"M10 20L30 40", "M10 20 30 40"},
+		{"m10,20 l5-5 h10 v-10 z", "M10 20 15 15 25 15 25 5Z"},
+		{"M1 2 3 4 5 6", "M1 2 3 4 5 6"},
+		{"m1 2 3 4 5 6", "M1 2 4 6 9 12"},
+		{"M0 0C1 1 2 2 3 3S5 5 6 6", "M0 0C1 1 2 2 3 3 4 4 5 5 6 6"},
+		{"M0 0s1 1 2 2", "M0 0C0 0 1 1 2 2"},
+		{"M0 0Q1 1 2 0T4 0", "M0 0Q1 1 2 0 3-1 4 0"},
+		{"M0 0t2 0", "M0 0Q0 0 2 0"},
+		{"M.5.5-1-1e1", "M.5.5-1-10"},
+		{"M1 1z l1 0", "M1 1ZL2 1"},
+		{"M0 0A0 5 0 0 1 10 0", "M0 0 10 0"},
+		{"M0 0a5 5 0 0 0 0 0", "M0 0"},
+	}
+	for _, tt := range tests {
+		p, err := gfx.ParseSVGPath(tt.data)
+		if err != nil {
+			t.Errorf("ParseSVGPath(%q) error: %v", tt.data, err)
+			continue
+		}
+		if got := p.SVG(); got != tt.want {
+			t.Errorf("ParseSVGPath(%q).SVG() = %q, want %q", tt.data, got, tt.want)
+		}
+	}
+
+	for _, data := range []string{"", "L1 2", "M1", "M1 2Z3 4", "M1 2x", "M1e999 0", "M0 0A1 1 0 2 0 1 1"} {
+		if data == "" {
+			if p, err := gfx.ParseSVGPath(data); err != nil || !p.IsEmpty() {
+				t.Errorf("ParseSVGPath(%q) = %v, %v", data, p, err)
+			}
+			continue
+		}
+		if _, err := gfx.ParseSVGPath(data); err == nil {
+			t.Errorf("ParseSVGPath(%q) succeeded", data)
+		}
+	}
+}
+
+func TestParseSVGPathArc(t *testing.T) {
+	// radii too small to reach the end point grow to a half circle, on the
+	// side the sweep flag picks; cubic arcs run a little long
+	for _, tt := range []struct {
+		data string
+		mid  gfx.Point
+	}{
+		{"M0 0A1 1 0 0 1 10 0", gfx.Point{5, -5}},
+		{"M0 0A1 1 0 0 0 10 0", gfx.Point{5, 5}},
+		{"M0 0A1 1 0 1 1 10 0", gfx.Point{5, -5}},
+	} {
+		p, err := gfx.ParseSVGPath(tt.data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := gfx.NewPathMeasure(p)
+		if math.Abs(m.Length()-5*math.Pi) > 5*math.Pi*1e-3 {
+			t.Errorf("%q: length = %v, want %v", tt.data, m.Length(), 5*math.Pi)
+		}
+		if pt, _ := m.PointAt(m.Length() / 2); pt.DistanceTo(tt.mid) > 1e-3 {
+			t.Errorf("%q: midpoint = %v, want %v", tt.data, pt, tt.mid)
+		}
+		if x, y := p.LastPoint(); x != 10 || y != 0 {
+			t.Errorf("%q: ends at %v, %v", tt.data, x, y)
+		}
+	}
+
+	// the large arc of a circle of radius 5 between points 5 apart
+	p, err := gfx.ParseSVGPath("M0 0A5 5 0 1 1 5 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := gfx.NewPathMeasure(p).Length(); math.Abs(l-5*5*math.Pi/3) > 5*5*math.Pi/3*1e-3 {
+		t.Errorf("large arc length = %v, want %v", l, 5*5*math.Pi/3)
+	}
+}
+
+func FuzzParseSVGPath(f *testing.F) {
+	for _, data := range []string{
+		"M10 20L30 40Z",
+		"m1 2 3 4h5v6z",
+		"M0 0C1 2 3 4 5 6s7 8 9 10",
+		"M0 0q1 2 3 4t5 6",
+		"M0 0a10 20 30 1 0 40 50",
+		"M.5.5-1-1e1",
+		"M1e300 1e300l1e300 1e300",
+	} {
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data string) {
+		p, _ := gfx.ParseSVGPath(data)
+		q, err := gfx.ParseSVGPath(p.SVG())
+		if err != nil {
+			t.Fatalf("parsing %q: %v", p.SVG(), err)
+		}
+		if !reflect.DeepEqual(p.Components, q.Components) || !reflect.DeepEqual(p.Points, q.Points) {
+			t.Fatalf("%q does not round trip: %v != %v", p.SVG(), p, q)
+		}
+	})
+}
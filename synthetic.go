@@ -0,0 +1,293 @@
+package gfx
+
+import "math"
+
+// SyntheticBoldStemRatio is the share of the base font's stem width by which
+// each side of a glyph outline is pushed outwards when emboldening. Bold
+// faces commonly have stems about one and a half times as wide as regular
+// ones. The advance grows by twice the resulting distance.
+const SyntheticBoldStemRatio = 0.25
+
+// SyntheticBoldStrength is the emboldening distance, in em units, used when
+// the stem width of the base font cannot be measured.
+const SyntheticBoldStrength = 1.0 / 48
+
+// SyntheticObliqueSkew is the horizontal shear applied per unit of height
+// when slanting a glyph, roughly 12 degrees.
+const SyntheticObliqueSkew = 0.2126
+
+// SynthesizeFont returns a font that renders f with the bold and italic
+// styles in style that f does not provide itself. If f already has every
+// requested style it is returned unchanged. The returned font reports the
+// combined style in Info, with the synthesized bits in FontData.Synthetic.
+// Emboldening is relative to the stem width of f, see SyntheticBoldStemRatio.
+func SynthesizeFont(f Font, style FontStyle) Font {
+	missing := style &^ f.Info().Style & (FontStyleBold | FontStyleItalic)
+	if missing == 0 {
+		return f
+	}
+
+	sf, ok := f.(syntheticFont)
+	if !ok {
+		sf = syntheticFont{base: f}
+	}
+	if missing&FontStyleBold != 0 {
+		sf.strength = boldStrength(sf.base)
+	}
+	sf.style |= missing
+	return sf
+}
+
+// boldStrength returns the emboldening distance for f, measured from the
+// stem of its l or I. A stem's width is taken as the glyph's area over its
+// height.
+func boldStrength(f Font) float64 {
+	for _, chr := range "lI" {
+		if !FontHasGlyph(f, chr) {
+			continue
+		}
+		glyph := f.Glyph(chr, IdentityMatrix)
+		if glyph == nil || glyph.Path == nil {
+			continue
+		}
+		bounds := glyph.Path.Bounds()
+		if height, area := bounds.Y.Max-bounds.Y.Min, math.Abs(glyph.Path.SignedArea()); height > 0 && area > 0 {
+			return SyntheticBoldStemRatio * area / height
+		}
+	}
+	return SyntheticBoldStrength
+}
+
+// syntheticFont wraps a font, emboldening and slanting its outlines. It
+// implements ShapingFont so that shaping data of the base font is kept. It is
+// a value so that wrapping the same font twice gives equal fonts, which share
+// glyph cache entries.
+type syntheticFont struct {
+	base     Font
+	style    FontStyle
+	strength float64
+}
+
+func (f syntheticFont) Name() string { return f.base.Name() }
+
+func (f syntheticFont) Info() FontData {
+	info := f.base.Info()
+	info.Style |= f.style
+	info.Synthetic |= f.style
+	return info
+}
+
+func (f syntheticFont) BoundingBox() Rect {
+	bbox := f.base.BoundingBox()
+	if f.style&FontStyleBold != 0 {
+		s := f.strength
+		bbox = MakeRect(bbox.X.Min, bbox.Y.Min-s, bbox.X.Max+2*s, bbox.Y.Max+s)
+	}
+	if f.style&FontStyleItalic != 0 {
+		bbox = f.skew().TransformRect(bbox)
+	}
+	return bbox
+}
+
+func (f syntheticFont) Glyph(chr rune, trm Matrix) *Glyph {
+	return f.GlyphOutline(f.GlyphIndex(chr), trm)
+}
+
+func (f syntheticFont) Advance(chr rune, mode int) float64 {
+	if mode != 0 {
+		return f.base.Advance(chr, mode)
+	}
+	return f.GlyphAdvance(f.GlyphIndex(chr))
+}
+
+func (f syntheticFont) HasGlyph(chr rune) bool { return FontHasGlyph(f.base, chr) }
+
+func (f syntheticFont) GlyphIndex(chr rune) GlyphID {
+	if sf, ok := f.base.(ShapingFont); ok {
+		return sf.GlyphIndex(chr)
+	}
+	return GlyphID(chr)
+}
+
+func (f syntheticFont) GlyphAdvance(gid GlyphID) float64 {
+	var advance float64
+	if sf, ok := f.base.(ShapingFont); ok {
+		advance = sf.GlyphAdvance(gid)
+	} else {
+		advance = f.base.Advance(rune(gid), 0)
+	}
+	if f.style&FontStyleBold != 0 {
+		advance += 2 * f.strength
+	}
+	return advance
+}
+
+// GlyphOutline loads the base outline in em units, emboldens and slants it
+// there and then applies trm.
+func (f syntheticFont) GlyphOutline(gid GlyphID, trm Matrix) *Glyph {
+	glyph := glyphOutline(f.base, gid, IdentityMatrix)
+	if glyph == nil {
+		return nil
+	}
+
+	advance := glyph.Width
+	if glyph.Path != nil {
+		// the base font may hand out shared outlines
+		glyph = glyph.Copy()
+		if f.style&FontStyleBold != 0 {
+			emboldenPath(glyph.Path, f.strength)
+		}
+
+		m := IdentityMatrix
+		if f.style&FontStyleBold != 0 {
+			m = NewTranslationMatrix(f.strength, 0)
+		}
+		if f.style&FontStyleItalic != 0 {
+			m = m.Concat(f.skew())
+		}
+		m = m.Concat(trm)
+		glyph.Path.Transform(m)
+	}
+
+	if f.style&FontStyleBold != 0 {
+		advance += 2 * f.strength
+	}
+	glyph.Width = trm.TransformVec(Point{advance, 0}).X
+	return glyph
+}
+
+func (f syntheticFont) Kerning(left, right GlyphID) float64 {
+	if sf, ok := f.base.(ShapingFont); ok {
+		return sf.Kerning(left, right)
+	}
+	return 0
+}
+
+func (f syntheticFont) Substitutions(feature string) []SubstitutionLookup {
+	if sf, ok := f.base.(ShapingFont); ok {
+		return sf.Substitutions(feature)
+	}
+	return nil
+}
+
+func (f syntheticFont) skew() Matrix {
+	return Matrix{1, 0, SyntheticObliqueSkew, 1, 0, 0}
+}
+
+// emboldenPath pushes every contour of a y-up outline outwards by strength,
+// moving each point along the bisector of its adjacent edges. Counters move
+// inwards, so stems thicken on both sides. Control points are treated as
+// polygon vertices, which keeps curves close to parallel.
+func emboldenPath(path *Path, strength float64) {
+	var contours [][]int
+	var current []int
+	var area float64
+
+	flush := func() {
+		if len(current) > 0 {
+			contours = append(contours, current)
+			for i := range current {
+				p, q := path.Points[current[i]], path.Points[current[(i+1)%len(current)]]
+				area += p.Cross(q)
+			}
+		}
+		current = nil
+	}
+
+	for i, j := 0, 0; i < len(path.Components); i++ {
+		cmd := path.Components[i]
+		if cmd == MoveToComp || cmd == ClosePathComp {
+			flush()
+		}
+		for k := 0; k < cmd.PointCount(); k++ {
+			current = append(current, j+k)
+		}
+		j += cmd.PointCount()
+	}
+	flush()
+
+	// outward normal of an edge direction for the outline's orientation
+	sign := 1.0
+	if area < 0 {
+		sign = -1
+	}
+	normal := func(d Point) Point { return Point{d.Y * sign, -d.X * sign} }
+
+	shifted := make([]Point, len(path.Points))
+	copy(shifted, path.Points)
+	for _, contour := range contours {
+		n := len(contour)
+		if n < 3 {
+			continue
+		}
+
+		for i, idx := range contour {
+			pt := path.Points[idx]
+
+			prev := pt
+			for k := 1; k < n && prev.Eq(pt); k++ {
+				prev = path.Points[contour[(i-k+n)%n]]
+			}
+			next := pt
+			for k := 1; k < n && next.Eq(pt); k++ {
+				next = path.Points[contour[(i+k)%n]]
+			}
+			if prev.Eq(pt) || next.Eq(pt) {
+				continue
+			}
+
+			in := normal(pt.Sub(prev).Normalize())
+			out := normal(next.Sub(pt).Normalize())
+			d := 1 + in.Dot(out)
+			if d < 1.0/16 {
+				// nearly reversing edges; a bisector shift would explode
+				continue
+			}
+			shift := in.Add(out).Mul(strength / d)
+			if l := shift.Norm(); l > 4*strength {
+				shift = shift.Mul(4 * strength / l)
+			}
+			shifted[idx] = pt.Add(shift)
+		}
+	}
+	copy(path.Points, shifted)
+
+	if n := len(path.Points); n > 0 {
+		path.x, path.y = path.Points[n-1].X, path.Points[n-1].Y
+	}
+}
+
+// SyntheticFontCache wraps a FontCache and synthesizes bold and italic
+// styles from the closest face the cache holds when the exact style is
+// missing.
+type SyntheticFontCache struct {
+	FontCache
+}
+
+// NewSyntheticFontCache creates a cache that falls back to synthesized
+// styles for fonts missing from cache.
+func NewSyntheticFontCache(cache FontCache) *SyntheticFontCache {
+	return &SyntheticFontCache{FontCache: cache}
+}
+
+// Load returns the font matching data. If the cache has no face with the
+// requested style, the italic, bold and finally regular faces are tried in
+// turn and the missing styles are synthesized.
+func (c *SyntheticFontCache) Load(data FontData) (Font, error) {
+	font, err := c.FontCache.Load(data)
+	if err == nil {
+		return SynthesizeFont(font, data.Style), nil
+	}
+
+	for _, style := range []FontStyle{FontStyleItalic, FontStyleBold, FontStyleNormal} {
+		if style == data.Style || data.Style&style != style {
+			continue
+		}
+		fallback := data
+		fallback.Style = style
+		if font, ferr := c.FontCache.Load(fallback); ferr == nil {
+			return SynthesizeFont(font, data.Style), nil
+		}
+	}
+	return nil, err
+}
@@ -0,0 +1,96 @@
+package gfx_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bryanmatteson/gfx"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// stemWidth returns the width of the stem of l in em units.
+func stemWidth(font gfx.Font) float64 {
+	path := font.Glyph('l', gfx.IdentityMatrix).Path
+	b := path.Bounds()
+	return math.Abs(path.SignedArea()) / (b.Y.Max - b.Y.Min)
+}
+
+func TestSynthesizeBold(t *testing.T) {
+	regular, err := gfx.ParseFont(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bold, err := gfx.ParseFont(gobold.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if gfx.SynthesizeFont(bold, gfx.FontStyleBold) != bold {
+		t.Error("bold font was synthesized again")
+	}
+
+	synth := gfx.SynthesizeFont(regular, gfx.FontStyleBold)
+	info := synth.Info()
+	if !info.IsBold() || info.IsItalic() || info.Synthetic != gfx.FontStyleBold || synth.Name() != regular.Name() {
+		t.Errorf("synthetic bold info = %+v named %q", info, synth.Name())
+	}
+
+	// the emboldened stem comes close to the real bold one
+	if got, want := stemWidth(synth), stemWidth(bold); math.Abs(got-want) > 0.1*want {
+		t.Errorf("synthetic bold stem = %v, real bold stem = %v", got, want)
+	}
+
+	// the advance and bounding box grow by the stem growth
+	strength := gfx.SyntheticBoldStemRatio * stemWidth(regular)
+	for _, chr := range "lmW" {
+		if got, want := synth.Advance(chr, 0), regular.Advance(chr, 0)+2*strength; math.Abs(got-want) > 1e-9 {
+			t.Errorf("advance of %q = %v, want %v", chr, got, want)
+		}
+	}
+	base, box := regular.BoundingBox(), synth.BoundingBox()
+	if math.Abs((box.X.Max-box.X.Min)-(base.X.Max-base.X.Min)-2*strength) > 1e-9 ||
+		math.Abs((box.Y.Max-box.Y.Min)-(base.Y.Max-base.Y.Min)-2*strength) > 1e-9 {
+		t.Errorf("bounding box = %v, base %v, strength %v", box, base, strength)
+	}
+
+	glyph := synth.Glyph('o', gfx.NewTranslationMatrix(10, 20))
+	last := glyph.Path.Points[len(glyph.Path.Points)-1]
+	if x, y := glyph.Path.LastPoint(); x != last.X || y != last.Y {
+		t.Errorf("glyph current point = %v, %v, want %v", x, y, last)
+	}
+}
+
+func TestSynthesizeOblique(t *testing.T) {
+	regular, err := gfx.ParseFont(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	synth := gfx.SynthesizeFont(regular, gfx.FontStyleItalic)
+	if info := synth.Info(); !info.IsItalic() || info.IsBold() || info.Synthetic != gfx.FontStyleItalic {
+		t.Errorf("synthetic italic info = %+v", info)
+	}
+	if synth.Advance('H', 0) != regular.Advance('H', 0) {
+		t.Errorf("slanting changed the advance of H from %v to %v", regular.Advance('H', 0), synth.Advance('H', 0))
+	}
+
+	// the top of the H leans right, the baseline stays
+	upright, slanted := regular.Glyph('H', gfx.IdentityMatrix).Path.Bounds(), synth.Glyph('H', gfx.IdentityMatrix).Path.Bounds()
+	if shift := slanted.X.Max - upright.X.Max; math.Abs(shift-gfx.SyntheticObliqueSkew*upright.Y.Max) > 1e-9 {
+		t.Errorf("top of H moved %v, want %v", shift, gfx.SyntheticObliqueSkew*upright.Y.Max)
+	}
+	if slanted.X.Min != upright.X.Min {
+		t.Errorf("foot of H moved from %v to %v", upright.X.Min, slanted.X.Min)
+	}
+
+	base, box := regular.BoundingBox(), synth.BoundingBox()
+	if want := gfx.SyntheticObliqueSkew * base.Y.Max; math.Abs(box.X.Max-base.X.Max-want) > 1e-9 {
+		t.Errorf("bounding box = %v, base %v", box, base)
+	}
+
+	both := gfx.SynthesizeFont(synth, gfx.FontStyleBold|gfx.FontStyleItalic)
+	if info := both.Info(); info.Synthetic != gfx.FontStyleBold|gfx.FontStyleItalic {
+		t.Errorf("synthetic bold italic info = %+v", info)
+	}
+}
@@ -0,0 +1,306 @@
+package gfx
+
+import (
+	"math"
+	"sort"
+)
+
+// TableCell is a cell of a Table. Row and Col are the indices of its top
+// left grid cell; merged cells span more than one row or column.
+type TableCell struct {
+	Row, Col         int
+	RowSpan, ColSpan int
+	Rect             Rect
+}
+
+// Table is a grid found on a page. Rows are ordered top to bottom and
+// columns left to right; their ranges are the grid boundaries. Cells lists
+// every cell once, merged cells included, in row major order. HeaderRows is
+// the number of leading rows that head the columns, where known.
+type Table struct {
+	Bounds     Rect
+	Rows       []Range
+	Columns    []Range
+	Cells      []TableCell
+	HeaderRows int
+}
+
+// Cell returns the cell covering grid position (row, col).
+func (t Table) Cell(row, col int) (TableCell, bool) {
+	for _, cell := range t.Cells {
+		if row >= cell.Row && row < cell.Row+cell.RowSpan && col >= cell.Col && col < cell.Col+cell.ColSpan {
+			return cell, true
+		}
+	}
+	return TableCell{}, false
+}
+
+// TableOptions controls table detection from ruling lines.
+type TableOptions struct {
+	// SnapTolerance is the distance within which ruling positions are
+	// aligned and line ends are joined to the lines they nearly touch.
+	SnapTolerance float64
+	// MaxLineGap is the largest gap between collinear segments that are
+	// merged into one ruling, as in Lines.Smooth.
+	MaxLineGap float64
+	// MinCells is the smallest number of cells a grid needs to count as a
+	// table.
+	MinCells int
+}
+
+func DefaultTableOptions() TableOptions {
+	return TableOptions{SnapTolerance: 2, MaxLineGap: 2, MinCells: 2}
+}
+
+// FindTables assembles the horizontal and vertical ruling lines of a page
+// into tables. Nearly axis-aligned lines are straightened, rulings are
+// snapped and merged, and each group of intersecting rulings becomes a grid.
+// Grid cells not separated by a ruling are merged. Coordinates are taken to
+// be y-up. Tables are returned in reading order.
+func FindTables(lines Lines, opts TableOptions) (tables []Table) {
+	tol := opts.SnapTolerance
+
+	var rulings Lines
+	for _, line := range lines {
+		switch {
+		case math.Abs(line.End.Y-line.Start.Y) <= tol && math.Abs(line.End.X-line.Start.X) > tol:
+			y := (line.Start.Y + line.End.Y) / 2
+			rulings = append(rulings, MakeLine(line.Start.X, y, line.End.X, y))
+		case math.Abs(line.End.X-line.Start.X) <= tol && math.Abs(line.End.Y-line.Start.Y) > tol:
+			x := (line.Start.X + line.End.X) / 2
+			rulings = append(rulings, MakeLine(x, line.Start.Y, x, line.End.Y))
+		}
+	}
+
+	snapRulingPositions(rulings, tol)
+	rulings.Smooth(opts.MaxLineGap, tol)
+	rulings.NormalizeDirection()
+	snapRulingEnds(rulings, tol)
+
+	var bounds Rects
+	for _, group := range rulings.GroupIntersecting(1) {
+		if table, ok := makeTable(group, tol); ok && len(table.Cells) >= opts.MinCells {
+			tables = append(tables, table)
+			bounds = append(bounds, table.Bounds)
+		}
+	}
+
+	ordered := make([]Table, 0, len(tables))
+	for _, i := range XYCut(bounds, XYCutOptions{}).Indices {
+		ordered = append(ordered, tables[i])
+	}
+	return ordered
+}
+
+// snapRulingPositions moves horizontal rulings whose y differ by at most
+// tol onto their mean y, and likewise vertical rulings in x.
+func snapRulingPositions(rulings Lines, tol float64) {
+	snap := func(horizontal bool) {
+		var ids []int
+		for i, line := range rulings {
+			if line.IsHorizontal() == horizontal && line.IsVertical() != horizontal {
+				ids = append(ids, i)
+			}
+		}
+
+		pos := func(i int) float64 {
+			if horizontal {
+				return rulings[i].Start.Y
+			}
+			return rulings[i].Start.X
+		}
+		sort.SliceStable(ids, func(a, b int) bool { return pos(ids[a]) < pos(ids[b]) })
+
+		for start := 0; start < len(ids); {
+			end, sum := start+1, pos(ids[start])
+			for end < len(ids) && pos(ids[end])-pos(ids[end-1]) <= tol {
+				sum += pos(ids[end])
+				end++
+			}
+			mean := sum / float64(end-start)
+			for _, i := range ids[start:end] {
+				if horizontal {
+					rulings[i].Start.Y, rulings[i].End.Y = mean, mean
+				} else {
+					rulings[i].Start.X, rulings[i].End.X = mean, mean
+				}
+			}
+			start = end
+		}
+	}
+	snap(true)
+	snap(false)
+}
+
+// snapRulingEnds moves each end of a ruling that falls within tol of a
+// perpendicular ruling exactly onto it, closing near-miss joints.
+func snapRulingEnds(rulings Lines, tol float64) {
+	for i, line := range rulings {
+		for _, other := range rulings {
+			switch {
+			case line.IsHorizontal() && other.IsVertical():
+				if !MakeRange(other.Start.Y, other.End.Y).Expanded(tol).Contains(line.Start.Y) {
+					continue
+				}
+				if math.Abs(line.Start.X-other.Start.X) <= tol {
+					line.Start.X = other.Start.X
+				}
+				if math.Abs(line.End.X-other.Start.X) <= tol {
+					line.End.X = other.Start.X
+				}
+			case line.IsVertical() && other.IsHorizontal():
+				if !MakeRange(other.Start.X, other.End.X).Expanded(tol).Contains(line.Start.X) {
+					continue
+				}
+				if math.Abs(line.Start.Y-other.Start.Y) <= tol {
+					line.Start.Y = other.Start.Y
+				}
+				if math.Abs(line.End.Y-other.Start.Y) <= tol {
+					line.End.Y = other.Start.Y
+				}
+			}
+		}
+		rulings[i] = line
+	}
+}
+
+// makeTable builds the grid spanned by a group of intersecting, normalized
+// rulings and merges grid cells that no ruling separates into rectangular
+// cells.
+func makeTable(group Lines, tol float64) (table Table, ok bool) {
+	var horizontal, vertical Lines
+	for _, line := range group {
+		switch {
+		case line.IsHorizontal():
+			horizontal = append(horizontal, line)
+		case line.IsVertical():
+			vertical = append(vertical, line)
+		}
+	}
+	if len(horizontal) < 2 || len(vertical) < 2 {
+		return
+	}
+
+	ys := distinctPositions(horizontal, func(l Line) float64 { return l.Start.Y })
+	xs := distinctPositions(vertical, func(l Line) float64 { return l.Start.X })
+	if len(ys) < 2 || len(xs) < 2 {
+		return
+	}
+	// rows run top to bottom
+	for i, j := 0, len(ys)-1; i < j; i, j = i+1, j-1 {
+		ys[i], ys[j] = ys[j], ys[i]
+	}
+
+	rows, cols := len(ys)-1, len(xs)-1
+	for r := 0; r < rows; r++ {
+		table.Rows = append(table.Rows, Range{ys[r+1], ys[r]})
+	}
+	for c := 0; c < cols; c++ {
+		table.Columns = append(table.Columns, Range{xs[c], xs[c+1]})
+	}
+	table.Bounds = MakeRect(xs[0], ys[rows], xs[cols], ys[0])
+
+	// ruled reports whether a ruling covers the boundary at pos between a
+	// and b, looking at horizontal or vertical rulings.
+	ruled := func(lines Lines, pos, a, b float64, horizontal bool) bool {
+		for _, line := range lines {
+			at, span := line.Start.X, MakeRange(line.Start.Y, line.End.Y)
+			if horizontal {
+				at, span = line.Start.Y, MakeRange(line.Start.X, line.End.X)
+			}
+			if math.Abs(at-pos) <= tol && span.Min <= a+tol && span.Max >= b-tol {
+				return true
+			}
+		}
+		return false
+	}
+
+	parent := make([]int, rows*cols)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	union := func(a, b int) {
+		if ra, rb := find(a), find(b); ra != rb {
+			if ra < rb {
+				parent[rb] = ra
+			} else {
+				parent[ra] = rb
+			}
+		}
+	}
+
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			if c+1 < cols && !ruled(vertical, xs[c+1], ys[r+1], ys[r], false) {
+				union(r*cols+c, r*cols+c+1)
+			}
+			if r+1 < rows && !ruled(horizontal, ys[r+1], xs[c], xs[c+1], true) {
+				union(r*cols+c, (r+1)*cols+c)
+			}
+		}
+	}
+
+	// cover each merged region with rectangles, widest first from its
+	// top-left cell, so that a region left L-shaped by a broken ruling is
+	// split instead of producing overlapping cells
+	covered := make([]bool, rows*cols)
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			if covered[r*cols+c] {
+				continue
+			}
+			root := find(r*cols + c)
+			same := func(r, c int) bool { return !covered[r*cols+c] && find(r*cols+c) == root }
+
+			colSpan := 1
+			for c+colSpan < cols && same(r, c+colSpan) {
+				colSpan++
+			}
+			rowSpan := 1
+		extend:
+			for r+rowSpan < rows {
+				for cc := c; cc < c+colSpan; cc++ {
+					if !same(r+rowSpan, cc) {
+						break extend
+					}
+				}
+				rowSpan++
+			}
+
+			for rr := r; rr < r+rowSpan; rr++ {
+				for cc := c; cc < c+colSpan; cc++ {
+					covered[rr*cols+cc] = true
+				}
+			}
+			table.Cells = append(table.Cells, TableCell{
+				Row: r, Col: c, RowSpan: rowSpan, ColSpan: colSpan,
+				Rect: MakeRect(xs[c], ys[r+rowSpan], xs[c+colSpan], ys[r]),
+			})
+		}
+	}
+	return table, true
+}
+
+// distinctPositions returns the sorted positions of lines with values closer
+// than Epsilon merged.
+func distinctPositions(lines Lines, pos func(Line) float64) (positions []float64) {
+	for _, line := range lines {
+		positions = append(positions, pos(line))
+	}
+	sort.Float64s(positions)
+
+	out := positions[:0]
+	for i, p := range positions {
+		if i == 0 || !EqualEpsilon(p, out[len(out)-1]) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
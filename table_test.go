@@ -0,0 +1,147 @@
+package gfx_test
+
+import (
+	"testing"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+func TestFindTables(t *testing.T) {
+	lines := gfx.Lines{
+		// 3x3 grid whose top two cells are merged, drawn with near-miss
+		// joints, a slightly tilted ruling and a broken vertical
+		gfx.MakeLine(0, 300, 300, 300),
+		gfx.MakeLine(0.5, 200, 300, 200.8),
+		gfx.MakeLine(0, 100, 298.7, 100),
+		gfx.MakeLine(0, 0, 300, 0),
+		gfx.MakeLine(0, 0, 0, 300),
+		gfx.MakeLine(100, 0, 100, 201.5),
+		gfx.MakeLine(200, 0, 200, 150),
+		gfx.MakeLine(200, 151, 200, 300),
+		gfx.MakeLine(300, 1, 300, 300),
+		// a second table below the first
+		gfx.MakeLine(0, -100, 50, -100),
+		gfx.MakeLine(0, -150, 50, -150),
+		gfx.MakeLine(0, -150, 0, -100),
+		gfx.MakeLine(25, -150, 25, -100),
+		gfx.MakeLine(50, -150, 50, -100),
+		// a stray rule
+		gfx.MakeLine(500, 500, 600, 500),
+	}
+
+	tables := gfx.FindTables(lines, gfx.DefaultTableOptions())
+	if len(tables) != 2 {
+		t.Fatalf("found %d tables, want 2", len(tables))
+	}
+
+	table := tables[0]
+	if len(table.Rows) != 3 || len(table.Columns) != 3 {
+		t.Fatalf("grid = %dx%d, want 3x3", len(table.Rows), len(table.Columns))
+	}
+	if len(table.Cells) != 8 {
+		t.Errorf("cells = %d, want 8", len(table.Cells))
+	}
+
+	merged, ok := table.Cell(0, 1)
+	if !ok || merged.Row != 0 || merged.Col != 0 || merged.ColSpan != 2 || merged.RowSpan != 1 {
+		t.Errorf("cell (0, 1) = %+v, want the merged top left cell", merged)
+	}
+
+	cell, _ := table.Cell(2, 2)
+	if !cell.Rect.ContainsPoint(gfx.Point{X: 250, Y: 50}) {
+		t.Errorf("cell (2, 2) = %v, want it to contain (250, 50)", cell.Rect)
+	}
+
+	if len(tables[1].Cells) != 2 || tables[1].Bounds.Y.Max != -100 {
+		t.Errorf("second table = %+v", tables[1])
+	}
+}
+
+func TestFindTablesPartialRuling(t *testing.T) {
+	// a 2x2 grid whose inner rulings only separate the bottom right cell,
+	// leaving the other three an L-shaped region
+	lines := gfx.Lines{
+		gfx.MakeLine(0, 200, 200, 200),
+		gfx.MakeLine(100, 100, 200, 100),
+		gfx.MakeLine(0, 0, 200, 0),
+		gfx.MakeLine(0, 0, 0, 200),
+		gfx.MakeLine(100, 0, 100, 100),
+		gfx.MakeLine(200, 0, 200, 200),
+	}
+
+	tables := gfx.FindTables(lines, gfx.DefaultTableOptions())
+	if len(tables) != 1 {
+		t.Fatalf("found %d tables, want 1", len(tables))
+	}
+
+	want := []gfx.TableCell{
+		{Row: 0, Col: 0, RowSpan: 1, ColSpan: 2, Rect: gfx.MakeRect(0, 100, 200, 200)},
+		{Row: 1, Col: 0, RowSpan: 1, ColSpan: 1, Rect: gfx.MakeRect(0, 0, 100, 100)},
+		{Row: 1, Col: 1, RowSpan: 1, ColSpan: 1, Rect: gfx.MakeRect(100, 0, 200, 100)},
+	}
+	cells := tables[0].Cells
+	if len(cells) != len(want) {
+		t.Fatalf("cells = %+v, want %+v", cells, want)
+	}
+	for i := range want {
+		if cells[i] != want[i] {
+			t.Errorf("cell %d = %+v, want %+v", i, cells[i], want[i])
+		}
+	}
+}
+
+func TestFindBorderlessTables(t *testing.T) {
+	var words gfx.TextWords
+	add := func(s string, x, y float64) {
+		words = append(words, gfx.MakeWord(typeset(s, x, y, gfx.IdentityMatrix)))
+	}
+
+	add("Some", 0, 240)
+	add("prose", 45, 240)
+	add("above", 99, 240)
+
+	// left, right and decimal aligned columns with a spanning total row
+	add("Name", 0, 200)
+	add("Qty", 100, 200)
+	add("Price", 200, 200)
+	add("apple", 0, 186)
+	add("3", 118, 186)
+	add("1.5", 200, 186)
+	add("pear", 0, 172)
+	add("12", 109, 172)
+	add("12.25", 191, 172)
+	add("fig", 0, 158)
+	add("150", 100, 158)
+	add("0.75", 200, 158)
+	add("Total", 0, 144)
+	add("for", 50, 144)
+	add("all", 84, 144)
+	add("fruit", 116, 144)
+	add("14.5", 191, 144)
+
+	tables := gfx.FindBorderlessTables(words, gfx.DefaultBorderlessTableOptions())
+	if len(tables) != 1 {
+		t.Fatalf("found %d tables, want 1", len(tables))
+	}
+
+	table := tables[0]
+	if len(table.Rows) != 5 || len(table.Columns) != 3 {
+		t.Fatalf("grid = %dx%d, want 5x3", len(table.Rows), len(table.Columns))
+	}
+	if table.HeaderRows != 1 {
+		t.Errorf("header rows = %d, want 1", table.HeaderRows)
+	}
+	if len(table.Cells) != 14 {
+		t.Errorf("cells = %d, want 14", len(table.Cells))
+	}
+
+	total, _ := table.Cell(4, 1)
+	if total.Col != 0 || total.ColSpan != 2 {
+		t.Errorf("cell (4, 1) = %+v, want the total spanning two columns", total)
+	}
+
+	price, _ := table.Cell(2, 2)
+	if !price.Rect.Contains(words[11].Quad.Bounds()) {
+		t.Errorf("cell (2, 2) = %v, want it to contain %q", price.Rect, words[11].Value)
+	}
+}
@@ -1,314 +1,318 @@
 package gfx
 
-// // Writing modes
-// const (
-// 	WModeHorizontal int = iota
-// 	WModeVertical
-// )
-
-// type Letters []Letter
-// type Letter struct {
-// 	Rune     rune
-// 	GlyphID  int
-// 	Origin   Point
-// 	FontData *FontData
-// 	FontSize float64
-// 	Color    color.Color
-// }
-
-// func (ch Letters) Orientation() (orientation Orientation) {
-// 	if len(ch) == 0 {
-// 		return OtherOrientation
-// 	}
-
-// 	orientation = ch[0].Orientation
-// 	if orientation == OtherOrientation {
-// 		return
-// 	}
-
-// 	for _, word := range ch[1:] {
-// 		if word.Orientation != orientation {
-// 			return OtherOrientation
-// 		}
-// 	}
-// 	return
-// }
-
-// func (ch Letters) OrderByReadingOrder() (ret Letters) {
-// 	if len(ch) <= 1 {
-// 		return ch
-// 	}
-
-// 	switch ch.Orientation() {
-// 	case PageUp:
-// 		linq.From(ch).OrderBy(func(i interface{}) interface{} { return i.(Letter).Quad.BottomLeft.X }).ToSlice(&ret)
-// 	case PageDown:
-// 		linq.From(ch).OrderByDescending(func(i interface{}) interface{} { return i.(Letter).Quad.BottomLeft.X }).ToSlice(&ret)
-// 	case PageLeft:
-// 		linq.From(ch).OrderByDescending(func(i interface{}) interface{} { return i.(Letter).Quad.BottomLeft.Y }).ToSlice(&ret)
-// 	case PageRight:
-// 		linq.From(ch).OrderBy(func(i interface{}) interface{} { return i.(Letter).Quad.BottomLeft.Y }).ToSlice(&ret)
-// 	default:
-// 		avgAngle := linq.From(ch).Select(func(i interface{}) interface{} { return i.(Letter).Quad.Rotation() }).Average()
-// 		switch {
-// 		case 0 < avgAngle && avgAngle <= 90:
-// 			linq.From(ch).OrderBy(func(i interface{}) interface{} { return i.(Letter).Quad.BottomLeft.X }).ThenBy(func(i interface{}) interface{} { return i.(Letter).Quad.BottomLeft.Y }).ToSlice(&ret)
-// 		case 90 < avgAngle && avgAngle <= 180:
-// 			linq.From(ch).OrderByDescending(func(i interface{}) interface{} { return i.(Letter).Quad.BottomLeft.X }).ThenBy(func(i interface{}) interface{} { return i.(Letter).Quad.BottomLeft.Y }).ToSlice(&ret)
-// 		case -180 < avgAngle && avgAngle <= -90:
-// 			linq.From(ch).OrderByDescending(func(i interface{}) interface{} { return i.(Letter).Quad.BottomLeft.X }).ThenByDescending(func(i interface{}) interface{} { return i.(Letter).Quad.BottomLeft.Y }).ToSlice(&ret)
-// 		case -90 < avgAngle && avgAngle <= 0:
-// 			linq.From(ch).OrderBy(func(i interface{}) interface{} { return i.(Letter).Quad.BottomLeft.X }).ThenByDescending(func(i interface{}) interface{} { return i.(Letter).Quad.BottomLeft.Y }).ToSlice(&ret)
-// 		default:
-// 			return ch
-// 		}
-// 	}
-
-// 	return
-// }
-
-// func (ch Letters) IsWhitespace() bool {
-// 	for _, letter := range ch {
-// 		if !letter.IsWhitespace() {
-// 			return false
-// 		}
-// 	}
-// 	return true
-// }
-
-// func (l Letter) IsWhitespace() bool { return unicode.IsSpace(l.Rune) }
-
-// type TextWords []TextWord
-
-// func (w TextWords) Orientation() (orientation Orientation) {
-// 	if len(w) == 0 {
-// 		return OtherOrientation
-// 	}
-
-// 	orientation = w[0].Orientation
-// 	if orientation == OtherOrientation {
-// 		return
-// 	}
-
-// 	for _, word := range w[1:] {
-// 		if word.Orientation != orientation {
-// 			return OtherOrientation
-// 		}
-// 	}
-// 	return
-// }
-
-// func (w TextWords) OrderByReadingOrder() (ret TextWords) {
-// 	if len(w) <= 1 {
-// 		return w
-// 	}
-
-// 	switch w.Orientation() {
-// 	case PageUp:
-// 		linq.From(w).OrderBy(func(i interface{}) interface{} { return i.(TextWord).Quad.BottomLeft.X }).ToSlice(&ret)
-// 	case PageDown:
-// 		linq.From(w).OrderByDescending(func(i interface{}) interface{} { return i.(TextWord).Quad.BottomLeft.X }).ToSlice(&ret)
-// 	case PageLeft:
-// 		linq.From(w).OrderByDescending(func(i interface{}) interface{} { return i.(TextWord).Quad.BottomLeft.Y }).ToSlice(&ret)
-// 	case PageRight:
-// 		linq.From(w).OrderBy(func(i interface{}) interface{} { return i.(TextWord).Quad.BottomLeft.Y }).ToSlice(&ret)
-// 	default:
-// 		avgAngle := linq.From(w).Select(func(i interface{}) interface{} { return i.(TextWord).Quad.Rotation() }).Average()
-// 		switch {
-// 		case 0 < avgAngle && avgAngle <= 90:
-// 			linq.From(w).OrderBy(func(i interface{}) interface{} { return i.(TextWord).Quad.BottomLeft.X }).ThenBy(func(i interface{}) interface{} { return i.(TextWord).Quad.BottomLeft.Y }).ToSlice(&ret)
-// 		case 90 < avgAngle && avgAngle <= 180:
-// 			linq.From(w).OrderByDescending(func(i interface{}) interface{} { return i.(TextWord).Quad.BottomLeft.X }).ThenBy(func(i interface{}) interface{} { return i.(TextWord).Quad.BottomLeft.Y }).ToSlice(&ret)
-// 		case -180 < avgAngle && avgAngle <= -90:
-// 			linq.From(w).OrderByDescending(func(i interface{}) interface{} { return i.(TextWord).Quad.BottomLeft.X }).ThenByDescending(func(i interface{}) interface{} { return i.(TextWord).Quad.BottomLeft.Y }).ToSlice(&ret)
-// 		case -90 < avgAngle && avgAngle <= 0:
-// 			linq.From(w).OrderBy(func(i interface{}) interface{} { return i.(TextWord).Quad.BottomLeft.X }).ThenByDescending(func(i interface{}) interface{} { return i.(TextWord).Quad.BottomLeft.Y }).ToSlice(&ret)
-// 		default:
-// 			return w
-// 		}
-// 	}
-
-// 	return
-// }
-
-// type TextWord struct {
-// 	Value         string
-// 	Quad          Quad
-// 	Confidence    float64
-// 	Orientation   Orientation
-// 	DeskewAngle   float64
-// 	StartBaseline Point
-// 	EndBaseline   Point
-// }
-
-// func MakeWord(letters Letters) (word TextWord) {
-// 	if len(letters) == 0 {
-// 		return
-// 	}
-
-// 	if len(letters) == 1 {
-// 		word.Confidence = letters[0].Confidence
-// 		word.DeskewAngle = letters[0].DeskewAngle
-// 		word.Quad = letters[0].Quad
-// 		word.EndBaseline = letters[0].EndBaseline
-// 		word.StartBaseline = letters[0].StartBaseline
-// 		word.EndBaseline = letters[0].EndBaseline
-// 		word.Orientation = letters[0].Orientation
-// 		word.Value = fmt.Sprintf("%c", letters[0].Rune)
-// 		return
-// 	}
-
-// 	letters = letters.OrderByReadingOrder()
-
-// 	quads := make(Quads, len(letters))
-// 	for i, letter := range letters {
-// 		quads[i] = letter.Quad
-// 	}
-
-// 	var builder strings.Builder
-// 	for _, letter := range letters {
-// 		builder.WriteRune(letter.Rune)
-// 	}
-// 	word.Value = builder.String()
-// 	word.Confidence = letters.GetMeanConfidence()
-// 	word.DeskewAngle = letters.GetMeanDeskewAngle()
-// 	word.Orientation = letters.Orientation()
-// 	word.StartBaseline = letters[0].StartBaseline
-// 	word.EndBaseline = letters[len(letters)-1].EndBaseline
-// 	word.Quad = quads.Union()
-
-// 	return
-// }
-
-// func (w TextWord) IsWhitespace() bool {
-// 	return strings.TrimSpace(w.Value) == ""
-// }
-
-// func (w TextWord) String() string {
-// 	return w.Value
-// }
-
-// type TextLines []TextLine
-
-// func (l TextLines) Orientation() (orientation Orientation) {
-// 	if len(l) == 0 {
-// 		return OtherOrientation
-// 	}
-
-// 	orientation = l[0].Orientation
-// 	if orientation == OtherOrientation {
-// 		return
-// 	}
-
-// 	for _, word := range l[1:] {
-// 		if word.Orientation != orientation {
-// 			return OtherOrientation
-// 		}
-// 	}
-// 	return
-// }
-
-// func (l TextLines) OrderByReadingOrder() (ret TextLines) {
-// 	if len(l) <= 1 {
-// 		return l
-// 	}
-
-// 	switch l.Orientation() {
-// 	case PageUp:
-// 		linq.From(l).OrderByDescending(func(i interface{}) interface{} { return i.(TextLine).Quad.BottomLeft.Y }).ToSlice(&ret)
-// 	case PageDown:
-// 		linq.From(l).OrderBy(func(i interface{}) interface{} { return i.(TextLine).Quad.BottomLeft.Y }).ToSlice(&ret)
-// 	case PageLeft:
-// 		linq.From(l).OrderByDescending(func(i interface{}) interface{} { return i.(TextLine).Quad.BottomLeft.X }).ToSlice(&ret)
-// 	case PageRight:
-// 		linq.From(l).OrderBy(func(i interface{}) interface{} { return i.(TextLine).Quad.BottomLeft.X }).ToSlice(&ret)
-// 	default:
-// 		avgAngle := linq.From(l).Select(func(i interface{}) interface{} { return i.(TextLine).Quad.Rotation() }).Average()
-// 		switch {
-// 		case 0 < avgAngle && avgAngle <= 90:
-// 			linq.From(l).OrderByDescending(func(i interface{}) interface{} { return i.(TextLine).Quad.BottomLeft.Y }).ThenBy(func(i interface{}) interface{} { return i.(TextLine).Quad.BottomLeft.X }).ToSlice(&ret)
-// 		case 90 < avgAngle && avgAngle <= 180:
-// 			linq.From(l).OrderBy(func(i interface{}) interface{} { return i.(TextLine).Quad.BottomLeft.Y }).ThenBy(func(i interface{}) interface{} { return i.(TextLine).Quad.BottomLeft.Y }).ToSlice(&ret)
-// 		case -180 < avgAngle && avgAngle <= -90:
-// 			linq.From(l).OrderBy(func(i interface{}) interface{} { return i.(TextLine).Quad.BottomLeft.Y }).ThenByDescending(func(i interface{}) interface{} { return i.(TextLine).Quad.BottomLeft.X }).ToSlice(&ret)
-// 		case -90 < avgAngle && avgAngle <= 0:
-// 			linq.From(l).OrderByDescending(func(i interface{}) interface{} { return i.(TextLine).Quad.BottomLeft.X }).ThenByDescending(func(i interface{}) interface{} { return i.(TextLine).Quad.BottomLeft.Y }).ToSlice(&ret)
-// 		default:
-// 			return l
-// 		}
-// 	}
-
-// 	return
-// }
-
-// type TextLine struct {
-// 	TextWords
-// 	Quad          Quad
-// 	Orientation   Orientation
-// 	WordSeparator string
-// }
-
-// func MakeTextLine(words TextWords, sep string) TextLine {
-// 	var quads = make(Quads, len(words))
-// 	for i, l := range words {
-// 		quads[i] = l.Quad
-// 	}
-
-// 	return TextLine{
-// 		TextWords:     words,
-// 		Orientation:   quads.Orientation(),
-// 		Quad:          quads.Union(),
-// 		WordSeparator: sep,
-// 	}
-// }
-
-// func (l TextLine) String() string {
-// 	words := make([]string, 0, len(l.TextWords))
-// 	for _, w := range l.TextWords {
-// 		if strings.TrimSpace(w.Value) == "" {
-// 			continue
-// 		}
-// 		words = append(words, w.String())
-// 	}
-// 	return strings.Join(words, l.WordSeparator)
-// }
-
-// type TextBlocks []TextBlock
-
-// func (tb TextBlocks) String() string {
-// 	var builder strings.Builder
-// 	for _, block := range tb {
-// 		builder.WriteString(block.String())
-// 		builder.WriteRune('\n')
-// 	}
-// 	return builder.String()
-// }
-
-// type TextBlock struct {
-// 	TextLines
-// 	Quad          Quad
-// 	Orientation   Orientation
-// 	LineSeparator string
-// }
-
-// func MakeTextBlock(lines TextLines, sep string) TextBlock {
-// 	var quads = make(Quads, len(lines))
-// 	for i, l := range lines {
-// 		quads[i] = l.Quad
-// 	}
-
-// 	return TextBlock{
-// 		TextLines:     lines,
-// 		Orientation:   quads.Orientation(),
-// 		Quad:          quads.Union(),
-// 		LineSeparator: sep,
-// 	}
-// }
-
-// func (b TextBlock) String() string {
-// 	lines := make([]string, len(b.TextLines))
-// 	for i, l := range b.TextLines {
-// 		lines[i] = l.String()
-// 	}
-// 	return strings.Join(lines, b.LineSeparator)
-// }
+import (
+	"image/color"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/ahmetb/go-linq"
+)
+
+// Writing modes
+const (
+	WModeHorizontal int = iota
+	WModeVertical
+)
+
+type Letters []Letter
+
+// Letter is a single positioned character, as produced by rendering text or
+// by an OCR engine. StartBaseline and EndBaseline are the ends of the
+// character's baseline in reading direction.
+type Letter struct {
+	Rune          rune
+	GlyphID       GlyphID
+	Quad          Quad
+	StartBaseline Point
+	EndBaseline   Point
+	FontData      FontData
+	FontSize      float64
+	Color         color.Color
+	Confidence    float64
+}
+
+func (l Letter) Orientation() Orientation { return l.Quad.Orientation() }
+
+func (l Letter) IsWhitespace() bool { return unicode.IsSpace(l.Rune) }
+
+// DeskewAngle returns the angle of the letter's baseline in degrees. Letters
+// without a baseline use the rotation of their quad.
+func (l Letter) DeskewAngle() float64 {
+	if l.StartBaseline == l.EndBaseline {
+		return l.Quad.Rotation()
+	}
+	return math.Atan2(l.EndBaseline.Y-l.StartBaseline.Y, l.EndBaseline.X-l.StartBaseline.X) * 180 / math.Pi
+}
+
+func (ch Letters) Quads() Quads {
+	quads := make(Quads, len(ch))
+	for i, letter := range ch {
+		quads[i] = letter.Quad
+	}
+	return quads
+}
+
+func (ch Letters) Orientation() Orientation { return ch.Quads().Orientation() }
+
+// OrderByReadingOrder sorts letters along their mean baseline direction.
+func (ch Letters) OrderByReadingOrder() (ret Letters) {
+	if len(ch) <= 1 {
+		return ch
+	}
+
+	dir, _ := readingFrame(ch.Quads())
+	linq.From(ch).OrderBy(func(i interface{}) interface{} { return i.(Letter).Quad.BottomLeft.Dot(dir) }).ToSlice(&ret)
+	return
+}
+
+func (ch Letters) IsWhitespace() bool {
+	for _, letter := range ch {
+		if !letter.IsWhitespace() {
+			return false
+		}
+	}
+	return true
+}
+
+func (ch Letters) GetMeanConfidence() float64 {
+	if len(ch) == 0 {
+		return 0
+	}
+	return linq.From(ch).Select(func(i interface{}) interface{} { return i.(Letter).Confidence }).Average()
+}
+
+// GetMeanDeskewAngle averages the letters' baseline angles as unit vectors,
+// so that angles either side of ±180 degrees do not cancel out.
+func (ch Letters) GetMeanDeskewAngle() float64 {
+	var x, y float64
+	for _, letter := range ch {
+		angle := letter.DeskewAngle() * math.Pi / 180
+		x += math.Cos(angle)
+		y += math.Sin(angle)
+	}
+	if x == 0 && y == 0 {
+		return 0
+	}
+	return math.Atan2(y, x) * 180 / math.Pi
+}
+
+func (ch Letters) String() string {
+	var builder strings.Builder
+	for _, letter := range ch {
+		builder.WriteRune(letter.Rune)
+	}
+	return builder.String()
+}
+
+type TextWords []TextWord
+
+func (w TextWords) Quads() Quads {
+	quads := make(Quads, len(w))
+	for i, word := range w {
+		quads[i] = word.Quad
+	}
+	return quads
+}
+
+func (w TextWords) Orientation() (orientation Orientation) {
+	if len(w) == 0 {
+		return OtherOrientation
+	}
+
+	orientation = w[0].Orientation
+	if orientation == OtherOrientation {
+		return
+	}
+
+	for _, word := range w[1:] {
+		if word.Orientation != orientation {
+			return OtherOrientation
+		}
+	}
+	return
+}
+
+// OrderByReadingOrder sorts words along their mean baseline direction.
+func (w TextWords) OrderByReadingOrder() (ret TextWords) {
+	if len(w) <= 1 {
+		return w
+	}
+
+	dir, _ := readingFrame(w.Quads())
+	linq.From(w).OrderBy(func(i interface{}) interface{} { return i.(TextWord).Quad.BottomLeft.Dot(dir) }).ToSlice(&ret)
+	return
+}
+
+type TextWord struct {
+	Value         string
+	Letters       Letters
+	Quad          Quad
+	Confidence    float64
+	Orientation   Orientation
+	DeskewAngle   float64
+	StartBaseline Point
+	EndBaseline   Point
+}
+
+// MakeWord builds a word from letters, ordering them by reading order.
+func MakeWord(letters Letters) (word TextWord) {
+	if len(letters) == 0 {
+		return
+	}
+
+	letters = letters.OrderByReadingOrder()
+
+	word.Value = letters.String()
+	word.Letters = letters
+	word.Confidence = letters.GetMeanConfidence()
+	word.DeskewAngle = letters.GetMeanDeskewAngle()
+	word.Orientation = letters.Orientation()
+	word.StartBaseline = letters[0].StartBaseline
+	word.EndBaseline = letters[len(letters)-1].EndBaseline
+	word.Quad = letters.Quads().Union()
+	return
+}
+
+func (w TextWord) IsWhitespace() bool {
+	return strings.TrimSpace(w.Value) == ""
+}
+
+func (w TextWord) String() string {
+	return w.Value
+}
+
+type TextLines []TextLine
+
+func (l TextLines) Quads() Quads {
+	quads := make(Quads, len(l))
+	for i, line := range l {
+		quads[i] = line.Quad
+	}
+	return quads
+}
+
+func (l TextLines) Orientation() (orientation Orientation) {
+	if len(l) == 0 {
+		return OtherOrientation
+	}
+
+	orientation = l[0].Orientation
+	if orientation == OtherOrientation {
+		return
+	}
+
+	for _, line := range l[1:] {
+		if line.Orientation != orientation {
+			return OtherOrientation
+		}
+	}
+	return
+}
+
+// OrderByReadingOrder sorts lines from the top of the text to the bottom,
+// measured perpendicular to the mean baseline direction. Lines at the same
+// height are ordered along the baseline.
+func (l TextLines) OrderByReadingOrder() (ret TextLines) {
+	if len(l) <= 1 {
+		return l
+	}
+
+	dir, up := readingFrame(l.Quads())
+	linq.From(l).
+		OrderByDescending(func(i interface{}) interface{} { return i.(TextLine).Quad.BottomLeft.Dot(up) }).
+		ThenBy(func(i interface{}) interface{} { return i.(TextLine).Quad.BottomLeft.Dot(dir) }).
+		ToSlice(&ret)
+	return
+}
+
+type TextLine struct {
+	TextWords
+	Quad          Quad
+	Orientation   Orientation
+	WordSeparator string
+}
+
+// MakeTextLine builds a line from words, ordering them by reading order.
+func MakeTextLine(words TextWords, sep string) TextLine {
+	words = words.OrderByReadingOrder()
+	quads := words.Quads()
+	return TextLine{
+		TextWords:     words,
+		Orientation:   quads.Orientation(),
+		Quad:          quads.Union(),
+		WordSeparator: sep,
+	}
+}
+
+func (l TextLine) String() string {
+	words := make([]string, 0, len(l.TextWords))
+	for _, w := range l.TextWords {
+		if w.IsWhitespace() {
+			continue
+		}
+		words = append(words, w.String())
+	}
+	return strings.Join(words, l.WordSeparator)
+}
+
+type TextBlocks []TextBlock
+
+func (tb TextBlocks) String() string {
+	var builder strings.Builder
+	for _, block := range tb {
+		builder.WriteString(block.String())
+		builder.WriteRune('\n')
+	}
+	return builder.String()
+}
+
+type TextBlock struct {
+	TextLines
+	Quad          Quad
+	Orientation   Orientation
+	LineSeparator string
+}
+
+// MakeTextBlock builds a block from lines, ordering them by reading order.
+func MakeTextBlock(lines TextLines, sep string) TextBlock {
+	lines = lines.OrderByReadingOrder()
+	quads := lines.Quads()
+	return TextBlock{
+		TextLines:     lines,
+		Orientation:   quads.Orientation(),
+		Quad:          quads.Union(),
+		LineSeparator: sep,
+	}
+}
+
+func (b TextBlock) String() string {
+	lines := make([]string, len(b.TextLines))
+	for i, l := range b.TextLines {
+		lines[i] = l.String()
+	}
+	return strings.Join(lines, b.LineSeparator)
+}
+
+// readingFrame returns the mean baseline direction of quads and the mean
+// direction from their baselines to their tops, both as unit vectors. The up
+// vector follows the quads rather than the coordinate system, so y-down
+// device coordinates are handled as well as y-up page coordinates.
+func readingFrame(quads Quads) (dir, up Point) {
+	for _, q := range quads {
+		t := q.T()
+		dir = dir.Add(Point{math.Cos(t), math.Sin(t)})
+		up = up.Add(q.TopLeft.Sub(q.BottomLeft).Normalize())
+	}
+	if dir.Norm() < Epsilon {
+		dir = Point{1, 0}
+	}
+	dir = dir.Normalize()
+
+	// keep only the component of up perpendicular to the baseline
+	ortho := dir.Ortho()
+	if ortho.Dot(up) < 0 {
+		ortho = ortho.Mul(-1)
+	}
+	return dir, ortho
+}
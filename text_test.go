@@ -0,0 +1,170 @@
+package gfx_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+func letters(s string, x, y float64, trm gfx.Matrix) (letters gfx.Letters) {
+	for _, chr := range s {
+		letters = append(letters, gfx.Letter{
+			Rune:          chr,
+			Quad:          trm.TransformQuad(gfx.MakeQuad(x, y, x+8, y+10)),
+			StartBaseline: trm.TransformPoint(gfx.Point{X: x, Y: y}),
+			EndBaseline:   trm.TransformPoint(gfx.Point{X: x + 8, Y: y}),
+			Confidence:    0.5,
+		})
+		x += 10
+	}
+	return
+}
+
+// typeset lays out s as letters 8 wide with a gap of 1 between them, as
+// tight as text set in a real font.
+func typeset(s string, x, y float64, trm gfx.Matrix) (letters gfx.Letters) {
+	for _, chr := range s {
+		letters = append(letters, gfx.Letter{
+			Rune:          chr,
+			Quad:          trm.TransformQuad(gfx.MakeQuad(x, y, x+8, y+10)),
+			StartBaseline: trm.TransformPoint(gfx.Point{X: x, Y: y}),
+			EndBaseline:   trm.TransformPoint(gfx.Point{X: x + 8, Y: y}),
+			Confidence:    1,
+		})
+		x += 9
+	}
+	return
+}
+
+func reversed(l gfx.Letters) gfx.Letters {
+	out := make(gfx.Letters, len(l))
+	for i := range l {
+		out[len(l)-1-i] = l[i]
+	}
+	return out
+}
+
+func TestTextModel(t *testing.T) {
+	tests := []struct {
+		name        string
+		trm         gfx.Matrix
+		orientation gfx.Orientation
+		angle       float64
+	}{
+		{"up", gfx.IdentityMatrix, gfx.PageUp, 0},
+		{"left", gfx.NewRotationMatrixDeg(90), gfx.PageLeft, 90},
+		{"down", gfx.NewRotationMatrixDeg(180), gfx.PageDown, 180},
+		{"right", gfx.NewRotationMatrixDeg(-90), gfx.PageRight, -90},
+		{"skewed", gfx.NewRotationMatrixDeg(30), gfx.OtherOrientation, 30},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			word1 := gfx.MakeWord(reversed(letters("hello", 0, 100, test.trm)))
+			word2 := gfx.MakeWord(letters("world", 60, 100, test.trm))
+			word3 := gfx.MakeWord(letters("again", 0, 80, test.trm))
+
+			if word1.Value != "hello" {
+				t.Errorf("word value = %q, want %q", word1.Value, "hello")
+			}
+			if word1.Orientation != test.orientation {
+				t.Errorf("word orientation = %v, want %v", word1.Orientation, test.orientation)
+			}
+			if word1.Confidence != 0.5 {
+				t.Errorf("word confidence = %v, want 0.5", word1.Confidence)
+			}
+			if math.Abs(gfx.BoundAngle180(word1.DeskewAngle-test.angle)) > 1e-6 {
+				t.Errorf("word deskew angle = %v, want %v", word1.DeskewAngle, test.angle)
+			}
+			if !word1.StartBaseline.Eq(test.trm.TransformPoint(gfx.Point{X: 0, Y: 100})) {
+				t.Errorf("word start baseline = %v", word1.StartBaseline)
+			}
+
+			want := test.trm.TransformQuad(gfx.MakeQuad(0, 100, 48, 110))
+			for _, pair := range [][2]gfx.Point{
+				{word1.Quad.BottomLeft, want.BottomLeft},
+				{word1.Quad.TopLeft, want.TopLeft},
+				{word1.Quad.TopRight, want.TopRight},
+				{word1.Quad.BottomRight, want.BottomRight},
+			} {
+				if pair[0].DistanceTo(pair[1]) > 1e-6 {
+					t.Errorf("word quad = %v, want %v", word1.Quad, want)
+					break
+				}
+			}
+
+			line1 := gfx.MakeTextLine(gfx.TextWords{word2, word1}, " ")
+			line2 := gfx.MakeTextLine(gfx.TextWords{word3}, " ")
+			if got := line1.String(); got != "hello world" {
+				t.Errorf("line = %q, want %q", got, "hello world")
+			}
+
+			block := gfx.MakeTextBlock(gfx.TextLines{line2, line1}, "\n")
+			if got := block.String(); got != "hello world\nagain" {
+				t.Errorf("block = %q, want %q", got, "hello world\nagain")
+			}
+			if block.Orientation != test.orientation {
+				t.Errorf("block orientation = %v, want %v", block.Orientation, test.orientation)
+			}
+		})
+	}
+}
+
+func TestLettersBlocks(t *testing.T) {
+	var page gfx.Letters
+	page = append(page, typeset("title", 0, 200, gfx.IdentityMatrix)...)
+	page = append(page, typeset("left one", 0, 160, gfx.IdentityMatrix)...)
+	page = append(page, typeset("left two", 0, 146, gfx.IdentityMatrix)...)
+	page = append(page, typeset("right one", 120, 160, gfx.IdentityMatrix)...)
+	page = append(page, typeset("right two", 120, 146, gfx.IdentityMatrix)...)
+	page = append(page, typeset("sideways", 300, 0, gfx.NewRotationMatrixDeg(90))...)
+
+	// shuffle deterministically so input order does not help
+	for i := range page {
+		j := (i * 7) % len(page)
+		page[i], page[j] = page[j], page[i]
+	}
+
+	var got []string
+	for _, block := range page.Blocks(gfx.DefaultTextLayoutOptions()) {
+		got = append(got, block.String())
+	}
+
+	want := []string{"title", "left one\nleft two", "right one\nright two", "sideways"}
+	if len(got) != len(want) {
+		t.Fatalf("blocks = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("block %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestEstimateOrientation(t *testing.T) {
+	var quads gfx.Quads
+	quads = append(quads, typeset("a scanned page turned a quarter", 0, 100, gfx.NewRotationMatrixDeg(92)).Quads()...)
+	quads = append(quads, typeset("and skewed by two degrees", 0, 80, gfx.NewRotationMatrixDeg(91.5)).Quads()...)
+	quads = append(quads, typeset("stamp", 0, 0, gfx.IdentityMatrix).Quads()...)
+
+	page := gfx.MakeRect(-300, -300, 300, 300)
+	est := quads.EstimateOrientation(page)
+	if est.Orientation != gfx.PageLeft {
+		t.Errorf("orientation = %v, want %v", est.Orientation, gfx.PageLeft)
+	}
+	if math.Abs(est.Skew-2) > 1e-6 || math.Abs(est.Angle-92) > 1e-6 {
+		t.Errorf("skew = %v, angle = %v, want 2 and 92", est.Skew, est.Angle)
+	}
+	if est.Confidence < 0.85 || est.Confidence >= 1 {
+		t.Errorf("confidence = %v", est.Confidence)
+	}
+
+	upright := est.Matrix.TransformQuad(quads[0])
+	if upright.Orientation() != gfx.PageUp {
+		t.Errorf("normalized quad = %v, want it upright", upright)
+	}
+	if r := est.Matrix.TransformRect(page); math.Abs(r.X.Min) > 1e-6 || math.Abs(r.Y.Min) > 1e-6 {
+		t.Errorf("normalized page = %v, want it at the origin", r)
+	}
+}
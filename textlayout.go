@@ -0,0 +1,246 @@
+package gfx
+
+import (
+	"math"
+	"sort"
+)
+
+// TextLayoutOptions controls how letters are grouped into words, lines and
+// blocks. Spacings are fractions of the height of the letters involved, so
+// the same options work for any font size and resolution.
+type TextLayoutOptions struct {
+	// WordSpacing is the gap between letters on a line above which they
+	// belong to different words.
+	WordSpacing float64
+	// ColumnSpacing is the gap between letters at the same height above
+	// which they belong to different lines, as in adjacent columns.
+	ColumnSpacing float64
+	// BlockSpacing is the gap between lines above which they belong to
+	// different blocks.
+	BlockSpacing float64
+	// AngleTolerance is the largest difference, in degrees, between the
+	// baseline angles of letters laid out together.
+	AngleTolerance float64
+
+	WordSeparator string
+	LineSeparator string
+}
+
+func DefaultTextLayoutOptions() TextLayoutOptions {
+	return TextLayoutOptions{
+		WordSpacing:    0.15,
+		ColumnSpacing:  1.0,
+		BlockSpacing:   1.0,
+		AngleTolerance: 5,
+		WordSeparator:  " ",
+		LineSeparator:  "\n",
+	}
+}
+
+// Blocks groups letters into words, lines and blocks and returns the blocks
+// in reading order. Letters are first split by baseline angle; each group is
+// laid out in its own frame, so rotated text is handled like upright text.
+// Within a group, blocks are ordered by recursively cutting the page along
+// horizontal and then vertical whitespace, so columns are read one after
+// another.
+func (ch Letters) Blocks(opts TextLayoutOptions) (blocks TextBlocks) {
+	for _, group := range groupLettersByAngle(ch, opts.AngleTolerance) {
+		blocks = append(blocks, layoutBlocks(ch, group, opts)...)
+	}
+	return
+}
+
+// Lines returns the lines of all blocks in reading order.
+func (ch Letters) Lines(opts TextLayoutOptions) (lines TextLines) {
+	for _, block := range ch.Blocks(opts) {
+		lines = append(lines, block.TextLines...)
+	}
+	return
+}
+
+// Words returns the words of all lines in reading order.
+func (ch Letters) Words(opts TextLayoutOptions) (words TextWords) {
+	for _, line := range ch.Lines(opts) {
+		words = append(words, line.TextWords...)
+	}
+	return
+}
+
+// groupLettersByAngle splits letters into groups whose baseline angles lie
+// within tolerance of the group's first letter. Groups are returned largest
+// first; equal sized groups keep the order they first appear in.
+func groupLettersByAngle(ch Letters, tolerance float64) (groups [][]int) {
+	var angles []float64
+	for i, letter := range ch {
+		angle := letter.DeskewAngle()
+		found := false
+		for g, ref := range angles {
+			if math.Abs(BoundAngle180(angle-ref)) <= tolerance {
+				groups[g] = append(groups[g], i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			angles = append(angles, angle)
+			groups = append(groups, []int{i})
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool { return len(groups[i]) > len(groups[j]) })
+	return
+}
+
+// layoutLetter is a letter projected into the reading frame of its group,
+// where x runs along the baseline and y points to the top of the text.
+type layoutLetter struct {
+	rect     Rect
+	baseline float64
+	size     float64
+}
+
+func layoutBlocks(ch Letters, group []int, opts TextLayoutOptions) TextBlocks {
+	quads := make(Quads, len(group))
+	for i, idx := range group {
+		quads[i] = ch[idx].Quad
+	}
+	dir, up := readingFrame(quads)
+	project := func(p Point) Point { return Point{p.Dot(dir), p.Dot(up)} }
+
+	frame := make(map[int]layoutLetter, len(group))
+	for _, idx := range group {
+		letter := ch[idx]
+		q := letter.Quad
+		rect := Points{project(q.BottomLeft), project(q.TopLeft), project(q.TopRight), project(q.BottomRight)}.Bounds()
+
+		ll := layoutLetter{rect: rect, baseline: rect.Y.Min, size: rect.Height()}
+		if letter.StartBaseline != letter.EndBaseline {
+			ll.baseline = project(letter.StartBaseline).Y
+		}
+		if ll.size <= 0 {
+			ll.size = math.Max(rect.Width(), 1)
+		}
+		frame[idx] = ll
+	}
+
+	var lines [][]int
+	for _, row := range groupLetterRows(group, frame) {
+		for _, segment := range splitLetterRow(row, frame, opts.ColumnSpacing) {
+			// a segment can still hold several lines when rows of adjacent
+			// columns chained together; regrouping one column separates them
+			lines = append(lines, groupLetterRows(segment, frame)...)
+		}
+	}
+
+	var textLines TextLines
+	var lineRects Rects
+	var sizes []float64
+	for _, line := range lines {
+		var words TextWords
+		var rects Rects
+		for _, part := range splitLetterRow(line, frame, opts.WordSpacing) {
+			// whitespace letters also separate words
+			var letters Letters
+			for _, idx := range append(part, -1) {
+				if idx < 0 || ch[idx].IsWhitespace() {
+					if len(letters) > 0 {
+						words = append(words, MakeWord(letters))
+					}
+					letters = nil
+					continue
+				}
+				letters = append(letters, ch[idx])
+				rects = append(rects, frame[idx].rect)
+				sizes = append(sizes, frame[idx].size)
+			}
+		}
+		if len(words) > 0 {
+			textLines = append(textLines, MakeTextLine(words, opts.WordSeparator))
+			lineRects = append(lineRects, rects.Union())
+		}
+	}
+	if len(textLines) == 0 {
+		return nil
+	}
+
+	sort.Float64s(sizes)
+	size := sizes[len(sizes)/2]
+
+	// Lines in one block must overlap or nearly overlap horizontally, so
+	// x is scaled such that a gap of ColumnSpacing counts as much as a gap
+	// of BlockSpacing between lines.
+	scale := 1.0
+	if opts.ColumnSpacing > 0 {
+		scale = opts.BlockSpacing / opts.ColumnSpacing
+	}
+	scaled := make(Rects, len(lineRects))
+	for i, r := range lineRects {
+		scaled[i] = MakeRect(r.X.Min*scale, r.Y.Min, r.X.Max*scale, r.Y.Max)
+	}
+
+	blockLines := scaled.ClusterIndices(0, opts.BlockSpacing*size)
+	blockRects := make(Rects, len(blockLines))
+	for b, ids := range blockLines {
+		rects := make(Rects, len(ids))
+		for i, id := range ids {
+			rects[i] = lineRects[id]
+		}
+		blockRects[b] = rects.Union()
+	}
+
+	var blocks TextBlocks
+	for _, b := range XYCut(blockRects, XYCutOptions{}).Indices {
+		lines := make(TextLines, len(blockLines[b]))
+		for i, idx := range blockLines[b] {
+			lines[i] = textLines[idx]
+		}
+		blocks = append(blocks, MakeTextBlock(lines, opts.LineSeparator))
+	}
+	return blocks
+}
+
+// groupLetterRows groups letters whose baseline bands overlap using
+// Rects.GroupRows. The band spans the lower part of the letter above its
+// baseline, so descenders and ascenders of neighbouring lines never meet.
+func groupLetterRows(ids []int, frame map[int]layoutLetter) (rows [][]int) {
+	bands := make(Rects, len(ids))
+	for i, idx := range ids {
+		ll := frame[idx]
+		bands[i] = MakeRect(ll.rect.X.Min, ll.baseline, ll.rect.X.Max, ll.baseline+0.3*ll.size)
+	}
+
+	for _, row := range bands.GroupRowIndices() {
+		letters := make([]int, len(row))
+		for i, b := range row {
+			letters[i] = ids[b]
+		}
+		rows = append(rows, letters)
+	}
+	return
+}
+
+// splitLetterRow sorts a row along the baseline and splits it wherever the
+// gap to the next letter exceeds spacing times the larger letter size.
+func splitLetterRow(row []int, frame map[int]layoutLetter, spacing float64) (parts [][]int) {
+	sorted := append([]int(nil), row...)
+	sort.SliceStable(sorted, func(i, j int) bool { return frame[sorted[i]].rect.X.Min < frame[sorted[j]].rect.X.Min })
+
+	var part []int
+	right := math.Inf(-1)
+	for _, idx := range sorted {
+		ll := frame[idx]
+		if len(part) > 0 {
+			size := math.Max(ll.size, frame[part[len(part)-1]].size)
+			if ll.rect.X.Min-right > spacing*size {
+				parts = append(parts, part)
+				part, right = nil, math.Inf(-1)
+			}
+		}
+		part = append(part, idx)
+		right = math.Max(right, ll.rect.X.Max)
+	}
+	if len(part) > 0 {
+		parts = append(parts, part)
+	}
+	return
+}
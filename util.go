@@ -18,6 +18,20 @@ func Max4(a float64, b float64, c float64, d float64) float64 {
 	return math.Max(math.Max(a, b), math.Max(c, d))
 }
 
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
 // Epsilon is the smallest number below which we assume to be zero
 var Epsilon = math.Nextafter(1.0, 2.0) - 1
 
@@ -0,0 +1,182 @@
+package gfx
+
+import (
+	"math"
+	"sort"
+)
+
+// RegionSplit tells how a Region was divided into its children.
+type RegionSplit int
+
+const (
+	RegionLeaf RegionSplit = iota
+	RegionRows
+	RegionColumns
+)
+
+// Region is a node of an XY-cut tree. Children of a RegionRows region are
+// ordered top to bottom and those of a RegionColumns region left to right,
+// so walking the tree depth first visits regions in reading order. Indices
+// holds the indices of the input rects inside the region in that order.
+type Region struct {
+	Bounds   Rect
+	Split    RegionSplit
+	Indices  []int
+	Children []*Region
+}
+
+func (r *Region) IsLeaf() bool { return len(r.Children) == 0 }
+
+// Leaves returns the leaf regions below r in reading order.
+func (r *Region) Leaves() (leaves []*Region) {
+	if r.IsLeaf() {
+		return []*Region{r}
+	}
+	for _, child := range r.Children {
+		leaves = append(leaves, child.Leaves()...)
+	}
+	return
+}
+
+// XYCutOptions controls XY-cut segmentation. Gaps are in the units of the
+// rects being cut.
+type XYCutOptions struct {
+	// MinRowGap is the smallest height of a horizontal whitespace valley
+	// that separates rows.
+	MinRowGap float64
+	// MinColumnGap is the smallest width of a vertical whitespace valley
+	// that separates columns.
+	MinColumnGap float64
+	// MinRulingCoverage is the fraction of a region's width (or height) a
+	// ruling line must span to act as a separator. Zero means 0.8.
+	MinRulingCoverage float64
+}
+
+// XYCut recursively splits rects at whitespace valleys of their horizontal
+// and vertical projection profiles. Each region is first cut into rows at
+// every horizontal valley at least MinRowGap high; regions without one are
+// cut into columns at vertical valleys at least MinColumnGap wide. Rects in
+// regions that cannot be cut are ordered top to bottom, then left to right.
+// Coordinates are taken to be y-up.
+func XYCut(rects Rects, opts XYCutOptions) *Region {
+	return XYCutWithRulings(rects, nil, opts)
+}
+
+// XYCutWithRulings is like XYCut but also treats ruling lines as hard
+// separators: a horizontal or vertical ruling spanning most of a region cuts
+// it wherever the ruling lies in whitespace, however narrow the valley.
+func XYCutWithRulings(rects Rects, rulings Lines, opts XYCutOptions) *Region {
+	if opts.MinRulingCoverage <= 0 {
+		opts.MinRulingCoverage = 0.8
+	}
+
+	ids := make([]int, len(rects))
+	for i := range ids {
+		ids[i] = i
+	}
+	return xyCut(rects, ids, rulings, opts)
+}
+
+func xyCut(rects Rects, ids []int, rulings Lines, opts XYCutOptions) *Region {
+	region := &Region{Bounds: EmptyRect()}
+	for _, id := range ids {
+		region.Bounds = region.Bounds.Union(rects[id])
+	}
+
+	if len(ids) > 1 {
+		for _, split := range []RegionSplit{RegionRows, RegionColumns} {
+			parts := xyCutSplit(rects, ids, rulings, region.Bounds, split, opts)
+			if len(parts) < 2 {
+				continue
+			}
+
+			region.Split = split
+			for _, part := range parts {
+				child := xyCut(rects, part, rulings, opts)
+				region.Children = append(region.Children, child)
+				region.Indices = append(region.Indices, child.Indices...)
+			}
+			return region
+		}
+	}
+
+	region.Indices = append([]int(nil), ids...)
+	sort.SliceStable(region.Indices, func(i, j int) bool {
+		a, b := rects[region.Indices[i]], rects[region.Indices[j]]
+		if a.Y.Max != b.Y.Max {
+			return a.Y.Max > b.Y.Max
+		}
+		return a.X.Min < b.X.Min
+	})
+	return region
+}
+
+// xyCutSplit splits ids into rows (top to bottom) or columns (left to
+// right) at the valleys of the projection profile on the split axis.
+func xyCutSplit(rects Rects, ids []int, rulings Lines, bounds Rect, split RegionSplit, opts XYCutOptions) [][]int {
+	axis := func(r Rect) Range {
+		if split == RegionRows {
+			return r.Y
+		}
+		return r.X
+	}
+
+	spans := make([]Range, len(ids))
+	for i, id := range ids {
+		spans[i] = axis(rects[id])
+	}
+
+	minGap := opts.MinColumnGap
+	if split == RegionRows {
+		minGap = opts.MinRowGap
+	}
+
+	var cuts []float64
+	for _, gap := range MakeRangeSet(spans...).Gaps() {
+		if gap.Length() >= minGap || xyCutRuling(gap, rulings, bounds, split, opts.MinRulingCoverage) {
+			cuts = append(cuts, (gap.Min+gap.Max)/2)
+		}
+	}
+	if len(cuts) == 0 {
+		return nil
+	}
+
+	parts := make([][]int, len(cuts)+1)
+	for i, id := range ids {
+		k := sort.SearchFloat64s(cuts, spans[i].Min)
+		parts[k] = append(parts[k], id)
+	}
+	if split == RegionRows {
+		for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+			parts[i], parts[j] = parts[j], parts[i]
+		}
+	}
+	return parts
+}
+
+// xyCutRuling reports whether a ruling perpendicular to the split axis lies
+// in gap and spans at least coverage of the region.
+func xyCutRuling(gap Range, rulings Lines, bounds Rect, split RegionSplit, coverage float64) bool {
+	for _, line := range rulings {
+		var at float64
+		var span, extent Range
+		switch {
+		case split == RegionRows && line.IsHorizontal():
+			at = line.Start.Y
+			span, extent = MakeRange(math.Min(line.Start.X, line.End.X), math.Max(line.Start.X, line.End.X)), bounds.X
+		case split == RegionColumns && line.IsVertical():
+			at = line.Start.X
+			span, extent = MakeRange(math.Min(line.Start.Y, line.End.Y), math.Max(line.Start.Y, line.End.Y)), bounds.Y
+		default:
+			continue
+		}
+
+		if !gap.Contains(at) || extent.Length() <= 0 {
+			continue
+		}
+		if span.Intersection(extent).Length() >= coverage*extent.Length() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package gfx_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+func TestXYCutColumns(t *testing.T) {
+	// a header above two columns of two lines each, listed out of order
+	rects := gfx.Rects{
+		gfx.MakeRect(110, 60, 200, 70), // right, second line
+		gfx.MakeRect(0, 80, 90, 90),    // left, first line
+		gfx.MakeRect(0, 110, 200, 120), // header
+		gfx.MakeRect(110, 80, 200, 90), // right, first line
+		gfx.MakeRect(0, 60, 90, 70),    // left, second line
+	}
+
+	root := gfx.XYCut(rects, gfx.XYCutOptions{MinRowGap: 15, MinColumnGap: 15})
+	if got := fmt.Sprint(root.Indices); got != "[2 1 4 3 0]" {
+		t.Errorf("reading order = %v, want [2 1 4 3 0]", got)
+	}
+	if root.Split != gfx.RegionRows || len(root.Children) != 2 || root.Children[1].Split != gfx.RegionColumns {
+		t.Errorf("root split %v into %d regions", root.Split, len(root.Children))
+	}
+	if leaves := root.Leaves(); len(leaves) != 3 {
+		t.Errorf("%d leaves, want 3", len(leaves))
+	}
+}
+
+func TestXYCutRulings(t *testing.T) {
+	// two cells too close together to cut without a ruling between them
+	rects := gfx.Rects{gfx.MakeRect(12, 0, 20, 10), gfx.MakeRect(0, 0, 10, 10)}
+	opts := gfx.XYCutOptions{MinRowGap: 5, MinColumnGap: 5}
+
+	if root := gfx.XYCut(rects, opts); !root.IsLeaf() {
+		t.Errorf("cut without rulings into %d regions", len(root.Children))
+	}
+
+	short := gfx.Lines{gfx.MakeLine(11, 0, 11, 5)}
+	if root := gfx.XYCutWithRulings(rects, short, opts); !root.IsLeaf() {
+		t.Error("cut at a ruling spanning half the region")
+	}
+
+	full := gfx.Lines{gfx.MakeLine(11, -1, 11, 11)}
+	root := gfx.XYCutWithRulings(rects, full, opts)
+	if root.Split != gfx.RegionColumns || len(root.Children) != 2 {
+		t.Fatalf("split %v into %d regions at a full ruling", root.Split, len(root.Children))
+	}
+	if got := fmt.Sprint(root.Indices); got != "[1 0]" {
+		t.Errorf("reading order = %v, want [1 0]", got)
+	}
+}
+
+func TestXYCutEmpty(t *testing.T) {
+	root := gfx.XYCut(nil, gfx.XYCutOptions{})
+	if !root.IsLeaf() || len(root.Indices) != 0 || !root.Bounds.IsEmpty() {
+		t.Errorf("XYCut of nothing = %+v", root)
+	}
+	if leaves := root.Leaves(); len(leaves) != 1 || leaves[0] != root {
+		t.Errorf("Leaves = %v", leaves)
+	}
+}